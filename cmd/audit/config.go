@@ -0,0 +1,12 @@
+package main
+
+type Config struct {
+	DatabaseURL string
+	SqlitePath  string
+	Faculty     string
+	Type        string
+	Outcome     string
+	Since       string
+	Limit       int
+	Format      string
+}
@@ -1,8 +1,46 @@
 package main
 
+import "time"
+
 type Config struct {
 	Port        int
 	Workers     int
 	DatabaseURL string
 	Debug       bool
+	ReplyTTL    time.Duration
+	NotifyPort  int
+
+	// Stored responses answer retries for this long
+	ReplayWindow time.Duration
+
+	// Storage backend
+	Storage      string
+	SqlitePath   string
+	EventsPath   string
+	Classrooms   int
+	Laboratories int
+
+	// Offer expiration
+	Hold         time.Duration
+	ReapInterval time.Duration
+
+	// Semesters opened and registry loaded at startup
+	Semesters string
+	Registry  string
+
+	// Room choice
+	Strategy           string
+	SemesterStrategies string
+
+	// Fair-share allocation
+	FairShareWindow    time.Duration
+	FairShareWeights   string
+	FairShareSemesters string
+
+	// Failover
+	Mode        string
+	ControlPort int
+
+	// Privileged routes (disabled when empty)
+	AdminToken string
 }
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/go-zeromq/zmq4"
+	"github.com/rs/zerolog"
+)
+
+// client talks to the central servers following the Lazy Pirate pattern: every
+// attempt waits a bounded time for the reply, and a failed attempt throws the
+// socket away and retries against the next address in the list.
+type client struct {
+	identity  string
+	addresses []string
+	current   int
+	timeout   time.Duration
+	retries   int
+	sequence  int
+
+	logger     zerolog.Logger
+	serializer services.ModelSerializer
+}
+
+func newClient(identity string, addresses []string, timeout time.Duration, retries int, serializer services.ModelSerializer, logger zerolog.Logger) *client {
+	return &client{
+		identity:   identity,
+		addresses:  addresses,
+		timeout:    timeout,
+		retries:    retries,
+		logger:     logger,
+		serializer: serializer,
+	}
+}
+
+// Request sends a request and waits for its reply. Every attempt reuses the
+// same request ID so the server can recognise retries.
+func (c *client) Request(kind string, content interface{}) (*models.Response, []byte, error) {
+	c.sequence++
+
+	// 1. Encode the request
+	request := &models.Request{
+		ID:      c.sequence,
+		Type:    kind,
+		Content: content,
+	}
+
+	encoded, err := c.serializer.Encode(request)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	// 2. Try until a reply arrives or the retries run out
+	for attempt := 0; attempt <= c.retries; attempt++ {
+		address := c.addresses[c.current]
+
+		resp, raw, err := c.attempt(address, request.ID, encoded)
+		if err == nil {
+			return resp, raw, nil
+		}
+
+		// 2.1 Fail over to the next server for the following attempt
+		c.logger.Warn().Err(err).Msgf("Request %d to %q failed (attempt %d of %d)", request.ID, address, attempt+1, c.retries+1)
+		c.current = (c.current + 1) % len(c.addresses)
+	}
+
+	return nil, nil, fmt.Errorf("no reply for request %d after %d attempts", request.ID, c.retries+1)
+}
+
+func (c *client) attempt(address string, id int, encoded []byte) (*models.Response, []byte, error) {
+	// 1. Create a fresh dealer bound to the attempt deadline
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	dealer := zmq4.NewDealer(ctx, zmq4.WithID(zmq4.SocketIdentity(c.identity)))
+	defer dealer.Close()
+
+	if err := dealer.Dial(address); err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to server: %w", err)
+	}
+
+	// 2. Send the request
+	if err := dealer.Send(zmq4.NewMsgFrom([][]byte{encoded}...)); err != nil {
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+
+	// 3. Receive the response, skipping replies to earlier requests
+	for {
+		response, err := dealer.Recv()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to receive response: %w", err)
+		}
+
+		var resp models.Response
+		if err := c.serializer.Decode(response.Frames[0], &resp); err != nil {
+			return nil, nil, fmt.Errorf("failed to deserialize response: %w", err)
+		}
+
+		if resp.ID == id {
+			return &resp, response.Frames[0], nil
+		}
+
+		c.logger.Debug().Msgf("Discarding stale reply %d while waiting for %d", resp.ID, id)
+	}
+}
@@ -1,8 +1,16 @@
 package main
 
+import "time"
+
 type Config struct {
 	Faculties int
 	Address   string
+	Timeout   time.Duration
+	Retries   int
+
+	Partial     bool
+	Waitlist    bool
+	DeclineRate float64
 }
 
 var Faculties = []string{
@@ -14,7 +22,7 @@ var Faculties = []string{
 	"Medicina",
 	"Psicología",
 	"Ciencias Sociales",
-	"Derecho",
+	"Ciencias Jurídicas",
 	"Administracion",
 }
 
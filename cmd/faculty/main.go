@@ -1,16 +1,16 @@
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"math/rand/v2"
 	"os"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/foxinuni/distribuidos-central/internal/models"
 	"github.com/foxinuni/distribuidos-central/internal/services"
-	"github.com/go-zeromq/zmq4"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -19,7 +19,12 @@ var config Config
 
 func init() {
 	flag.IntVar(&config.Faculties, "faculties", 10, "Number of facultires")
-	flag.StringVar(&config.Address, "address", "tcp://127.0.0.1:5555", "The server address")
+	flag.StringVar(&config.Address, "address", "tcp://127.0.0.1:5555", "The server addresses, comma separated in failover order")
+	flag.DurationVar(&config.Timeout, "timeout", 5*time.Second, "Time to wait for each reply")
+	flag.IntVar(&config.Retries, "retries", 3, "Number of retries before giving up on a request")
+	flag.BoolVar(&config.Partial, "partial", false, "Accept partial allocations when rooms run short")
+	flag.BoolVar(&config.Waitlist, "waitlist", false, "Queue unmet demand in the waitlist")
+	flag.Float64Var(&config.DeclineRate, "decline-rate", 0, "Probability that a faculty declines its offer")
 	flag.Parse()
 
 	// Set up zerolog logger for debug and pretty print
@@ -53,20 +58,18 @@ func main() {
 func facultyWorker(id int, serializer *services.JsonModelSerializer) {
 	logger := log.With().Str("faculty", Faculties[id]).Logger()
 
-	// 1. Create the dealer
-	dealer := zmq4.NewDealer(context.Background())
-	defer dealer.Close()
-
-	if err := dealer.Dial(config.Address); err != nil {
-		logger.Fatal().Err(err).Msg("Failed to connect to server")
-	}
+	// 1. Create the client (the identity must survive reconnections, and never
+	// repeat across runs so old stored responses are not replayed)
+	identity := fmt.Sprintf("faculty-%d-%016x", id, rand.Uint64())
+	client := newClient(identity, strings.Split(config.Address, ","), config.Timeout, config.Retries, serializer, logger)
 
 	log.Info().Msgf("Starting faculty worker for %s", Faculties[id])
 
 	// 2. Open log file
 	file, err := os.OpenFile(fmt.Sprintf("logs/%s.json", Faculties[id]), os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		logger.Fatal().Err(err).Msg("Failed to open file")
+		logger.Error().Err(err).Msg("Failed to open file")
+		return
 	}
 	defer file.Close()
 
@@ -75,6 +78,8 @@ func facultyWorker(id int, serializer *services.JsonModelSerializer) {
 		content := &models.AllocateRequest{
 			Semester: "2025-1",
 			Faculty:  Faculties[id],
+			Partial:  config.Partial,
+			Waitlist: config.Waitlist,
 			Programs: []models.ProgramInfo{},
 		}
 
@@ -88,86 +93,42 @@ func facultyWorker(id int, serializer *services.JsonModelSerializer) {
 			content.Programs = append(content.Programs, info)
 		}
 
-		request := &models.Request{
-			ID:      id,
-			Type:    "allocate",
-			Content: content,
-		}
-
-		// 4. Encode the request
-		encoded, err := serializer.Encode(request)
-		if err != nil {
-			logger.Fatal().Err(err).Msg("Failed to serialize request")
-		}
-
-		// 5. Send the request
-		if err := dealer.Send(zmq4.NewMsgFrom([][]byte{encoded}...)); err != nil {
-			logger.Fatal().Err(err).Msg("Failed to send request")
-		}
-
-		// 6. Receive the response
-		response, err := dealer.Recv()
+		// 4. Send the request and wait for the response
+		resp, raw, err := client.Request("allocate", content)
 		if err != nil {
-			logger.Fatal().Err(err).Msg("Failed to receive response")
-		}
-
-		// 7. Decode the response
-		var resp models.Response
-		if err := serializer.Decode(response.Frames[0], &resp); err != nil {
-			logger.Fatal().Err(err).Msg("Failed to deserialize response")
+			logger.Error().Err(err).Msg("Failed to allocate rooms")
+			return
 		}
 
-		// 8. Print the response
+		// 5. Print the response
 		logger.Info().Interface("response", resp).Msg("Received response")
 
-		// 9. Write to a file
-		if _, err := file.Write(response.Frames[0]); err != nil {
+		// 6. Write to a file
+		if _, err := file.Write(raw); err != nil {
 			logger.Warn().Err(err).Msg("Failed to write to file")
 		}
 	}
 
 	{
-		// 10. Confirm request
+		// 7. Confirm (or decline) request
 		content := &models.ConfirmRequest{
 			Semester: "2025-1",
 			Faculty:  Faculties[id],
-			Accept:   true,
-		}
-
-		request := &models.Request{
-			ID:      id,
-			Type:    "confirm",
-			Content: content,
-		}
-
-		// 11. Encode the request
-		encoded, err := serializer.Encode(request)
-		if err != nil {
-			logger.Fatal().Err(err).Msg("Failed to serialize request")
-		}
-
-		// 12. Send the request
-		if err := dealer.Send(zmq4.NewMsgFrom([][]byte{encoded}...)); err != nil {
-			logger.Fatal().Err(err).Msg("Failed to send request")
+			Accept:   rand.Float64() >= config.DeclineRate,
 		}
 
-		// 13. Receive the response
-		response, err := dealer.Recv()
+		// 8. Send the request and wait for the response
+		resp, raw, err := client.Request("confirm", content)
 		if err != nil {
-			logger.Fatal().Err(err).Msg("Failed to receive response")
-		}
-
-		// 14. Decode the response
-		var resp models.Response
-		if err := serializer.Decode(response.Frames[0], &resp); err != nil {
-			logger.Fatal().Err(err).Msg("Failed to deserialize response")
+			logger.Error().Err(err).Msg("Failed to confirm allocation")
+			return
 		}
 
-		// 15. Print the response
+		// 9. Print the response
 		logger.Info().Interface("response", resp).Msg("Received response")
 
-		// 16. Write to a file
-		if _, err := file.Write(response.Frames[0]); err != nil {
+		// 10. Write to a file
+		if _, err := file.Write(raw); err != nil {
 			logger.Warn().Err(err).Msg("Failed to write to file")
 		}
 	}
@@ -0,0 +1,11 @@
+package main
+
+import "time"
+
+type Config struct {
+	Address  string
+	Backup   string
+	Interval time.Duration
+	Timeout  time.Duration
+	Retries  int
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"os"
 	"time"
 
@@ -11,58 +13,96 @@ import (
 	"gopkg.in/zeromq/goczmq.v4"
 )
 
+var config Config
+
 func init() {
+	flag.StringVar(&config.Address, "address", "tcp://127.0.0.1:5555", "The primary server address")
+	flag.StringVar(&config.Backup, "backup", "", "The backup server control address (empty disables failover)")
+	flag.DurationVar(&config.Interval, "interval", 1*time.Second, "Time between heartbeats")
+	flag.DurationVar(&config.Timeout, "timeout", 1*time.Second, "Time to wait for a heartbeat reply")
+	flag.IntVar(&config.Retries, "retries", 3, "Missed heartbeats before promoting the backup")
+	flag.Parse()
+
 	// Set up zerolog logger for debug and pretty print
 	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
 	zerolog.SetGlobalLevel(zerolog.DebugLevel)
 }
 
 func main() {
+	log.Info().Msgf("Monitoring %q (backup: %q, retries: %d)", config.Address, config.Backup, config.Retries)
+
 	// 1. Create the serializer
 	serializer := services.NewJsonModelSerializer()
 
-	// 2. Create the dealer
-	dealer, err := goczmq.NewDealer("tcp://localhost:5555")
+	// 2. Heartbeat the primary, promoting the backup once it stops answering
+	misses := 0
+	promoted := false
+	for id := 1; ; id++ {
+		log.Info().Msg("Sending heartbeat request to server")
+		resp, err := send(serializer, config.Address, &models.Request{ID: id, Type: "health-check"})
+		if err != nil {
+			misses++
+			log.Warn().Err(err).Msgf("Missed heartbeat (%d of %d)", misses, config.Retries)
+		} else {
+			misses = 0
+			log.Info().Interface("response", resp).Msg("Received response")
+		}
+
+		// 2.1 Promote the backup when the primary is considered dead
+		if misses >= config.Retries && config.Backup != "" && !promoted {
+			log.Warn().Msgf("Primary unreachable, promoting backup at %q", config.Backup)
+
+			resp, err := send(serializer, config.Backup, &models.Request{ID: id, Type: "promote"})
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to promote backup")
+			} else if !resp.Success {
+				log.Error().Msgf("Backup refused promotion: %s", resp.Error)
+			} else {
+				log.Info().Msg("Backup promoted, monitoring it on the primary address")
+				promoted = true
+				misses = 0
+			}
+		}
+
+		// Sleep before sending the next request
+		time.Sleep(config.Interval)
+	}
+}
+
+// send performs a single request with a receive timeout. A fresh socket is
+// used every time so a lost reply never leaves a stale one queued.
+func send(serializer services.ModelSerializer, address string, request *models.Request) (*models.Response, error) {
+	// 1. Create the dealer
+	dealer, err := goczmq.NewDealer(address)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to create dealer socket")
+		return nil, fmt.Errorf("failed to create dealer socket: %w", err)
 	}
 	defer dealer.Destroy()
 
-	// 3. Send healthcheck request
-	request := &models.Request{
-		ID:   1,
-		Type: "health-check",
-	}
-	
+	dealer.SetLinger(0)
+	dealer.SetRcvtimeo(int(config.Timeout.Milliseconds()))
+
+	// 2. Encode and send the request
 	encoded, err := serializer.Encode(request)
 	if err != nil {
-		log.Fatal().Err(err).Msg("Failed to serialize request")
+		return nil, fmt.Errorf("failed to serialize request: %w", err)
 	}
 
-	for {
-		log.Info().Msg("Sending heartbeat request to server")
-		if err := dealer.SendMessage([][]byte{encoded}); err != nil {
-			log.Warn().Err(err).Msg("Failed to send request")
-		}
-
-		response, err := dealer.RecvMessage()
-		if err != nil {
-			log.Warn().Err(err).Msg("Failed to receive response")
-			continue
-		}
-
-		// Deserialize the response
-		var resp models.Response
-		if err := serializer.Decode(response[0], &resp); err != nil {
-			log.Warn().Err(err).Msg("Failed to deserialize response")
-			continue
-		}
-
-		// Print the response
-		log.Info().Interface("response", resp).Msg("Received response")
+	if err := dealer.SendMessage([][]byte{encoded}); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
 
-		// Sleep for 1 second before sending the next request
-		time.Sleep(1 * time.Second)
+	// 3. Receive the response (fails once the timeout elapses)
+	response, err := dealer.RecvMessage()
+	if err != nil {
+		return nil, fmt.Errorf("failed to receive response: %w", err)
+	}
 
+	// 4. Deserialize the response
+	var resp models.Response
+	if err := serializer.Decode(response[0], &resp); err != nil {
+		return nil, fmt.Errorf("failed to deserialize response: %w", err)
 	}
+
+	return &resp, nil
 }
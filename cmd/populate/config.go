@@ -4,4 +4,5 @@ type Config struct {
 	Classrooms   int
 	Laboratories int
 	DatabaseURL  string
+	SqlitePath   string
 }
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/rs/zerolog/log"
+)
+
+// runCommand runs a subcommand given after the flags, e.g.
+// "populate outages add -room AUL-001 -from 2025-03-01 -to 2025-04-01".
+func runCommand(service services.OutageService, args []string) {
+	if args[0] != "outages" || len(args) < 2 {
+		log.Fatal().Msgf("Unknown command %q (expected outages add, outages list or outages remove)", strings.Join(args, " "))
+	}
+
+	switch args[1] {
+	case "add":
+		addOutage(service, args[2:])
+	case "list":
+		listOutages(service, args[2:])
+	case "remove":
+		removeOutage(service, args[2:])
+	default:
+		log.Fatal().Msgf("Unknown outages command %q (expected add, list or remove)", args[1])
+	}
+}
+
+// addOutage declares an outage and warns about the allocations it overlaps.
+func addOutage(service services.OutageService, args []string) {
+	var room, from, to, reason string
+
+	flags := flag.NewFlagSet("outages add", flag.ExitOnError)
+	flags.StringVar(&room, "room", "", "Room out of service (e.g. AUL-001)")
+	flags.StringVar(&from, "from", "", "Start of the outage (RFC 3339 or YYYY-MM-DD, defaults to now)")
+	flags.StringVar(&to, "to", "", "End of the outage (RFC 3339 or YYYY-MM-DD, exclusive)")
+	flags.StringVar(&reason, "reason", "", "Why the room is out of service")
+	flags.Parse(args)
+
+	// 1. Parse the period
+	startsAt := time.Now()
+	if from != "" {
+		startsAt = parseTime("from", from)
+	}
+
+	if to == "" {
+		log.Fatal().Msg("The end of the outage (-to) is required")
+	}
+
+	// 2. Declare it
+	response, err := service.DeclareOutage(context.Background(), &models.OutageRequest{
+		Room:     room,
+		StartsAt: startsAt,
+		EndsAt:   parseTime("to", to),
+		Reason:   reason,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to declare outage")
+	}
+
+	log.Info().Msgf("Declared outage %d for room %s (%s to %s)",
+		response.Outage.ID,
+		response.Outage.Room,
+		response.Outage.StartsAt.Format(time.RFC3339),
+		response.Outage.EndsAt.Format(time.RFC3339),
+	)
+
+	// 3. Report where the allocations went, or that they move once it starts
+	for _, relocations := range response.Relocations {
+		for _, relocation := range relocations.Relocated {
+			log.Info().Msgf("Moved faculty %q (program %q) from %s to %s in semester %q",
+				relocation.Faculty,
+				relocation.Program,
+				relocation.Previous,
+				relocation.Room,
+				relocations.Semester,
+			)
+		}
+
+		for _, relocation := range relocations.Unplaced {
+			log.Warn().Msgf("No equivalent room is free for faculty %q (program %q) in semester %q, it stays in %s",
+				relocation.Faculty,
+				relocation.Program,
+				relocations.Semester,
+				relocation.Previous,
+			)
+		}
+	}
+
+	if len(response.Relocations) == 0 {
+		for _, conflict := range response.Conflicts {
+			log.Warn().Msgf("Room %s is %s by faculty %q (program %q) in semester %q and will be relocated when the outage starts",
+				response.Outage.Room,
+				conflict.State,
+				conflict.Faculty,
+				conflict.Program,
+				conflict.Semester,
+			)
+		}
+	}
+}
+
+func listOutages(service services.OutageService, args []string) {
+	var all bool
+
+	flags := flag.NewFlagSet("outages list", flag.ExitOnError)
+	flags.BoolVar(&all, "all", false, "Include the outages that already ended")
+	flags.Parse(args)
+
+	response, err := service.ListOutages(context.Background(), &models.OutageListRequest{All: all})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to list outages")
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "ID\tROOM\tFROM\tTO\tREASON")
+	for _, outage := range response.Outages {
+		fmt.Fprintf(writer, "%d\t%s\t%s\t%s\t%s\n",
+			outage.ID,
+			outage.Room,
+			outage.StartsAt.Format(time.RFC3339),
+			outage.EndsAt.Format(time.RFC3339),
+			outage.Reason,
+		)
+	}
+	writer.Flush()
+}
+
+func removeOutage(service services.OutageService, args []string) {
+	var id int
+
+	flags := flag.NewFlagSet("outages remove", flag.ExitOnError)
+	flags.IntVar(&id, "id", 0, "Outage to remove (as shown by outages list)")
+	flags.Parse(args)
+
+	outage, err := service.RemoveOutage(context.Background(), &models.OutageRemoveRequest{ID: id})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to remove outage")
+	}
+
+	log.Info().Msgf("Removed outage %d for room %s", outage.ID, outage.Room)
+}
+
+// parseTime reads an RFC 3339 timestamp or a local date.
+func parseTime(name string, value string) time.Time {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed
+	}
+
+	parsed, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+	if err != nil {
+		log.Fatal().Msgf("Invalid -%s %q (expected RFC 3339 or YYYY-MM-DD)", name, value)
+	}
+
+	return parsed
+}
@@ -0,0 +1,11 @@
+package main
+
+type Config struct {
+	DatabaseURL string
+	SqlitePath  string
+	EventsPath  string
+	At          string
+	Semester    string
+	Faculty     string
+	Json        bool
+}
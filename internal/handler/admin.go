@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+)
+
+// admin guards a privileged route. The request content must carry the admin
+// token, which is removed before the handler sees it. Admin routes are off
+// unless a token is set, and require the audit trail so every use is recorded.
+func (s *Server) admin(handler RouteHandler) RouteHandler {
+	return func(ctx context.Context, body interface{}) (interface{}, error) {
+		if s.adminToken == "" {
+			return nil, fmt.Errorf("admin routes are disabled")
+		}
+
+		if s.auditor == nil {
+			return nil, fmt.Errorf("admin routes require the audit trail")
+		}
+
+		content, ok := body.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid admin credential")
+		}
+
+		token, _ := content["token"].(string)
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) != 1 {
+			return nil, fmt.Errorf("invalid admin credential")
+		}
+
+		// The token must not reach the logs
+		stripped := make(map[string]interface{}, len(content))
+		for key, value := range content {
+			if key != "token" {
+				stripped[key] = value
+			}
+		}
+
+		return handler(ctx, stripped)
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+// auditBuffer is how many records may wait for the audit writer before
+// workers have to wait for it too.
+const auditBuffer = 1024
+
+// audit queues a request for the audit trail. The request is nil when it could
+// not be parsed, the response is nil unless the request succeeded.
+func (s *Server) audit(identity string, payload []byte, request *models.Request, response interface{}, outcome string, err error, started time.Time) {
+	if s.auditor == nil {
+		return
+	}
+
+	// 1. Describe the request
+	hash := sha256.Sum256(payload)
+	record := &models.AuditRecord{
+		Identity:    printableIdentity(identity),
+		PayloadHash: hex.EncodeToString(hash[:]),
+		Outcome:     outcome,
+		LatencyUs:   time.Since(started).Microseconds(),
+	}
+
+	if request != nil {
+		record.RequestID = request.ID
+		record.Type = request.Type
+
+		if content, ok := request.Content.(map[string]interface{}); ok {
+			record.Faculty, _ = content["faculty"].(string)
+		}
+	}
+
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	auditChanges(record, response)
+
+	// 2. Hand it to the writer, so workers do not wait for the database
+	s.audits <- record
+}
+
+// auditWriter stores the queued records until the queue is closed. A failure
+// must not affect the reply, which was already sent.
+func (s *Server) auditWriter() {
+	defer close(s.auditDone)
+
+	for record := range s.audits {
+		if err := s.auditor.Record(context.Background(), record); err != nil {
+			log.Error().Err(err).Msgf("Failed to record request %d in the audit trail", record.RequestID)
+		}
+	}
+}
+
+// auditChanges adds what an admin change touched to its record, taking the
+// faculty from the change rather than from the request.
+func auditChanges(record *models.AuditRecord, response interface{}) {
+	switch change := response.(type) {
+	case *models.AdminAllocation:
+		record.Semester = change.Semester
+		record.Faculty = change.Faculty
+		record.Program = change.Program
+		record.Room = change.Room
+	case *models.AdminBlockResponse:
+		record.Semester = change.Semester
+		record.Room = change.Room
+	}
+}
+
+// printableIdentity returns the ROUTER identity as text, or in hex when it is
+// binary (ZeroMQ generates binary identities for clients that set none).
+func printableIdentity(identity string) string {
+	if !utf8.ValidString(identity) {
+		return hex.EncodeToString([]byte(identity))
+	}
+
+	for _, r := range identity {
+		if !unicode.IsPrint(r) {
+			return hex.EncodeToString([]byte(identity))
+		}
+	}
+
+	return identity
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type AdminController struct {
+	service services.AdminService
+}
+
+func NewAdminController(service services.AdminService) *AdminController {
+	return &AdminController{
+		service: service,
+	}
+}
+
+func (c *AdminController) Assign(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.AdminAssignRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received AdminAssignRequest: %+v", req)
+	return c.service.AdminAssign(ctx, req)
+}
+
+func (c *AdminController) Revoke(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.AdminRevokeRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received AdminRevokeRequest: %+v", req)
+	return c.service.AdminRevoke(ctx, req)
+}
+
+func (c *AdminController) BlockRoom(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.AdminBlockRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received AdminBlockRequest: %+v", req)
+	return c.service.AdminBlockRoom(ctx, req)
+}
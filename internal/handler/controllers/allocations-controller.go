@@ -20,22 +20,52 @@ func NewAllocationsController(service services.AllocationService) *AllocationsCo
 	}
 }
 
-func (c *AllocationsController) Allocate(body interface{}) (interface{}, error) {
+func (c *AllocationsController) Allocate(ctx context.Context, body interface{}) (interface{}, error) {
 	req := &models.AllocateRequest{}
 	if err := mapstructure.Decode(body, req); err != nil {
 		return nil, fmt.Errorf("failed to decode request: %w", err)
 	}
 
 	log.Info().Msgf("Received AllocateRequest: %+v", req)
-	return c.service.Allocate(context.Background(), req)
+	return c.service.Allocate(ctx, req)
 }
 
-func (c *AllocationsController) Confirm(body interface{}) (interface{}, error) {
+func (c *AllocationsController) Confirm(ctx context.Context, body interface{}) (interface{}, error) {
 	req := &models.ConfirmRequest{}
 	if err := mapstructure.Decode(body, req); err != nil {
 		return nil, fmt.Errorf("failed to decode request: %w", err)
 	}
 
 	log.Info().Msgf("Received ConfirmRequest: %+v", req)
-	return c.service.Confirm(context.Background(), req)
+	return c.service.Confirm(ctx, req)
+}
+
+func (c *AllocationsController) Quote(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.AllocateRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received QuoteRequest: %+v", req)
+	return c.service.Quote(ctx, req)
+}
+
+func (c *AllocationsController) Cancel(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.CancelRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received CancelRequest: %+v", req)
+	return c.service.Cancel(ctx, req)
+}
+
+func (c *AllocationsController) Modify(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.ModifyRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received ModifyRequest: %+v", req)
+	return c.service.Modify(ctx, req)
 }
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+// defaultAuditLimit caps the records returned when a request sets no limit,
+// so replies stay small.
+const defaultAuditLimit = 100
+
+type AuditController struct {
+	service services.AuditService
+}
+
+func NewAuditController(service services.AuditService) *AuditController {
+	return &AuditController{
+		service: service,
+	}
+}
+
+func (c *AuditController) Audit(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.AuditRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	if req.Limit <= 0 {
+		req.Limit = defaultAuditLimit
+	}
+
+	log.Info().Msgf("Received AuditRequest: %+v", req)
+	return c.service.Audit(ctx, req)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type BatchController struct {
+	service services.BatchAllocationService
+}
+
+func NewBatchController(service services.BatchAllocationService) *BatchController {
+	return &BatchController{
+		service: service,
+	}
+}
+
+func (c *BatchController) AllocateBatch(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.BatchAllocateRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received BatchAllocateRequest: %+v", req)
+	return c.service.AllocateBatch(ctx, req)
+}
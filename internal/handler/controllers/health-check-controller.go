@@ -1,11 +1,13 @@
 package controllers
 
+import "context"
+
 type HealthCheckController struct{}
 
 func NewHealthCheckController() *HealthCheckController {
 	return &HealthCheckController{}
 }
 
-func (c *HealthCheckController) HealthCheck(_ interface{}) (interface{}, error) {
+func (c *HealthCheckController) HealthCheck(_ context.Context, _ interface{}) (interface{}, error) {
 	return "OK", nil
 }
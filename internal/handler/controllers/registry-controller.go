@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type RegistryController struct {
+	service services.RegistryService
+}
+
+func NewRegistryController(service services.RegistryService) *RegistryController {
+	return &RegistryController{
+		service: service,
+	}
+}
+
+func (c *RegistryController) RegisterFaculty(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.RegisterFacultyRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received RegisterFacultyRequest: %+v", req)
+	return c.service.RegisterFaculty(ctx, req)
+}
+
+func (c *RegistryController) SetQuota(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.FacultyQuota{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received FacultyQuota: %+v", req)
+	return c.service.SetQuota(ctx, req)
+}
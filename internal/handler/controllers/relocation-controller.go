@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type RelocationController struct {
+	service services.RelocationService
+}
+
+func NewRelocationController(service services.RelocationService) *RelocationController {
+	return &RelocationController{
+		service: service,
+	}
+}
+
+func (c *RelocationController) Relocate(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.RelocateRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received RelocateRequest: %+v", req)
+	return c.service.Relocate(ctx, req)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type RenewalController struct {
+	service services.RenewalService
+}
+
+func NewRenewalController(service services.RenewalService) *RenewalController {
+	return &RenewalController{
+		service: service,
+	}
+}
+
+func (c *RenewalController) Renew(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.RenewRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received RenewRequest: %+v", req)
+	return c.service.Renew(ctx, req)
+}
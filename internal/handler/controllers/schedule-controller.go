@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type ScheduleController struct {
+	service services.ScheduleService
+}
+
+func NewScheduleController(service services.ScheduleService) *ScheduleController {
+	return &ScheduleController{
+		service: service,
+	}
+}
+
+func (c *ScheduleController) Schedule(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.ScheduleRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received ScheduleRequest: %+v", req)
+	return c.service.Schedule(ctx, req)
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type SemesterController struct {
+	service services.SemesterService
+}
+
+func NewSemesterController(service services.SemesterService) *SemesterController {
+	return &SemesterController{
+		service: service,
+	}
+}
+
+func (c *SemesterController) Create(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.SemesterRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received SemesterRequest (create): %+v", req)
+	return c.service.CreateSemester(ctx, req)
+}
+
+func (c *SemesterController) Open(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.SemesterRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received SemesterRequest (open): %+v", req)
+	return c.service.OpenSemester(ctx, req)
+}
+
+func (c *SemesterController) Close(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.SemesterRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received SemesterRequest (close): %+v", req)
+	return c.service.CloseSemester(ctx, req)
+}
+
+func (c *SemesterController) Archive(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.SemesterRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received SemesterRequest (archive): %+v", req)
+	return c.service.ArchiveSemester(ctx, req)
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type SwapController struct {
+	service services.SwapService
+}
+
+func NewSwapController(service services.SwapService) *SwapController {
+	return &SwapController{
+		service: service,
+	}
+}
+
+func (c *SwapController) Propose(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.SwapProposal{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received SwapProposal: %+v", req)
+	return c.service.ProposeSwap(ctx, req)
+}
+
+func (c *SwapController) Respond(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.SwapDecision{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received SwapDecision: %+v", req)
+	return c.service.RespondSwap(ctx, req)
+}
+
+func (c *SwapController) List(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.SwapListRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received SwapListRequest: %+v", req)
+	return c.service.ListSwaps(ctx, req)
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog/log"
+)
+
+type WaitlistController struct {
+	service services.WaitlistService
+}
+
+func NewWaitlistController(service services.WaitlistService) *WaitlistController {
+	return &WaitlistController{
+		service: service,
+	}
+}
+
+func (c *WaitlistController) Status(ctx context.Context, body interface{}) (interface{}, error) {
+	req := &models.WaitlistStatusRequest{}
+	if err := mapstructure.Decode(body, req); err != nil {
+		return nil, fmt.Errorf("failed to decode request: %w", err)
+	}
+
+	log.Info().Msgf("Received WaitlistStatusRequest: %+v", req)
+	return c.service.WaitlistStatus(ctx, req)
+}
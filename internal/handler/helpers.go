@@ -1,9 +1,12 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 
 	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
 	"github.com/rs/zerolog/log"
 )
 
@@ -24,15 +27,16 @@ func (s *Server) parseRequest(request [][]byte) (*models.Request, string, error)
 	return &req, identity, nil
 }
 
-func (s *Server) processRequest(request *models.Request) (interface{}, error) {
+func (s *Server) processRequest(identity string, request *models.Request) (interface{}, error) {
 	// Find the handler for the request type
 	handler, ok := s.routes[request.Type]
 	if !ok {
 		return nil, fmt.Errorf("no handler found for request type: %s", request.Type)
 	}
 
-	// Call the handler
-	return handler(request.Content)
+	// Call the handler, letting services know who sent the request
+	ctx := services.WithRequestKey(context.Background(), identity, request.ID)
+	return handler(ctx, request.Content)
 }
 
 func (s *Server) generateErrorResponse(identity string, id int, handler string, err error) [][]byte {
@@ -43,6 +47,12 @@ func (s *Server) generateErrorResponse(identity string, id int, handler string,
 		Error:   err.Error(),
 	}
 
+	// Errors that carry details send them as the content
+	var detailed interface{ Details() interface{} }
+	if errors.As(err, &detailed) {
+		response.Content = detailed.Details()
+	}
+
 	// Serialize the response
 	encoded, err := s.serializer.Encode(response)
 	if err != nil {
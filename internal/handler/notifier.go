@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/zeromq/goczmq.v4"
+)
+
+// Notifier publishes notifications on a PUB socket. Each message is sent with
+// the faculty name as its topic, so a faculty subscribes only to its own.
+type Notifier struct {
+	port int
+
+	// external
+	socket     *goczmq.Channeler
+	serializer services.ModelSerializer
+}
+
+func NewNotifier(port int, serializer services.ModelSerializer) *Notifier {
+	return &Notifier{
+		port:       port,
+		serializer: serializer,
+	}
+}
+
+func (n *Notifier) Start() error {
+	log.Info().Msgf("Starting notification socket on port %d", n.port)
+
+	// Start the socket
+	n.socket = goczmq.NewPubChanneler(fmt.Sprintf("tcp://*:%d", n.port))
+	if n.socket == nil {
+		return fmt.Errorf("failed to create notification socket")
+	}
+
+	return nil
+}
+
+func (n *Notifier) Stop() {
+	// Shutdown the socket
+	if n.socket != nil {
+		n.socket.Destroy()
+	}
+
+	log.Info().Msg("Notification socket closed.")
+}
+
+func (n *Notifier) Notify(notification *models.Notification) {
+	if n.socket == nil {
+		log.Warn().Msgf("Dropping %s notification for %q, notifier not started", notification.Type, notification.Faculty)
+		return
+	}
+
+	// Serialize the notification
+	encoded, err := n.serializer.Encode(notification)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to serialize notification")
+		return
+	}
+
+	log.Info().Msgf("Notifying %q: %s", notification.Faculty, notification.Type)
+	n.socket.SendChan <- [][]byte{[]byte(notification.Faculty), encoded}
+}
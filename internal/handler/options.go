@@ -1,5 +1,11 @@
 package handler
 
+import (
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/services"
+)
+
 type ServerOptions func(*Server)
 
 func WithPort(port int) ServerOptions {
@@ -13,3 +19,33 @@ func WithWorkerCount(workerCount int) ServerOptions {
 		c.workers = workerCount
 	}
 }
+
+func WithReplyCacheTTL(ttl time.Duration) ServerOptions {
+	return func(c *Server) {
+		c.replyTTL = ttl
+	}
+}
+
+// WithAuditService records every request received in the given audit trail.
+func WithAuditService(auditor services.AuditService) ServerOptions {
+	return func(c *Server) {
+		c.auditor = auditor
+	}
+}
+
+// WithAdminToken enables the admin routes for requests carrying the token.
+func WithAdminToken(token string) ServerOptions {
+	return func(c *Server) {
+		c.adminToken = token
+	}
+}
+
+// WithDeferredRoutes answers the given request types on their own goroutine,
+// so requests that wait (like fair-share allocations) do not hold a worker.
+func WithDeferredRoutes(types ...string) ServerOptions {
+	return func(c *Server) {
+		for _, kind := range types {
+			c.deferred[kind] = true
+		}
+	}
+}
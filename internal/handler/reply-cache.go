@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type cachedReply struct {
+	reply   [][]byte
+	created time.Time
+}
+
+// replyCache remembers the last replies sent to each client so a request that
+// is retried after a lost reply is answered again instead of re-executed.
+type replyCache struct {
+	mutex     sync.Mutex
+	ttl       time.Duration
+	lastSweep time.Time
+	entries   map[string]*cachedReply
+}
+
+func newReplyCache(ttl time.Duration) *replyCache {
+	return &replyCache{
+		ttl:       ttl,
+		lastSweep: time.Now(),
+		entries:   make(map[string]*cachedReply),
+	}
+}
+
+func replyKey(identity string, id int) string {
+	return fmt.Sprintf("%x/%d", identity, id)
+}
+
+// begin reserves the (identity, id) pair for a new request. If the pair was
+// already seen it reports a duplicate, returning the stored reply or nil when
+// the original request is still being processed.
+func (c *replyCache) begin(identity string, id int) ([][]byte, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.sweep()
+
+	key := replyKey(identity, id)
+	if entry, ok := c.entries[key]; ok {
+		return entry.reply, true
+	}
+
+	c.entries[key] = &cachedReply{created: time.Now()}
+	return nil, false
+}
+
+// complete stores the reply sent for a request reserved with begin.
+func (c *replyCache) complete(identity string, id int, reply [][]byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	key := replyKey(identity, id)
+	if entry, ok := c.entries[key]; ok {
+		entry.reply = reply
+	}
+}
+
+// sweep drops expired entries, at most once per ttl. Must be called with the
+// mutex held.
+func (c *replyCache) sweep() {
+	now := time.Now()
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+
+	for key, entry := range c.entries {
+		if now.Sub(entry.created) >= c.ttl {
+			delete(c.entries, key)
+		}
+	}
+
+	c.lastSweep = now
+}
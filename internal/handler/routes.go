@@ -1,9 +1,32 @@
 package handler
 
-type RouteHandler func(interface{}) (interface{}, error)
+import "context"
+
+type RouteHandler func(context.Context, interface{}) (interface{}, error)
 
 func (s *Server) registerRoutes() {
 	s.routes["health-check"] = s.healthCheckController.HealthCheck
 	s.routes["allocate"] = s.allocationsController.Allocate
+	s.routes["allocate-batch"] = s.batchController.AllocateBatch
+	s.routes["renew"] = s.renewalController.Renew
 	s.routes["confirm"] = s.allocationsController.Confirm
+	s.routes["quote"] = s.allocationsController.Quote
+	s.routes["cancel"] = s.allocationsController.Cancel
+	s.routes["modify"] = s.allocationsController.Modify
+	s.routes["waitlist-status"] = s.waitlistController.Status
+	s.routes["schedule"] = s.scheduleController.Schedule
+	s.routes["audit"] = s.admin(s.auditController.Audit)
+	s.routes["semester-create"] = s.admin(s.semesterController.Create)
+	s.routes["semester-open"] = s.admin(s.semesterController.Open)
+	s.routes["semester-close"] = s.admin(s.semesterController.Close)
+	s.routes["semester-archive"] = s.admin(s.semesterController.Archive)
+	s.routes["register-faculty"] = s.admin(s.registryController.RegisterFaculty)
+	s.routes["set-quota"] = s.admin(s.registryController.SetQuota)
+	s.routes["swap-propose"] = s.swapController.Propose
+	s.routes["swap-respond"] = s.swapController.Respond
+	s.routes["swap-list"] = s.swapController.List
+	s.routes["admin-assign"] = s.admin(s.adminController.Assign)
+	s.routes["admin-revoke"] = s.admin(s.adminController.Revoke)
+	s.routes["admin-block-room"] = s.admin(s.adminController.BlockRoom)
+	s.routes["admin-relocate"] = s.admin(s.relocationController.Relocate)
 }
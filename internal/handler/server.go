@@ -3,8 +3,10 @@ package handler
 import (
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/foxinuni/distribuidos-central/internal/handler/controllers"
+	"github.com/foxinuni/distribuidos-central/internal/models"
 	"github.com/foxinuni/distribuidos-central/internal/services"
 	"github.com/rs/zerolog/log"
 	"gopkg.in/zeromq/goczmq.v4"
@@ -14,42 +16,87 @@ type Server struct {
 	// internal
 	port      int
 	workers   int
+	replyTTL  time.Duration
 	waitgroup sync.WaitGroup
+	replies   *replyCache
+
+	adminToken string
+	deferred   map[string]bool
 
 	stopch   chan struct{}
 	requests chan [][]byte
 	routes   map[string]RouteHandler
 
+	audits    chan *models.AuditRecord
+	auditDone chan struct{}
+
 	// controllers
 	healthCheckController *controllers.HealthCheckController
 	allocationsController *controllers.AllocationsController
+	waitlistController    *controllers.WaitlistController
+	scheduleController    *controllers.ScheduleController
+	auditController       *controllers.AuditController
+	semesterController    *controllers.SemesterController
+	registryController    *controllers.RegistryController
+	batchController       *controllers.BatchController
+	renewalController     *controllers.RenewalController
+	swapController        *controllers.SwapController
+	adminController       *controllers.AdminController
+	relocationController  *controllers.RelocationController
 
 	// external
 	socket     *goczmq.Channeler
 	serializer services.ModelSerializer
+	auditor    services.AuditService
 }
 
 func NewServer(
 	healthCheckController *controllers.HealthCheckController,
 	allocationsController *controllers.AllocationsController,
+	waitlistController *controllers.WaitlistController,
+	scheduleController *controllers.ScheduleController,
+	auditController *controllers.AuditController,
+	semesterController *controllers.SemesterController,
+	registryController *controllers.RegistryController,
+	batchController *controllers.BatchController,
+	renewalController *controllers.RenewalController,
+	swapController *controllers.SwapController,
+	adminController *controllers.AdminController,
+	relocationController *controllers.RelocationController,
 	serializer services.ModelSerializer,
 	options ...ServerOptions,
 ) *Server {
 	server := &Server{
 		port:                  5555,
 		workers:               10,
+		replyTTL:              5 * time.Minute,
 		requests:              make(chan [][]byte),
 		stopch:                make(chan struct{}),
+		audits:                make(chan *models.AuditRecord, auditBuffer),
+		auditDone:             make(chan struct{}),
 		routes:                make(map[string]RouteHandler),
+		deferred:              make(map[string]bool),
 		serializer:            serializer,
 		healthCheckController: healthCheckController,
 		allocationsController: allocationsController,
+		waitlistController:    waitlistController,
+		scheduleController:    scheduleController,
+		auditController:       auditController,
+		semesterController:    semesterController,
+		registryController:    registryController,
+		batchController:       batchController,
+		renewalController:     renewalController,
+		swapController:        swapController,
+		adminController:       adminController,
+		relocationController:  relocationController,
 	}
 
 	for _, applyOption := range options {
 		applyOption(server)
 	}
 
+	server.replies = newReplyCache(server.replyTTL)
+
 	server.registerRoutes()
 
 	return server
@@ -65,6 +112,11 @@ func (s *Server) Start() error {
 		return fmt.Errorf("failed to create socket")
 	}
 
+	// Start the audit writer
+	if s.auditor != nil {
+		go s.auditWriter()
+	}
+
 	// Start the workers
 	for i := 0; i < s.workers; i++ {
 		s.waitgroup.Add(1)
@@ -106,6 +158,12 @@ func (s *Server) Stop() {
 	// Wait for all workers to finish
 	s.waitgroup.Wait()
 
+	// Store the audit records still queued
+	close(s.audits)
+	if s.auditor != nil {
+		<-s.auditDone
+	}
+
 	// Shutdown the socket
 	if s.socket != nil {
 		s.socket.Destroy()
@@ -115,39 +173,92 @@ func (s *Server) Stop() {
 }
 
 func (s *Server) worker(number int) {
+	for request := range s.requests {
+		s.serve(number, request)
+	}
+}
+
+// serve handles one message of the socket. Panics are recovered here, so a
+// malformed message costs its own reply and not the worker.
+func (s *Server) serve(number int, request [][]byte) {
 	defer func() {
 		if r := recover(); r != nil {
 			log.Error().Msgf("Panic recovered in worker %d: %v", number, r)
 		}
 	}()
 
-	for request := range s.requests {
-		log.Debug().Msgf("Received request from client (worker: %d, size: %d, identity: %v)", number, len(request[1]), request[0])
+	started := time.Now()
 
-		// Parse the request
-		req, identity, err := s.parseRequest(request)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to parse request")
+	// Parse the request
+	req, identity, err := s.parseRequest(request)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to parse request")
 
-			// Send error encoded
-			encoded := s.generateErrorResponse(identity, req.ID, req.Type, fmt.Errorf("invalid request format: %w", err))
+		// Send error encoded
+		if len(request) > 0 {
+			encoded := s.generateErrorResponse(string(request[0]), 0, "", fmt.Errorf("invalid request format: %w", err))
 			s.socket.SendChan <- encoded
-			continue
+			s.audit(string(request[0]), request[len(request)-1], nil, nil, "invalid", err, started)
 		}
+		return
+	}
 
-		// Process the request
-		response, err := s.processRequest(req)
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to process request")
+	log.Debug().Msgf("Received request from client (worker: %d, size: %d, identity: %v)", number, len(request[1]), identity)
 
-			// Send error encoded
-			encoded := s.generateErrorResponse(identity, req.ID, req.Type, fmt.Errorf("failed to process request: %w", err))
-			s.socket.SendChan <- encoded
-			continue
+	// Answer retried requests from the cache instead of executing them twice
+	if cached, duplicate := s.replies.begin(identity, req.ID); duplicate {
+		if cached == nil {
+			log.Warn().Msgf("Dropping duplicate of in-flight request %d (type: %s)", req.ID, req.Type)
+			return
 		}
 
-		// Send the response
-		encoded := s.generateSuccessResponse(identity, req.ID, req.Type, response)
-		s.socket.SendChan <- encoded
+		log.Warn().Msgf("Replaying cached reply for duplicate request %d (type: %s)", req.ID, req.Type)
+		s.socket.SendChan <- cached
+		s.audit(identity, request[1], req, nil, "duplicate", nil, started)
+		return
+	}
+
+	// Deferred routes may wait a long time, so they answer without
+	// holding the worker
+	if s.deferred[req.Type] {
+		s.waitgroup.Add(1)
+		go func() {
+			defer s.waitgroup.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					log.Error().Msgf("Panic recovered in deferred request %d: %v", req.ID, r)
+				}
+			}()
+
+			s.respond(identity, request[1], req, started)
+		}()
+		return
+	}
+
+	s.respond(identity, request[1], req, started)
+}
+
+// respond processes a request, sends the reply and records it in the audit
+// trail.
+func (s *Server) respond(identity string, raw []byte, req *models.Request, started time.Time) {
+	// 1. Process the request
+	var encoded [][]byte
+	response, err := s.processRequest(identity, req)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to process request")
+		encoded = s.generateErrorResponse(identity, req.ID, req.Type, fmt.Errorf("failed to process request: %w", err))
+	} else {
+		encoded = s.generateSuccessResponse(identity, req.ID, req.Type, response)
+	}
+
+	// 2. Remember and send the response
+	s.replies.complete(identity, req.ID, encoded)
+	s.socket.SendChan <- encoded
+
+	// 3. Record the request in the audit trail
+	if err != nil {
+		s.audit(identity, raw, req, nil, "error", err, started)
+	} else {
+		s.audit(identity, raw, req, response, "success", nil, started)
 	}
 }
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/services"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/zeromq/goczmq.v4"
+)
+
+// controlLinger is how long the control socket stays open after acknowledging
+// a promotion, so the reply reaches the monitor before the socket is closed.
+const controlLinger = 500 * time.Millisecond
+
+// Standby keeps a backup central server idle on a control port until the
+// health-check monitor asks it to take over the primary's endpoint.
+type Standby struct {
+	port     int
+	stopch   chan struct{}
+	promoted chan struct{}
+
+	// external
+	socket     *goczmq.Channeler
+	serializer services.ModelSerializer
+}
+
+func NewStandby(port int, serializer services.ModelSerializer) *Standby {
+	return &Standby{
+		port:       port,
+		stopch:     make(chan struct{}),
+		promoted:   make(chan struct{}),
+		serializer: serializer,
+	}
+}
+
+// Promoted is closed once a promote request has been acknowledged.
+func (s *Standby) Promoted() <-chan struct{} {
+	return s.promoted
+}
+
+func (s *Standby) Start() error {
+	log.Info().Msgf("Starting standby control socket on port %d", s.port)
+
+	// Start the socket
+	s.socket = goczmq.NewRouterChanneler(fmt.Sprintf("tcp://*:%d", s.port))
+	if s.socket == nil {
+		return fmt.Errorf("failed to create control socket")
+	}
+
+	// Start the control loop
+	go func() {
+		for {
+			select {
+			case <-s.stopch:
+				return
+			case request := <-s.socket.RecvChan:
+				if s.handle(request) {
+					close(s.promoted)
+					return
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *Standby) Stop() {
+	close(s.stopch)
+
+	// Let the "PROMOTED" reply leave before closing the socket
+	select {
+	case <-s.promoted:
+		time.Sleep(controlLinger)
+	default:
+	}
+
+	// Shutdown the socket
+	if s.socket != nil {
+		s.socket.Destroy()
+	}
+
+	log.Info().Msg("Standby control socket closed.")
+}
+
+// handle answers a single control request and reports whether it was a
+// promotion order.
+func (s *Standby) handle(request [][]byte) bool {
+	if len(request) < 2 {
+		log.Warn().Msg("Discarding malformed control request")
+		return false
+	}
+
+	// Deserialize the request
+	var req models.Request
+	if err := s.serializer.Decode(request[1], &req); err != nil {
+		log.Warn().Err(err).Msg("Failed to decode control request")
+		return false
+	}
+
+	response := &models.Response{
+		ID:      req.ID,
+		Type:    req.Type,
+		Success: true,
+	}
+
+	promote := false
+	switch req.Type {
+	case "health-check":
+		response.Content = "STANDBY"
+	case "promote":
+		log.Warn().Msg("Promotion requested by health-check monitor")
+		response.Content = "PROMOTED"
+		promote = true
+	default:
+		response.Success = false
+		response.Error = fmt.Sprintf("standby server cannot handle request type: %s", req.Type)
+	}
+
+	// Serialize and send the response
+	encoded, err := s.serializer.Encode(response)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to serialize control response")
+		return promote
+	}
+
+	s.socket.SendChan <- [][]byte{request[0], encoded}
+	return promote
+}
@@ -0,0 +1,46 @@
+package models
+
+// AdminAssignRequest pins a room to a program, already confirmed. Classrooms
+// may be assigned as adapted laboratories.
+type AdminAssignRequest struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program"`
+	Room     string `json:"room"`
+	Adapted  bool   `json:"adapted"`
+}
+
+// AdminRevokeRequest strips the allocation of a room, whatever its state.
+type AdminRevokeRequest struct {
+	Semester string `json:"semester"`
+	Room     string `json:"room"`
+}
+
+// AdminAllocation is the allocation an admin route assigned or revoked.
+type AdminAllocation struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program"`
+	Room     string `json:"room"`
+	Adapted  bool   `json:"adapted"`
+}
+
+// AdminBlockRequest takes a room out of service for a semester, or puts it
+// back when Unblock is set.
+type AdminBlockRequest struct {
+	Semester string `json:"semester"`
+	Room     string `json:"room"`
+	Reason   string `json:"reason"`
+	Unblock  bool   `json:"unblock"`
+}
+
+// AdminBlockResponse tells where the allocations of a blocked room were
+// moved, and which ones found no equivalent room and stay in it.
+type AdminBlockResponse struct {
+	Semester  string       `json:"semester"`
+	Room      string       `json:"room"`
+	Reason    string       `json:"reason,omitempty"`
+	Blocked   bool         `json:"blocked"`
+	Relocated []Relocation `json:"relocated,omitempty"`
+	Unplaced  []Relocation `json:"unplaced,omitempty"`
+}
@@ -1,14 +1,27 @@
 package models
 
+import "time"
+
 type ProgramInfo struct {
 	Name         string `json:"name"`
 	Classrooms   int    `json:"classrooms"`
 	Laboratories int    `json:"laboratories"`
+
+	// Optional requirements for the rooms (the building is only a preference)
+	MinCapacity int    `json:"min_capacity,omitempty" mapstructure:"min_capacity"`
+	Equipment   string `json:"equipment,omitempty"`
+	Building    string `json:"building,omitempty"`
+
+	// Weekly hours, only used by schedule requests
+	ClassroomHours  int `json:"classroom_hours,omitempty" mapstructure:"classroom_hours"`
+	LaboratoryHours int `json:"laboratory_hours,omitempty" mapstructure:"laboratory_hours"`
 }
 
 type AllocateRequest struct {
 	Semester string `json:"semester"`
 	Faculty  string `json:"faculty"`
+	Partial  bool   `json:"partial"`
+	Waitlist bool   `json:"waitlist"`
 
 	Programs []ProgramInfo `json:"programs"`
 }
@@ -18,15 +31,38 @@ type ProgramAllocation struct {
 	Classrooms   []string `json:"classrooms"`
 	Laboratories []string `json:"laboratories"`
 	Adapted      []string `json:"adapted"`
+
+	MissingClassrooms   int      `json:"missing_classrooms,omitempty"`
+	MissingLaboratories int      `json:"missing_laboratories,omitempty"`
+	Unmet               []string `json:"unmet,omitempty"`
 }
 
 type AllocateResponse struct {
-	Semester string `json:"semester"`
-	Faculty  string `json:"faculty"`
+	Semester   string    `json:"semester"`
+	Faculty    string    `json:"faculty"`
+	Deadline   time.Time `json:"deadline"`
+	Partial    bool      `json:"partial"`
+	Waitlisted bool      `json:"waitlisted"`
 
 	Programs []ProgramAllocation `json:"programs"`
 }
 
+type ProgramShortfall struct {
+	Name         string   `json:"name"`
+	Classrooms   int      `json:"classrooms"`
+	Laboratories int      `json:"laboratories"`
+	Unmet        []string `json:"unmet,omitempty"`
+}
+
+type QuoteResponse struct {
+	Semester    string `json:"semester"`
+	Faculty     string `json:"faculty"`
+	Satisfiable bool   `json:"satisfiable"`
+
+	Programs  []ProgramAllocation `json:"programs"`
+	Shortfall []ProgramShortfall  `json:"shortfall"`
+}
+
 type ConfirmRequest struct {
 	Semester string `json:"semester"`
 	Faculty  string `json:"faculty"`
@@ -36,4 +72,51 @@ type ConfirmRequest struct {
 type ConfirmResponse struct {
 	Semester string `json:"semester"`
 	Faculty  string `json:"faculty"`
+	Accepted bool   `json:"accepted"`
+
+	Released []string `json:"released,omitempty"`
+}
+
+type ReleasedRoom struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program"`
+	Room     string `json:"room"`
+}
+
+type Availability struct {
+	Semester     string `json:"semester"`
+	Classrooms   int    `json:"classrooms"`
+	Laboratories int    `json:"laboratories"`
+}
+
+type CancelRequest struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program,omitempty"`
+}
+
+type CancelResponse struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program,omitempty"`
+
+	Released []string `json:"released"`
+}
+
+// ModifyRequest changes a confirmed allocation. The counts of each program
+// are deltas: positive values ask for more rooms, negative ones give back.
+type ModifyRequest struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+
+	Programs []ProgramInfo `json:"programs"`
+}
+
+type ModifyResponse struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+
+	Released []string            `json:"released"`
+	Programs []ProgramAllocation `json:"programs"`
 }
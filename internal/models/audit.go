@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// AuditRecord describes a request received by the server and how it ended.
+// Outcome is one of success, error, duplicate or invalid.
+type AuditRecord struct {
+	ID          int64     `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	Identity    string    `json:"identity"`
+	RequestID   int       `json:"request_id"`
+	Faculty     string    `json:"faculty,omitempty"`
+	Type        string    `json:"type"`
+	PayloadHash string    `json:"payload_hash"`
+	Outcome     string    `json:"outcome"`
+	Error       string    `json:"error,omitempty"`
+	LatencyUs   int64     `json:"latency_us"`
+
+	// What admin changes touched
+	Semester string `json:"semester,omitempty"`
+	Program  string `json:"program,omitempty"`
+	Room     string `json:"room,omitempty"`
+}
+
+// AuditRequest filters the audit trail. Empty fields match everything, Since
+// is an RFC 3339 timestamp and a Limit of 0 returns every record.
+type AuditRequest struct {
+	Faculty string `json:"faculty,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Outcome string `json:"outcome,omitempty"`
+	Since   string `json:"since,omitempty"`
+	Limit   int    `json:"limit,omitempty"`
+}
+
+type AuditResponse struct {
+	Records []AuditRecord `json:"records"`
+}
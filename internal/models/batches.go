@@ -0,0 +1,24 @@
+package models
+
+type BatchAllocateRequest struct {
+	Requests []AllocateRequest `json:"requests"`
+}
+
+type BatchAllocateResponse struct {
+	Responses []AllocateResponse `json:"responses"`
+}
+
+// BatchFailure is sent as the content of a failed allocate-batch reply. It
+// names the request (by its position in the batch, from 0) and, when rooms ran
+// short, the program that could not get them.
+type BatchFailure struct {
+	Index    int    `json:"index"`
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program,omitempty"`
+	Reason   string `json:"reason"`
+
+	MissingClassrooms   int      `json:"missing_classrooms,omitempty"`
+	MissingLaboratories int      `json:"missing_laboratories,omitempty"`
+	Unmet               []string `json:"unmet,omitempty"`
+}
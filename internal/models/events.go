@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// AllocationEvent is a change to a room held by a faculty, as recorded in the
+// append-only history cmd/replay folds back into the rooms held at any point
+// in time. Type is offered, confirmed, unlocked, swapped or the reason the
+// room was released. Weekday and hours are only set for weekly time blocks.
+type AllocationEvent struct {
+	ID         int64     `json:"id"`
+	OccurredAt time.Time `json:"occurred_at"`
+	Type       string    `json:"type"`
+	Semester   string    `json:"semester"`
+	Faculty    string    `json:"faculty"`
+	Program    string    `json:"program"`
+	RoomID     int       `json:"room_id"`
+	Room       string    `json:"room"`
+	Adapted    bool      `json:"adapted"`
+	Weekday    int       `json:"weekday,omitempty"`
+	StartHour  int       `json:"start_hour,omitempty"`
+	EndHour    int       `json:"end_hour,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+type Notification struct {
+	Type     string `json:"type"`
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Swap     int    `json:"swap,omitempty"`
+
+	Programs []ProgramInfo `json:"programs,omitempty"`
+	Rooms    []string      `json:"rooms,omitempty"`
+
+	// Rooms replaced by the ones in Rooms, in the same order (relocations)
+	Previous []string `json:"previous,omitempty"`
+}
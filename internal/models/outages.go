@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// RoomOutage is a period a room is under maintenance or otherwise out of
+// service. Rooms are held for whole semesters, so until the outage ends the
+// room is not given in any semester.
+type RoomOutage struct {
+	ID       int       `json:"id"`
+	Room     string    `json:"room"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+type OutageRequest struct {
+	Room     string    `json:"room"`
+	StartsAt time.Time `json:"starts_at"`
+	EndsAt   time.Time `json:"ends_at"`
+	Reason   string    `json:"reason"`
+}
+
+// OutageConflict is an allocation of a room that overlaps one of its outages,
+// and is relocated once the outage starts.
+type OutageConflict struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program"`
+	State    string `json:"state"`
+}
+
+// OutageResponse reports the allocations the outage overlaps and, when it has
+// already started, where they were moved (one entry per semester).
+type OutageResponse struct {
+	Outage      RoomOutage         `json:"outage"`
+	Conflicts   []OutageConflict   `json:"conflicts"`
+	Relocations []RelocateResponse `json:"relocations"`
+}
+
+// OutageListRequest lists the outages that have not ended, or every outage
+// when All is set.
+type OutageListRequest struct {
+	All bool `json:"all"`
+}
+
+type OutageListResponse struct {
+	Outages []RoomOutage `json:"outages"`
+}
+
+type OutageRemoveRequest struct {
+	ID int `json:"id"`
+}
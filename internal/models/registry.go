@@ -0,0 +1,29 @@
+package models
+
+type RegisterFacultyRequest struct {
+	Faculty  string   `json:"faculty"`
+	Programs []string `json:"programs"`
+}
+
+type RegisterFacultyResponse struct {
+	Faculty string `json:"faculty"`
+
+	// Every program registered for the faculty so far
+	Programs []string `json:"programs"`
+}
+
+// FacultyQuota is the most rooms a faculty may hold in a semester, counting
+// the demand it has in the waitlist. Labs include adapted classrooms.
+type FacultyQuota struct {
+	Semester     string `json:"semester"`
+	Faculty      string `json:"faculty"`
+	Classrooms   int    `json:"classrooms"`
+	Laboratories int    `json:"laboratories"`
+}
+
+// Registry is the content of the file given to the central server with
+// -registry.
+type Registry struct {
+	Faculties []RegisterFacultyRequest `json:"faculties"`
+	Quotas    []FacultyQuota           `json:"quotas"`
+}
@@ -0,0 +1,33 @@
+package models
+
+// RelocateRequest moves the allocations of a semester held in rooms out of
+// service (blocked or under an outage) to equivalent free rooms. With a room
+// name only that room's allocations are moved. Time blocks are moved to a
+// room free at the same hours.
+type RelocateRequest struct {
+	Semester string `json:"semester"`
+	Room     string `json:"room"`
+}
+
+// Relocation is an allocation moved from the previous room to a new one. The
+// room is empty when no equivalent room was free. Day and hours are only set
+// for time blocks.
+type Relocation struct {
+	Faculty  string `json:"faculty"`
+	Program  string `json:"program"`
+	State    string `json:"state"`
+	Previous string `json:"previous"`
+	Room     string `json:"room,omitempty"`
+	Adapted  bool   `json:"adapted"`
+	Day      string `json:"day,omitempty"`
+	Start    int    `json:"start,omitempty"`
+	End      int    `json:"end,omitempty"`
+}
+
+type RelocateResponse struct {
+	Semester  string       `json:"semester"`
+	Relocated []Relocation `json:"relocated"`
+
+	// Allocations left in their room, there was no equivalent room free
+	Unplaced []Relocation `json:"unplaced"`
+}
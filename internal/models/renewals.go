@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// RenewRequest asks to carry the rooms a faculty confirmed in the source
+// semester over to the target semester.
+type RenewRequest struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Faculty string `json:"faculty"`
+	Partial bool   `json:"partial"`
+}
+
+// RoomSubstitution pairs a room that could not be kept with the room given
+// in its place.
+type RoomSubstitution struct {
+	Previous string `json:"previous"`
+	Room     string `json:"room"`
+}
+
+type ProgramRenewal struct {
+	Name        string             `json:"name"`
+	Kept        []string           `json:"kept"`
+	Substituted []RoomSubstitution `json:"substituted"`
+
+	// Previous rooms left without a substitute (only in partial renewals)
+	Missing []string `json:"missing,omitempty"`
+}
+
+type RenewResponse struct {
+	Source   string    `json:"source"`
+	Target   string    `json:"target"`
+	Faculty  string    `json:"faculty"`
+	Deadline time.Time `json:"deadline"`
+	Partial  bool      `json:"partial"`
+
+	Programs []ProgramRenewal `json:"programs"`
+}
@@ -0,0 +1,12 @@
+package models
+
+// Room is a room that can be handed out, as seen by an allocation strategy.
+type Room struct {
+	ID         int      `json:"id"`
+	Name       string   `json:"name"`
+	Laboratory bool     `json:"laboratory"`
+	Capacity   int      `json:"capacity"`
+	Building   string   `json:"building"`
+	Floor      int      `json:"floor"`
+	Equipment  []string `json:"equipment"`
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// ScheduleRequest asks for weekly time blocks instead of whole-semester rooms.
+// Each program gives its ClassroomHours and LaboratoryHours per week.
+type ScheduleRequest struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Partial  bool   `json:"partial"`
+
+	Programs []ProgramInfo `json:"programs"`
+}
+
+type TimeSlot struct {
+	Room    string `json:"room"`
+	Type    string `json:"type"`
+	Day     string `json:"day"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Adapted bool   `json:"adapted"`
+}
+
+type ProgramTimetable struct {
+	Name  string     `json:"name"`
+	Slots []TimeSlot `json:"slots"`
+
+	MissingClassroomHours  int      `json:"missing_classroom_hours,omitempty"`
+	MissingLaboratoryHours int      `json:"missing_laboratory_hours,omitempty"`
+	Unmet                  []string `json:"unmet,omitempty"`
+}
+
+type ScheduleResponse struct {
+	Semester string    `json:"semester"`
+	Faculty  string    `json:"faculty"`
+	Deadline time.Time `json:"deadline"`
+	Partial  bool      `json:"partial"`
+
+	Programs []ProgramTimetable `json:"programs"`
+}
@@ -0,0 +1,25 @@
+package models
+
+// Semester lifecycle: a semester is created as a draft, opened to take
+// allocations, closed when the allocation period ends and finally archived.
+const (
+	SemesterDraft    = "draft"
+	SemesterOpen     = "open"
+	SemesterClosed   = "closed"
+	SemesterArchived = "archived"
+)
+
+type SemesterRequest struct {
+	Semester string `json:"semester"`
+
+	// Only used by create requests, creates the semester already open
+	Open bool `json:"open"`
+}
+
+type SemesterResponse struct {
+	Semester string `json:"semester"`
+	State    string `json:"state"`
+
+	// Offers released because the semester was closed
+	Released []ReleasedRoom `json:"released,omitempty"`
+}
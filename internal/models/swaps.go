@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+// Swap proposals wait for the other faculty to accept or reject them.
+const (
+	SwapPending  = "pending"
+	SwapAccepted = "accepted"
+	SwapRejected = "rejected"
+)
+
+// SwapProposal offers some of the faculty's confirmed rooms to another faculty
+// in exchange for some of its confirmed rooms. The requested rooms go to the
+// given program of the proposing faculty.
+type SwapProposal struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	To       string `json:"to"`
+	Program  string `json:"program"`
+
+	Offered   []string `json:"offered"`
+	Requested []string `json:"requested"`
+}
+
+// SwapDecision answers a swap proposal. The receiving faculty may accept it,
+// giving the program its offered rooms go to; either faculty may reject it.
+type SwapDecision struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+	Swap     int    `json:"swap"`
+	Accept   bool   `json:"accept"`
+	Program  string `json:"program,omitempty"`
+}
+
+type Swap struct {
+	ID        int       `json:"id"`
+	Semester  string    `json:"semester"`
+	Proposer  string    `json:"proposer"`
+	Recipient string    `json:"recipient"`
+	Program   string    `json:"program"`
+	State     string    `json:"state"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Offered   []string `json:"offered"`
+	Requested []string `json:"requested"`
+}
+
+type SwapListRequest struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+}
+
+type SwapListResponse struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+
+	Swaps []Swap `json:"swaps"`
+}
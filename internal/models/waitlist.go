@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type WaitlistStatusRequest struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+}
+
+type WaitlistEntry struct {
+	Program      string    `json:"program"`
+	Classrooms   int       `json:"classrooms"`
+	Laboratories int       `json:"laboratories"`
+	Position     int       `json:"position"`
+	Since        time.Time `json:"since"`
+}
+
+type WaitlistStatusResponse struct {
+	Semester string `json:"semester"`
+	Faculty  string `json:"faculty"`
+
+	Entries []WaitlistEntry `json:"entries"`
+}
@@ -7,6 +7,8 @@ package repository
 import (
 	"database/sql/driver"
 	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type RoomState string
@@ -93,18 +95,232 @@ func (ns NullRoomType) Value() (driver.Value, error) {
 	return string(ns.RoomType), nil
 }
 
+type SemesterState string
+
+const (
+	SemesterStateDraft    SemesterState = "draft"
+	SemesterStateOpen     SemesterState = "open"
+	SemesterStateClosed   SemesterState = "closed"
+	SemesterStateArchived SemesterState = "archived"
+)
+
+func (e *SemesterState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SemesterState(s)
+	case string:
+		*e = SemesterState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SemesterState: %T", src)
+	}
+	return nil
+}
+
+type NullSemesterState struct {
+	SemesterState SemesterState `json:"semester_state"`
+	Valid         bool          `json:"valid"` // Valid is true if SemesterState is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSemesterState) Scan(value interface{}) error {
+	if value == nil {
+		ns.SemesterState, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SemesterState.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSemesterState) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SemesterState), nil
+}
+
+type SwapState string
+
+const (
+	SwapStatePending  SwapState = "pending"
+	SwapStateAccepted SwapState = "accepted"
+	SwapStateRejected SwapState = "rejected"
+)
+
+func (e *SwapState) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = SwapState(s)
+	case string:
+		*e = SwapState(s)
+	default:
+		return fmt.Errorf("unsupported scan type for SwapState: %T", src)
+	}
+	return nil
+}
+
+type NullSwapState struct {
+	SwapState SwapState `json:"swap_state"`
+	Valid     bool      `json:"valid"` // Valid is true if SwapState is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullSwapState) Scan(value interface{}) error {
+	if value == nil {
+		ns.SwapState, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.SwapState.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullSwapState) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.SwapState), nil
+}
+
+type AllocationEvent struct {
+	ID         int64                     `db:"id" json:"id"`
+	OccurredAt pgtype.Timestamptz        `db:"occurred_at" json:"occurred_at"`
+	Type       string                    `db:"type" json:"type"`
+	Semester   string                    `db:"semester" json:"semester"`
+	Faculty    string                    `db:"faculty" json:"faculty"`
+	Program    string                    `db:"program" json:"program"`
+	RoomID     int32                     `db:"room_id" json:"room_id"`
+	Room       string                    `db:"room" json:"room"`
+	Adapted    bool                      `db:"adapted" json:"adapted"`
+	Weekday    pgtype.Int4               `db:"weekday" json:"weekday"`
+	Hours      pgtype.Range[pgtype.Int4] `db:"hours" json:"hours"`
+}
+
+type AuditLog struct {
+	ID          int64              `db:"id" json:"id"`
+	CreatedAt   pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	Identity    string             `db:"identity" json:"identity"`
+	RequestID   int32              `db:"request_id" json:"request_id"`
+	Faculty     string             `db:"faculty" json:"faculty"`
+	Type        string             `db:"type" json:"type"`
+	PayloadHash string             `db:"payload_hash" json:"payload_hash"`
+	Outcome     string             `db:"outcome" json:"outcome"`
+	Error       string             `db:"error" json:"error"`
+	LatencyUs   int64              `db:"latency_us" json:"latency_us"`
+	Semester    string             `db:"semester" json:"semester"`
+	Program     string             `db:"program" json:"program"`
+	Room        string             `db:"room" json:"room"`
+}
+
+type Faculty struct {
+	Name string `db:"name" json:"name"`
+}
+
+type FacultyQuota struct {
+	Semester     string `db:"semester" json:"semester"`
+	Faculty      string `db:"faculty" json:"faculty"`
+	Classrooms   int32  `db:"classrooms" json:"classrooms"`
+	Laboratories int32  `db:"laboratories" json:"laboratories"`
+}
+
+type ProcessedRequest struct {
+	Identity  string             `db:"identity" json:"identity"`
+	RequestID int32              `db:"request_id" json:"request_id"`
+	Type      string             `db:"type" json:"type"`
+	Response  []byte             `db:"response" json:"response"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type Program struct {
+	Faculty string `db:"faculty" json:"faculty"`
+	Name    string `db:"name" json:"name"`
+}
+
 type Room struct {
-	ID   int32    `db:"id" json:"id"`
-	Name string   `db:"name" json:"name"`
-	Type RoomType `db:"type" json:"type"`
+	ID        int32    `db:"id" json:"id"`
+	Name      string   `db:"name" json:"name"`
+	Type      RoomType `db:"type" json:"type"`
+	Capacity  int32    `db:"capacity" json:"capacity"`
+	Building  string   `db:"building" json:"building"`
+	Floor     int32    `db:"floor" json:"floor"`
+	Equipment []string `db:"equipment" json:"equipment"`
 }
 
 type RoomAllocation struct {
-	ID       int32     `db:"id" json:"id"`
-	State    RoomState `db:"state" json:"state"`
-	RoomID   int32     `db:"room_id" json:"room_id"`
-	Semester string    `db:"semester" json:"semester"`
-	Faculty  string    `db:"faculty" json:"faculty"`
-	Program  string    `db:"program" json:"program"`
-	Adapted  bool      `db:"adapted" json:"adapted"`
+	ID        int32              `db:"id" json:"id"`
+	State     RoomState          `db:"state" json:"state"`
+	RoomID    int32              `db:"room_id" json:"room_id"`
+	Semester  string             `db:"semester" json:"semester"`
+	Faculty   string             `db:"faculty" json:"faculty"`
+	Program   string             `db:"program" json:"program"`
+	Adapted   bool               `db:"adapted" json:"adapted"`
+	OfferedAt pgtype.Timestamptz `db:"offered_at" json:"offered_at"`
+	Unplaced  bool               `db:"unplaced" json:"unplaced"`
+}
+
+type RoomBlock struct {
+	Semester  string             `db:"semester" json:"semester"`
+	RoomID    int32              `db:"room_id" json:"room_id"`
+	Reason    string             `db:"reason" json:"reason"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type RoomBooking struct {
+	ID        int32                     `db:"id" json:"id"`
+	State     RoomState                 `db:"state" json:"state"`
+	RoomID    int32                     `db:"room_id" json:"room_id"`
+	Semester  string                    `db:"semester" json:"semester"`
+	Faculty   string                    `db:"faculty" json:"faculty"`
+	Program   string                    `db:"program" json:"program"`
+	Weekday   int32                     `db:"weekday" json:"weekday"`
+	Hours     pgtype.Range[pgtype.Int4] `db:"hours" json:"hours"`
+	Adapted   bool                      `db:"adapted" json:"adapted"`
+	OfferedAt pgtype.Timestamptz        `db:"offered_at" json:"offered_at"`
+	Unplaced  bool                      `db:"unplaced" json:"unplaced"`
+}
+
+type RoomOutage struct {
+	ID        int32              `db:"id" json:"id"`
+	RoomID    int32              `db:"room_id" json:"room_id"`
+	StartsAt  pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt    pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Reason    string             `db:"reason" json:"reason"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type Semester struct {
+	Name      string             `db:"name" json:"name"`
+	State     SemesterState      `db:"state" json:"state"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `db:"updated_at" json:"updated_at"`
+}
+
+type Swap struct {
+	ID        int32              `db:"id" json:"id"`
+	Semester  string             `db:"semester" json:"semester"`
+	Proposer  string             `db:"proposer" json:"proposer"`
+	Recipient string             `db:"recipient" json:"recipient"`
+	Program   string             `db:"program" json:"program"`
+	State     SwapState          `db:"state" json:"state"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	DecidedAt pgtype.Timestamptz `db:"decided_at" json:"decided_at"`
+}
+
+type SwapRoom struct {
+	SwapID  int32  `db:"swap_id" json:"swap_id"`
+	RoomID  int32  `db:"room_id" json:"room_id"`
+	Faculty string `db:"faculty" json:"faculty"`
+}
+
+type Waitlist struct {
+	ID           int32              `db:"id" json:"id"`
+	Semester     string             `db:"semester" json:"semester"`
+	Faculty      string             `db:"faculty" json:"faculty"`
+	Program      string             `db:"program" json:"program"`
+	Classrooms   int32              `db:"classrooms" json:"classrooms"`
+	Laboratories int32              `db:"laboratories" json:"laboratories"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	MinCapacity  int32              `db:"min_capacity" json:"min_capacity"`
+	Equipment    string             `db:"equipment" json:"equipment"`
+	Building     string             `db:"building" json:"building"`
 }
@@ -7,101 +7,614 @@ package repository
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const allocateClassrooms = `-- name: AllocateClassrooms :exec
-SELECT allocate_classrooms($1, $2, $3, $4)
+const addAuditRecord = `-- name: AddAuditRecord :exec
+INSERT INTO audit_log (identity, request_id, faculty, type, payload_hash, outcome, error, latency_us, semester, program, room)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+`
+
+type AddAuditRecordParams struct {
+	Identity    string `db:"identity" json:"identity"`
+	RequestID   int32  `db:"request_id" json:"request_id"`
+	Faculty     string `db:"faculty" json:"faculty"`
+	Type        string `db:"type" json:"type"`
+	PayloadHash string `db:"payload_hash" json:"payload_hash"`
+	Outcome     string `db:"outcome" json:"outcome"`
+	Error       string `db:"error" json:"error"`
+	LatencyUs   int64  `db:"latency_us" json:"latency_us"`
+	Semester    string `db:"semester" json:"semester"`
+	Program     string `db:"program" json:"program"`
+	Room        string `db:"room" json:"room"`
+}
+
+func (q *Queries) AddAuditRecord(ctx context.Context, arg AddAuditRecordParams) error {
+	_, err := q.db.Exec(ctx, addAuditRecord,
+		arg.Identity,
+		arg.RequestID,
+		arg.Faculty,
+		arg.Type,
+		arg.PayloadHash,
+		arg.Outcome,
+		arg.Error,
+		arg.LatencyUs,
+		arg.Semester,
+		arg.Program,
+		arg.Room,
+	)
+	return err
+}
+
+const addSwapRoom = `-- name: AddSwapRoom :exec
+INSERT INTO swap_rooms (swap_id, room_id, faculty)
+VALUES ($1, $2, $3)
+`
+
+type AddSwapRoomParams struct {
+	SwapID  int32  `db:"swap_id" json:"swap_id"`
+	RoomID  int32  `db:"room_id" json:"room_id"`
+	Faculty string `db:"faculty" json:"faculty"`
+}
+
+func (q *Queries) AddSwapRoom(ctx context.Context, arg AddSwapRoomParams) error {
+	_, err := q.db.Exec(ctx, addSwapRoom, arg.SwapID, arg.RoomID, arg.Faculty)
+	return err
+}
+
+const addToWaitlist = `-- name: AddToWaitlist :exec
+INSERT INTO waitlist (semester, faculty, program, classrooms, laboratories, min_capacity, equipment, building)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+`
+
+type AddToWaitlistParams struct {
+	Semester     string `db:"semester" json:"semester"`
+	Faculty      string `db:"faculty" json:"faculty"`
+	Program      string `db:"program" json:"program"`
+	Classrooms   int32  `db:"classrooms" json:"classrooms"`
+	Laboratories int32  `db:"laboratories" json:"laboratories"`
+	MinCapacity  int32  `db:"min_capacity" json:"min_capacity"`
+	Equipment    string `db:"equipment" json:"equipment"`
+	Building     string `db:"building" json:"building"`
+}
+
+func (q *Queries) AddToWaitlist(ctx context.Context, arg AddToWaitlistParams) error {
+	_, err := q.db.Exec(ctx, addToWaitlist,
+		arg.Semester,
+		arg.Faculty,
+		arg.Program,
+		arg.Classrooms,
+		arg.Laboratories,
+		arg.MinCapacity,
+		arg.Equipment,
+		arg.Building,
+	)
+	return err
+}
+
+const allocateClassrooms = `-- name: AllocateClassrooms :one
+SELECT allocate_classrooms($1, $2, $3, $4, $5, $6, $7, $8)
 `
 
 type AllocateClassroomsParams struct {
-	Semester string `db:"_semester" json:"_semester"`
-	Faculty  string `db:"_faculty" json:"_faculty"`
-	Program  string `db:"_program" json:"_program"`
-	Count    int32  `db:"_count" json:"_count"`
+	Semester    string  `db:"_semester" json:"_semester"`
+	Faculty     string  `db:"_faculty" json:"_faculty"`
+	Program     string  `db:"_program" json:"_program"`
+	Count       int32   `db:"_count" json:"_count"`
+	Strict      bool    `db:"_strict" json:"_strict"`
+	MinCapacity int32   `db:"_min_capacity" json:"_min_capacity"`
+	Building    string  `db:"_building" json:"_building"`
+	Ranking     []int32 `db:"_ranking" json:"_ranking"`
 }
 
-func (q *Queries) AllocateClassrooms(ctx context.Context, arg AllocateClassroomsParams) error {
-	_, err := q.db.Exec(ctx, allocateClassrooms,
+func (q *Queries) AllocateClassrooms(ctx context.Context, arg AllocateClassroomsParams) (int32, error) {
+	row := q.db.QueryRow(ctx, allocateClassrooms,
 		arg.Semester,
 		arg.Faculty,
 		arg.Program,
 		arg.Count,
+		arg.Strict,
+		arg.MinCapacity,
+		arg.Building,
+		arg.Ranking,
 	)
-	return err
+	var allocate_classrooms int32
+	err := row.Scan(&allocate_classrooms)
+	return allocate_classrooms, err
 }
 
-const allocateLaboratories = `-- name: AllocateLaboratories :exec
-SELECT allocate_laboratories($1, $2, $3, $4)
+const allocateLaboratories = `-- name: AllocateLaboratories :one
+SELECT allocate_laboratories($1, $2, $3, $4, $5, $6, $7, $8, $9)
 `
 
 type AllocateLaboratoriesParams struct {
-	Semester string `db:"_semester" json:"_semester"`
-	Faculty  string `db:"_faculty" json:"_faculty"`
-	Program  string `db:"_program" json:"_program"`
-	Count    int32  `db:"_count" json:"_count"`
+	Semester    string  `db:"_semester" json:"_semester"`
+	Faculty     string  `db:"_faculty" json:"_faculty"`
+	Program     string  `db:"_program" json:"_program"`
+	Count       int32   `db:"_count" json:"_count"`
+	Strict      bool    `db:"_strict" json:"_strict"`
+	MinCapacity int32   `db:"_min_capacity" json:"_min_capacity"`
+	Equipment   string  `db:"_equipment" json:"_equipment"`
+	Building    string  `db:"_building" json:"_building"`
+	Ranking     []int32 `db:"_ranking" json:"_ranking"`
 }
 
-func (q *Queries) AllocateLaboratories(ctx context.Context, arg AllocateLaboratoriesParams) error {
-	_, err := q.db.Exec(ctx, allocateLaboratories,
+func (q *Queries) AllocateLaboratories(ctx context.Context, arg AllocateLaboratoriesParams) (int32, error) {
+	row := q.db.QueryRow(ctx, allocateLaboratories,
 		arg.Semester,
 		arg.Faculty,
 		arg.Program,
 		arg.Count,
+		arg.Strict,
+		arg.MinCapacity,
+		arg.Equipment,
+		arg.Building,
+		arg.Ranking,
 	)
+	var allocate_laboratories int32
+	err := row.Scan(&allocate_laboratories)
+	return allocate_laboratories, err
+}
+
+const blockRoom = `-- name: BlockRoom :exec
+INSERT INTO room_blocks (semester, room_id, reason)
+VALUES ($1, $2, $3)
+ON CONFLICT (semester, room_id) DO UPDATE
+SET reason = EXCLUDED.reason
+`
+
+type BlockRoomParams struct {
+	Semester string `db:"semester" json:"semester"`
+	RoomID   int32  `db:"room_id" json:"room_id"`
+	Reason   string `db:"reason" json:"reason"`
+}
+
+func (q *Queries) BlockRoom(ctx context.Context, arg BlockRoomParams) error {
+	_, err := q.db.Exec(ctx, blockRoom, arg.Semester, arg.RoomID, arg.Reason)
 	return err
 }
 
-const generateRooms = `-- name: GenerateRooms :exec
-SELECT generate_rooms($1, $2)
+const cancelBookings = `-- name: CancelBookings :many
+WITH released AS (
+    DELETE FROM room_bookings
+    WHERE faculty = $1
+        AND semester = $2
+        AND ($3::TEXT = '' OR program = $3::TEXT)
+        AND state = 'locked'
+    RETURNING room_id
+)
+SELECT DISTINCT r.name
+FROM released
+JOIN rooms r
+    ON r.id = released.room_id
 `
 
-type GenerateRoomsParams struct {
-	NormalRooms  int32 `db:"normal_rooms" json:"normal_rooms"`
-	Laboratories int32 `db:"laboratories" json:"laboratories"`
+type CancelBookingsParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Semester string `db:"semester" json:"semester"`
+	Program  string `db:"program" json:"program"`
 }
 
-func (q *Queries) GenerateRooms(ctx context.Context, arg GenerateRoomsParams) error {
-	_, err := q.db.Exec(ctx, generateRooms, arg.NormalRooms, arg.Laboratories)
+func (q *Queries) CancelBookings(ctx context.Context, arg CancelBookingsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, cancelBookings, arg.Faculty, arg.Semester, arg.Program)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const cancelRooms = `-- name: CancelRooms :many
+SELECT cancel_rooms($1, $2, $3)::TEXT AS name
+`
+
+type CancelRoomsParams struct {
+	FacultyName  string `db:"faculty_name" json:"faculty_name"`
+	SemesterName string `db:"semester_name" json:"semester_name"`
+	ProgramName  string `db:"program_name" json:"program_name"`
+}
+
+func (q *Queries) CancelRooms(ctx context.Context, arg CancelRoomsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, cancelRooms, arg.FacultyName, arg.SemesterName, arg.ProgramName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const clearUnplacedBookings = `-- name: ClearUnplacedBookings :exec
+UPDATE room_bookings
+SET unplaced = FALSE
+WHERE unplaced
+    AND room_in_service(semester, room_id)
+`
+
+func (q *Queries) ClearUnplacedBookings(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, clearUnplacedBookings)
 	return err
 }
 
-const getRoomsByFacultyProgramSemester = `-- name: GetRoomsByFacultyProgramSemester :many
-SELECT r.id, r.name, r.type, ra.adapted
+const clearUnplacedRooms = `-- name: ClearUnplacedRooms :exec
+UPDATE room_allocations
+SET unplaced = FALSE
+WHERE unplaced
+    AND room_in_service(semester, room_id)
+`
+
+func (q *Queries) ClearUnplacedRooms(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, clearUnplacedRooms)
+	return err
+}
+
+const clearWaitlist = `-- name: ClearWaitlist :exec
+DELETE FROM waitlist
+WHERE semester = $1
+`
+
+func (q *Queries) ClearWaitlist(ctx context.Context, semester string) error {
+	_, err := q.db.Exec(ctx, clearWaitlist, semester)
+	return err
+}
+
+const closeBookings = `-- name: CloseBookings :many
+WITH released AS (
+    DELETE FROM room_bookings
+    WHERE semester = $1
+        AND state = 'awaiting'
+    RETURNING semester, faculty, program, room_id
+)
+SELECT DISTINCT e.semester, e.faculty, e.program, r.name
+FROM released e
+JOIN rooms r
+    ON r.id = e.room_id
+`
+
+type CloseBookingsRow struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+	Program  string `db:"program" json:"program"`
+	Name     string `db:"name" json:"name"`
+}
+
+func (q *Queries) CloseBookings(ctx context.Context, semester string) ([]CloseBookingsRow, error) {
+	rows, err := q.db.Query(ctx, closeBookings, semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CloseBookingsRow
+	for rows.Next() {
+		var i CloseBookingsRow
+		if err := rows.Scan(
+			&i.Semester,
+			&i.Faculty,
+			&i.Program,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const closeRooms = `-- name: CloseRooms :many
+WITH released AS (
+    DELETE FROM room_allocations
+    WHERE semester = $1
+        AND state = 'awaiting'
+    RETURNING semester, faculty, program, room_id
+)
+SELECT DISTINCT e.semester, e.faculty, e.program, r.name
+FROM released e
+JOIN rooms r
+    ON r.id = e.room_id
+`
+
+type CloseRoomsRow struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+	Program  string `db:"program" json:"program"`
+	Name     string `db:"name" json:"name"`
+}
+
+func (q *Queries) CloseRooms(ctx context.Context, semester string) ([]CloseRoomsRow, error) {
+	rows, err := q.db.Query(ctx, closeRooms, semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CloseRoomsRow
+	for rows.Next() {
+		var i CloseRoomsRow
+		if err := rows.Scan(
+			&i.Semester,
+			&i.Faculty,
+			&i.Program,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const countAvailableRooms = `-- name: CountAvailableRooms :one
+SELECT
+    COUNT(*) FILTER (WHERE r.type = 'classroom')::INT AS classrooms,
+    COUNT(*) FILTER (WHERE r.type = 'laboratory')::INT AS laboratories
 FROM rooms r
-JOIN room_allocations ra
-    ON r.id = ra.room_id
-WHERE ra.faculty = $1
-    AND ra.program = $2
-    AND ra.semester = $3
+WHERE room_is_free($1, r.id)
 `
 
-type GetRoomsByFacultyProgramSemesterParams struct {
+type CountAvailableRoomsRow struct {
+	Classrooms   int32 `db:"classrooms" json:"classrooms"`
+	Laboratories int32 `db:"laboratories" json:"laboratories"`
+}
+
+func (q *Queries) CountAvailableRooms(ctx context.Context, semester string) (CountAvailableRoomsRow, error) {
+	row := q.db.QueryRow(ctx, countAvailableRooms, semester)
+	var i CountAvailableRoomsRow
+	err := row.Scan(&i.Classrooms, &i.Laboratories)
+	return i, err
+}
+
+const countLockedProgramRooms = `-- name: CountLockedProgramRooms :one
+SELECT COUNT(*)::INT
+FROM room_allocations
+WHERE faculty = $1
+    AND semester = $2
+    AND program = $3
+    AND state = 'locked'
+`
+
+type CountLockedProgramRoomsParams struct {
 	Faculty  string `db:"faculty" json:"faculty"`
+	Semester string `db:"semester" json:"semester"`
 	Program  string `db:"program" json:"program"`
+}
+
+func (q *Queries) CountLockedProgramRooms(ctx context.Context, arg CountLockedProgramRoomsParams) (int32, error) {
+	row := q.db.QueryRow(ctx, countLockedProgramRooms, arg.Faculty, arg.Semester, arg.Program)
+	var column_1 int32
+	err := row.Scan(&column_1)
+	return column_1, err
+}
+
+const createOutage = `-- name: CreateOutage :one
+INSERT INTO room_outages (room_id, starts_at, ends_at, reason)
+VALUES ($1, $2, $3, $4)
+RETURNING id
+`
+
+type CreateOutageParams struct {
+	RoomID   int32              `db:"room_id" json:"room_id"`
+	StartsAt pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Reason   string             `db:"reason" json:"reason"`
+}
+
+func (q *Queries) CreateOutage(ctx context.Context, arg CreateOutageParams) (int32, error) {
+	row := q.db.QueryRow(ctx, createOutage,
+		arg.RoomID,
+		arg.StartsAt,
+		arg.EndsAt,
+		arg.Reason,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const createSemester = `-- name: CreateSemester :execrows
+INSERT INTO semesters (name, state)
+VALUES ($1, $2)
+ON CONFLICT (name) DO NOTHING
+`
+
+type CreateSemesterParams struct {
+	Name  string        `db:"name" json:"name"`
+	State SemesterState `db:"state" json:"state"`
+}
+
+func (q *Queries) CreateSemester(ctx context.Context, arg CreateSemesterParams) (int64, error) {
+	result, err := q.db.Exec(ctx, createSemester, arg.Name, arg.State)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const createSwap = `-- name: CreateSwap :one
+INSERT INTO swaps (semester, proposer, recipient, program)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at
+`
+
+type CreateSwapParams struct {
+	Semester  string `db:"semester" json:"semester"`
+	Proposer  string `db:"proposer" json:"proposer"`
+	Recipient string `db:"recipient" json:"recipient"`
+	Program   string `db:"program" json:"program"`
+}
+
+type CreateSwapRow struct {
+	ID        int32              `db:"id" json:"id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) CreateSwap(ctx context.Context, arg CreateSwapParams) (CreateSwapRow, error) {
+	row := q.db.QueryRow(ctx, createSwap,
+		arg.Semester,
+		arg.Proposer,
+		arg.Recipient,
+		arg.Program,
+	)
+	var i CreateSwapRow
+	err := row.Scan(&i.ID, &i.CreatedAt)
+	return i, err
+}
+
+const decideSwap = `-- name: DecideSwap :exec
+UPDATE swaps
+SET state = $2, decided_at = now()
+WHERE id = $1
+`
+
+type DecideSwapParams struct {
+	ID    int32     `db:"id" json:"id"`
+	State SwapState `db:"state" json:"state"`
+}
+
+func (q *Queries) DecideSwap(ctx context.Context, arg DecideSwapParams) error {
+	_, err := q.db.Exec(ctx, decideSwap, arg.ID, arg.State)
+	return err
+}
+
+const deleteOutage = `-- name: DeleteOutage :one
+DELETE FROM room_outages o
+USING rooms r
+WHERE o.id = $1
+    AND r.id = o.room_id
+RETURNING o.id, r.name, o.starts_at, o.ends_at, o.reason
+`
+
+type DeleteOutageRow struct {
+	ID       int32              `db:"id" json:"id"`
+	Name     string             `db:"name" json:"name"`
+	StartsAt pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Reason   string             `db:"reason" json:"reason"`
+}
+
+func (q *Queries) DeleteOutage(ctx context.Context, id int32) (DeleteOutageRow, error) {
+	row := q.db.QueryRow(ctx, deleteOutage, id)
+	var i DeleteOutageRow
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.StartsAt,
+		&i.EndsAt,
+		&i.Reason,
+	)
+	return i, err
+}
+
+const deleteProcessedRequests = `-- name: DeleteProcessedRequests :execrows
+DELETE FROM processed_requests
+WHERE created_at <= $1
+`
+
+func (q *Queries) DeleteProcessedRequests(ctx context.Context, createdAt pgtype.Timestamptz) (int64, error) {
+	result, err := q.db.Exec(ctx, deleteProcessedRequests, createdAt)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const expireBookings = `-- name: ExpireBookings :many
+WITH expired AS (
+    DELETE FROM room_bookings
+    WHERE state = 'awaiting'
+        AND offered_at < now() - $1::INTERVAL
+    RETURNING semester, faculty, program, room_id
+)
+SELECT DISTINCT e.semester, e.faculty, e.program, r.name
+FROM expired e
+JOIN rooms r
+    ON r.id = e.room_id
+`
+
+type ExpireBookingsRow struct {
 	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+	Program  string `db:"program" json:"program"`
+	Name     string `db:"name" json:"name"`
 }
 
-type GetRoomsByFacultyProgramSemesterRow struct {
-	ID      int32    `db:"id" json:"id"`
-	Name    string   `db:"name" json:"name"`
-	Type    RoomType `db:"type" json:"type"`
-	Adapted bool     `db:"adapted" json:"adapted"`
+func (q *Queries) ExpireBookings(ctx context.Context, hold pgtype.Interval) ([]ExpireBookingsRow, error) {
+	rows, err := q.db.Query(ctx, expireBookings, hold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ExpireBookingsRow
+	for rows.Next() {
+		var i ExpireBookingsRow
+		if err := rows.Scan(
+			&i.Semester,
+			&i.Faculty,
+			&i.Program,
+			&i.Name,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *Queries) GetRoomsByFacultyProgramSemester(ctx context.Context, arg GetRoomsByFacultyProgramSemesterParams) ([]GetRoomsByFacultyProgramSemesterRow, error) {
-	rows, err := q.db.Query(ctx, getRoomsByFacultyProgramSemester, arg.Faculty, arg.Program, arg.Semester)
+const expireRooms = `-- name: ExpireRooms :many
+SELECT semester, faculty, program, name
+FROM expire_rooms($1)
+`
+
+type ExpireRoomsRow struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+	Program  string `db:"program" json:"program"`
+	Name     string `db:"name" json:"name"`
+}
+
+func (q *Queries) ExpireRooms(ctx context.Context, hold pgtype.Interval) ([]ExpireRoomsRow, error) {
+	rows, err := q.db.Query(ctx, expireRooms, hold)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetRoomsByFacultyProgramSemesterRow
+	var items []ExpireRoomsRow
 	for rows.Next() {
-		var i GetRoomsByFacultyProgramSemesterRow
+		var i ExpireRoomsRow
 		if err := rows.Scan(
-			&i.ID,
+			&i.Semester,
+			&i.Faculty,
+			&i.Program,
 			&i.Name,
-			&i.Type,
-			&i.Adapted,
 		); err != nil {
 			return nil, err
 		}
@@ -113,16 +626,1714 @@ func (q *Queries) GetRoomsByFacultyProgramSemester(ctx context.Context, arg GetR
 	return items, nil
 }
 
-const lockRooms = `-- name: LockRooms :exec
-SELECT lock_rooms($1, $2)
+const facultyExists = `-- name: FacultyExists :one
+SELECT EXISTS (
+    SELECT 1
+    FROM faculties
+    WHERE name = $1
+)
 `
 
-type LockRoomsParams struct {
-	FacultyName  string `db:"faculty_name" json:"faculty_name"`
-	SemesterName string `db:"semester_name" json:"semester_name"`
+func (q *Queries) FacultyExists(ctx context.Context, name string) (bool, error) {
+	row := q.db.QueryRow(ctx, facultyExists, name)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
 }
 
-func (q *Queries) LockRooms(ctx context.Context, arg LockRoomsParams) error {
-	_, err := q.db.Exec(ctx, lockRooms, arg.FacultyName, arg.SemesterName)
-	return err
+const findRelocationBlock = `-- name: FindRelocationBlock :one
+SELECT r.id, r.name, r.type
+FROM rooms r
+JOIN rooms old ON old.id = $1
+WHERE (r.type = 'classroom' OR $2::BOOLEAN)
+    AND r.capacity >= old.capacity
+    AND r.equipment @> old.equipment
+    AND room_offerable($3, r.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM room_allocations a WHERE a.semester = $3 AND a.room_id = r.id
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM room_bookings b
+        WHERE b.semester = $3
+            AND b.room_id = r.id
+            AND b.weekday = $4
+            AND b.hours && int4range($5, $6)
+    )
+ORDER BY
+    ((r.type = 'laboratory') = $2::BOOLEAN) DESC,
+    (r.building = old.building) DESC,
+    r.id
+LIMIT 1
+FOR UPDATE OF r SKIP LOCKED
+`
+
+type FindRelocationBlockParams struct {
+	RoomID     int32  `db:"room_id" json:"room_id"`
+	Laboratory bool   `db:"laboratory" json:"laboratory"`
+	Semester   string `db:"semester" json:"semester"`
+	Weekday    int32  `db:"weekday" json:"weekday"`
+	StartHour  int32  `db:"start_hour" json:"start_hour"`
+	EndHour    int32  `db:"end_hour" json:"end_hour"`
+}
+
+type FindRelocationBlockRow struct {
+	ID   int32    `db:"id" json:"id"`
+	Name string   `db:"name" json:"name"`
+	Type RoomType `db:"type" json:"type"`
+}
+
+func (q *Queries) FindRelocationBlock(ctx context.Context, arg FindRelocationBlockParams) (FindRelocationBlockRow, error) {
+	row := q.db.QueryRow(ctx, findRelocationBlock,
+		arg.RoomID,
+		arg.Laboratory,
+		arg.Semester,
+		arg.Weekday,
+		arg.StartHour,
+		arg.EndHour,
+	)
+	var i FindRelocationBlockRow
+	err := row.Scan(&i.ID, &i.Name, &i.Type)
+	return i, err
+}
+
+const findRelocationRoom = `-- name: FindRelocationRoom :one
+SELECT r.id, r.name, r.type
+FROM rooms r
+JOIN rooms old ON old.id = $1
+WHERE (r.type = 'classroom' OR $2::BOOLEAN)
+    AND r.capacity >= old.capacity
+    AND r.equipment @> old.equipment
+    AND room_is_free($3, r.id)
+ORDER BY
+    ((r.type = 'laboratory') = $2::BOOLEAN) DESC,
+    (r.building = old.building) DESC,
+    r.id
+LIMIT 1
+FOR UPDATE OF r SKIP LOCKED
+`
+
+type FindRelocationRoomParams struct {
+	RoomID     int32  `db:"room_id" json:"room_id"`
+	Laboratory bool   `db:"laboratory" json:"laboratory"`
+	Semester   string `db:"semester" json:"semester"`
+}
+
+type FindRelocationRoomRow struct {
+	ID   int32    `db:"id" json:"id"`
+	Name string   `db:"name" json:"name"`
+	Type RoomType `db:"type" json:"type"`
+}
+
+func (q *Queries) FindRelocationRoom(ctx context.Context, arg FindRelocationRoomParams) (FindRelocationRoomRow, error) {
+	row := q.db.QueryRow(ctx, findRelocationRoom, arg.RoomID, arg.Laboratory, arg.Semester)
+	var i FindRelocationRoomRow
+	err := row.Scan(&i.ID, &i.Name, &i.Type)
+	return i, err
+}
+
+const generateRooms = `-- name: GenerateRooms :exec
+SELECT generate_rooms($1, $2)
+`
+
+type GenerateRoomsParams struct {
+	NormalRooms  int32 `db:"normal_rooms" json:"normal_rooms"`
+	Laboratories int32 `db:"laboratories" json:"laboratories"`
+}
+
+func (q *Queries) GenerateRooms(ctx context.Context, arg GenerateRoomsParams) error {
+	_, err := q.db.Exec(ctx, generateRooms, arg.NormalRooms, arg.Laboratories)
+	return err
+}
+
+const getAuditRecords = `-- name: GetAuditRecords :many
+SELECT id, created_at, identity, request_id, faculty, type, payload_hash, outcome, error, latency_us, semester, program, room
+FROM audit_log
+WHERE ($1::TEXT = '' OR faculty = $1::TEXT)
+    AND ($2::TEXT = '' OR type = $2::TEXT)
+    AND ($3::TEXT = '' OR outcome = $3::TEXT)
+    AND ($4::TIMESTAMPTZ IS NULL OR created_at >= $4::TIMESTAMPTZ)
+ORDER BY id DESC
+LIMIT NULLIF($5::INT, 0)
+`
+
+type GetAuditRecordsParams struct {
+	Faculty    string             `db:"faculty" json:"faculty"`
+	Type       string             `db:"type" json:"type"`
+	Outcome    string             `db:"outcome" json:"outcome"`
+	Since      pgtype.Timestamptz `db:"since" json:"since"`
+	MaxRecords int32              `db:"max_records" json:"max_records"`
+}
+
+func (q *Queries) GetAuditRecords(ctx context.Context, arg GetAuditRecordsParams) ([]AuditLog, error) {
+	rows, err := q.db.Query(ctx, getAuditRecords,
+		arg.Faculty,
+		arg.Type,
+		arg.Outcome,
+		arg.Since,
+		arg.MaxRecords,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.Identity,
+			&i.RequestID,
+			&i.Faculty,
+			&i.Type,
+			&i.PayloadHash,
+			&i.Outcome,
+			&i.Error,
+			&i.LatencyUs,
+			&i.Semester,
+			&i.Program,
+			&i.Room,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookingsByFacultyProgramSemester = `-- name: GetBookingsByFacultyProgramSemester :many
+SELECT r.name, r.type, b.weekday, lower(b.hours)::INT AS start_hour, upper(b.hours)::INT AS end_hour, b.adapted
+FROM room_bookings b
+JOIN rooms r
+    ON r.id = b.room_id
+WHERE b.faculty = $1
+    AND b.program = $2
+    AND b.semester = $3
+ORDER BY b.weekday, lower(b.hours), r.name
+`
+
+type GetBookingsByFacultyProgramSemesterParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Program  string `db:"program" json:"program"`
+	Semester string `db:"semester" json:"semester"`
+}
+
+type GetBookingsByFacultyProgramSemesterRow struct {
+	Name      string   `db:"name" json:"name"`
+	Type      RoomType `db:"type" json:"type"`
+	Weekday   int32    `db:"weekday" json:"weekday"`
+	StartHour int32    `db:"start_hour" json:"start_hour"`
+	EndHour   int32    `db:"end_hour" json:"end_hour"`
+	Adapted   bool     `db:"adapted" json:"adapted"`
+}
+
+func (q *Queries) GetBookingsByFacultyProgramSemester(ctx context.Context, arg GetBookingsByFacultyProgramSemesterParams) ([]GetBookingsByFacultyProgramSemesterRow, error) {
+	rows, err := q.db.Query(ctx, getBookingsByFacultyProgramSemester, arg.Faculty, arg.Program, arg.Semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBookingsByFacultyProgramSemesterRow
+	for rows.Next() {
+		var i GetBookingsByFacultyProgramSemesterRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Type,
+			&i.Weekday,
+			&i.StartHour,
+			&i.EndHour,
+			&i.Adapted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getEventsUntil = `-- name: GetEventsUntil :many
+SELECT id, occurred_at, type, semester, faculty, program, room_id, room, adapted, weekday, hours
+FROM allocation_events
+WHERE occurred_at <= $1
+    AND ($2::TEXT = '' OR semester = $2::TEXT)
+ORDER BY id
+`
+
+type GetEventsUntilParams struct {
+	Until    pgtype.Timestamptz `db:"until" json:"until"`
+	Semester string             `db:"semester" json:"semester"`
+}
+
+func (q *Queries) GetEventsUntil(ctx context.Context, arg GetEventsUntilParams) ([]AllocationEvent, error) {
+	rows, err := q.db.Query(ctx, getEventsUntil, arg.Until, arg.Semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AllocationEvent
+	for rows.Next() {
+		var i AllocationEvent
+		if err := rows.Scan(
+			&i.ID,
+			&i.OccurredAt,
+			&i.Type,
+			&i.Semester,
+			&i.Faculty,
+			&i.Program,
+			&i.RoomID,
+			&i.Room,
+			&i.Adapted,
+			&i.Weekday,
+			&i.Hours,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFacultyPrograms = `-- name: GetFacultyPrograms :many
+SELECT name
+FROM programs
+WHERE faculty = $1
+ORDER BY name
+`
+
+func (q *Queries) GetFacultyPrograms(ctx context.Context, faculty string) ([]string, error) {
+	rows, err := q.db.Query(ctx, getFacultyPrograms, faculty)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFacultyQuota = `-- name: GetFacultyQuota :one
+SELECT classrooms, laboratories
+FROM faculty_quotas
+WHERE semester = $1
+    AND faculty = $2
+FOR UPDATE
+`
+
+type GetFacultyQuotaParams struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+}
+
+type GetFacultyQuotaRow struct {
+	Classrooms   int32 `db:"classrooms" json:"classrooms"`
+	Laboratories int32 `db:"laboratories" json:"laboratories"`
+}
+
+func (q *Queries) GetFacultyQuota(ctx context.Context, arg GetFacultyQuotaParams) (GetFacultyQuotaRow, error) {
+	row := q.db.QueryRow(ctx, getFacultyQuota, arg.Semester, arg.Faculty)
+	var i GetFacultyQuotaRow
+	err := row.Scan(&i.Classrooms, &i.Laboratories)
+	return i, err
+}
+
+const getFacultyUsage = `-- name: GetFacultyUsage :one
+WITH held AS (
+    SELECT
+        COUNT(*) FILTER (WHERE r.type = 'classroom' AND NOT ra.adapted) AS classrooms,
+        COUNT(*) FILTER (WHERE r.type = 'laboratory' OR ra.adapted) AS laboratories
+    FROM room_allocations ra
+    JOIN rooms r
+        ON r.id = ra.room_id
+    WHERE ra.semester = $1
+        AND ra.faculty = $2
+), booked AS (
+    SELECT
+        COUNT(*) FILTER (WHERE r.type = 'classroom' AND NOT rb.adapted) AS classrooms,
+        COUNT(*) FILTER (WHERE r.type = 'laboratory' OR rb.adapted) AS laboratories
+    FROM room_bookings rb
+    JOIN rooms r
+        ON r.id = rb.room_id
+    WHERE rb.semester = $1
+        AND rb.faculty = $2
+), waiting AS (
+    SELECT
+        COALESCE(SUM(w.classrooms), 0) AS classrooms,
+        COALESCE(SUM(w.laboratories), 0) AS laboratories
+    FROM waitlist w
+    WHERE w.semester = $1
+        AND w.faculty = $2
+)
+SELECT (held.classrooms + booked.classrooms + waiting.classrooms)::INT AS classrooms,
+    (held.laboratories + booked.laboratories + waiting.laboratories)::INT AS laboratories
+FROM held, booked, waiting
+`
+
+type GetFacultyUsageParams struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+}
+
+type GetFacultyUsageRow struct {
+	Classrooms   int32 `db:"classrooms" json:"classrooms"`
+	Laboratories int32 `db:"laboratories" json:"laboratories"`
+}
+
+func (q *Queries) GetFacultyUsage(ctx context.Context, arg GetFacultyUsageParams) (GetFacultyUsageRow, error) {
+	row := q.db.QueryRow(ctx, getFacultyUsage, arg.Semester, arg.Faculty)
+	var i GetFacultyUsageRow
+	err := row.Scan(&i.Classrooms, &i.Laboratories)
+	return i, err
+}
+
+const getOutOfServiceAllocations = `-- name: GetOutOfServiceAllocations :many
+SELECT a.room_id, r.name, r.type, a.faculty, a.program, a.state, a.adapted, a.offered_at
+FROM room_allocations a
+JOIN rooms r ON r.id = a.room_id
+WHERE a.semester = $1
+    AND NOT room_in_service(a.semester, a.room_id)
+    AND ($2::TEXT = '' OR r.name = $2)
+ORDER BY a.faculty, a.program, a.room_id
+FOR UPDATE OF a
+`
+
+type GetOutOfServiceAllocationsParams struct {
+	Semester string `db:"semester" json:"semester"`
+	Room     string `db:"room" json:"room"`
+}
+
+type GetOutOfServiceAllocationsRow struct {
+	RoomID    int32              `db:"room_id" json:"room_id"`
+	Name      string             `db:"name" json:"name"`
+	Type      RoomType           `db:"type" json:"type"`
+	Faculty   string             `db:"faculty" json:"faculty"`
+	Program   string             `db:"program" json:"program"`
+	State     RoomState          `db:"state" json:"state"`
+	Adapted   bool               `db:"adapted" json:"adapted"`
+	OfferedAt pgtype.Timestamptz `db:"offered_at" json:"offered_at"`
+}
+
+func (q *Queries) GetOutOfServiceAllocations(ctx context.Context, arg GetOutOfServiceAllocationsParams) ([]GetOutOfServiceAllocationsRow, error) {
+	rows, err := q.db.Query(ctx, getOutOfServiceAllocations, arg.Semester, arg.Room)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOutOfServiceAllocationsRow
+	for rows.Next() {
+		var i GetOutOfServiceAllocationsRow
+		if err := rows.Scan(
+			&i.RoomID,
+			&i.Name,
+			&i.Type,
+			&i.Faculty,
+			&i.Program,
+			&i.State,
+			&i.Adapted,
+			&i.OfferedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOutOfServiceBookings = `-- name: GetOutOfServiceBookings :many
+SELECT b.id, b.room_id, r.name, r.type, b.faculty, b.program, b.state, b.adapted,
+    b.weekday, lower(b.hours)::INT AS start_hour, upper(b.hours)::INT AS end_hour, b.offered_at
+FROM room_bookings b
+JOIN rooms r ON r.id = b.room_id
+WHERE b.semester = $1
+    AND NOT room_in_service(b.semester, b.room_id)
+    AND ($2::TEXT = '' OR r.name = $2)
+ORDER BY b.faculty, b.program, b.weekday, lower(b.hours), b.room_id
+FOR UPDATE OF b
+`
+
+type GetOutOfServiceBookingsParams struct {
+	Semester string `db:"semester" json:"semester"`
+	Room     string `db:"room" json:"room"`
+}
+
+type GetOutOfServiceBookingsRow struct {
+	ID        int32              `db:"id" json:"id"`
+	RoomID    int32              `db:"room_id" json:"room_id"`
+	Name      string             `db:"name" json:"name"`
+	Type      RoomType           `db:"type" json:"type"`
+	Faculty   string             `db:"faculty" json:"faculty"`
+	Program   string             `db:"program" json:"program"`
+	State     RoomState          `db:"state" json:"state"`
+	Adapted   bool               `db:"adapted" json:"adapted"`
+	Weekday   int32              `db:"weekday" json:"weekday"`
+	StartHour int32              `db:"start_hour" json:"start_hour"`
+	EndHour   int32              `db:"end_hour" json:"end_hour"`
+	OfferedAt pgtype.Timestamptz `db:"offered_at" json:"offered_at"`
+}
+
+func (q *Queries) GetOutOfServiceBookings(ctx context.Context, arg GetOutOfServiceBookingsParams) ([]GetOutOfServiceBookingsRow, error) {
+	rows, err := q.db.Query(ctx, getOutOfServiceBookings, arg.Semester, arg.Room)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOutOfServiceBookingsRow
+	for rows.Next() {
+		var i GetOutOfServiceBookingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.RoomID,
+			&i.Name,
+			&i.Type,
+			&i.Faculty,
+			&i.Program,
+			&i.State,
+			&i.Adapted,
+			&i.Weekday,
+			&i.StartHour,
+			&i.EndHour,
+			&i.OfferedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOutOfServiceSemesters = `-- name: GetOutOfServiceSemesters :many
+SELECT a.semester
+FROM room_allocations a
+JOIN semesters s ON s.name = a.semester
+WHERE s.state <> 'archived'
+    AND NOT room_in_service(a.semester, a.room_id)
+UNION
+SELECT b.semester
+FROM room_bookings b
+JOIN semesters s ON s.name = b.semester
+WHERE s.state <> 'archived'
+    AND NOT room_in_service(b.semester, b.room_id)
+ORDER BY semester
+`
+
+func (q *Queries) GetOutOfServiceSemesters(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, getOutOfServiceSemesters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var semester string
+		if err := rows.Scan(&semester); err != nil {
+			return nil, err
+		}
+		items = append(items, semester)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOutageConflicts = `-- name: GetOutageConflicts :many
+SELECT a.semester, a.faculty, a.program, a.state
+FROM room_allocations a
+JOIN semesters s ON s.name = a.semester
+WHERE a.room_id = $1
+    AND s.state <> 'archived'
+UNION
+SELECT b.semester, b.faculty, b.program, b.state
+FROM room_bookings b
+JOIN semesters s ON s.name = b.semester
+WHERE b.room_id = $1
+    AND s.state <> 'archived'
+ORDER BY semester, faculty, program
+`
+
+type GetOutageConflictsRow struct {
+	Semester string    `db:"semester" json:"semester"`
+	Faculty  string    `db:"faculty" json:"faculty"`
+	Program  string    `db:"program" json:"program"`
+	State    RoomState `db:"state" json:"state"`
+}
+
+func (q *Queries) GetOutageConflicts(ctx context.Context, roomID int32) ([]GetOutageConflictsRow, error) {
+	rows, err := q.db.Query(ctx, getOutageConflicts, roomID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOutageConflictsRow
+	for rows.Next() {
+		var i GetOutageConflictsRow
+		if err := rows.Scan(
+			&i.Semester,
+			&i.Faculty,
+			&i.Program,
+			&i.State,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getProcessedRequest = `-- name: GetProcessedRequest :one
+SELECT type, response
+FROM processed_requests
+WHERE identity = $1
+    AND request_id = $2
+    AND created_at > $3
+`
+
+type GetProcessedRequestParams struct {
+	Identity  string             `db:"identity" json:"identity"`
+	RequestID int32              `db:"request_id" json:"request_id"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+type GetProcessedRequestRow struct {
+	Type     string `db:"type" json:"type"`
+	Response []byte `db:"response" json:"response"`
+}
+
+func (q *Queries) GetProcessedRequest(ctx context.Context, arg GetProcessedRequestParams) (GetProcessedRequestRow, error) {
+	row := q.db.QueryRow(ctx, getProcessedRequest, arg.Identity, arg.RequestID, arg.CreatedAt)
+	var i GetProcessedRequestRow
+	err := row.Scan(&i.Type, &i.Response)
+	return i, err
+}
+
+const getRoomByName = `-- name: GetRoomByName :one
+SELECT id, name, type
+FROM rooms
+WHERE name = $1
+ORDER BY id
+LIMIT 1
+FOR UPDATE
+`
+
+type GetRoomByNameRow struct {
+	ID   int32    `db:"id" json:"id"`
+	Name string   `db:"name" json:"name"`
+	Type RoomType `db:"type" json:"type"`
+}
+
+func (q *Queries) GetRoomByName(ctx context.Context, name string) (GetRoomByNameRow, error) {
+	row := q.db.QueryRow(ctx, getRoomByName, name)
+	var i GetRoomByNameRow
+	err := row.Scan(&i.ID, &i.Name, &i.Type)
+	return i, err
+}
+
+const getRoomsByFacultyProgramSemester = `-- name: GetRoomsByFacultyProgramSemester :many
+SELECT r.id, r.name, r.type, r.building, ra.adapted
+FROM rooms r
+JOIN room_allocations ra
+    ON r.id = ra.room_id
+WHERE ra.faculty = $1
+    AND ra.program = $2
+    AND ra.semester = $3
+`
+
+type GetRoomsByFacultyProgramSemesterParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Program  string `db:"program" json:"program"`
+	Semester string `db:"semester" json:"semester"`
+}
+
+type GetRoomsByFacultyProgramSemesterRow struct {
+	ID       int32    `db:"id" json:"id"`
+	Name     string   `db:"name" json:"name"`
+	Type     RoomType `db:"type" json:"type"`
+	Building string   `db:"building" json:"building"`
+	Adapted  bool     `db:"adapted" json:"adapted"`
+}
+
+func (q *Queries) GetRoomsByFacultyProgramSemester(ctx context.Context, arg GetRoomsByFacultyProgramSemesterParams) ([]GetRoomsByFacultyProgramSemesterRow, error) {
+	rows, err := q.db.Query(ctx, getRoomsByFacultyProgramSemester, arg.Faculty, arg.Program, arg.Semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRoomsByFacultyProgramSemesterRow
+	for rows.Next() {
+		var i GetRoomsByFacultyProgramSemesterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.Building,
+			&i.Adapted,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getRoomsByFacultySemester = `-- name: GetRoomsByFacultySemester :many
+SELECT ra.room_id, r.name, r.type, ra.adapted, ra.program, ra.state
+FROM room_allocations ra
+JOIN rooms r
+    ON r.id = ra.room_id
+WHERE ra.faculty = $1
+    AND ra.semester = $2
+ORDER BY ra.program, ra.id
+`
+
+type GetRoomsByFacultySemesterParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Semester string `db:"semester" json:"semester"`
+}
+
+type GetRoomsByFacultySemesterRow struct {
+	RoomID  int32     `db:"room_id" json:"room_id"`
+	Name    string    `db:"name" json:"name"`
+	Type    RoomType  `db:"type" json:"type"`
+	Adapted bool      `db:"adapted" json:"adapted"`
+	Program string    `db:"program" json:"program"`
+	State   RoomState `db:"state" json:"state"`
+}
+
+func (q *Queries) GetRoomsByFacultySemester(ctx context.Context, arg GetRoomsByFacultySemesterParams) ([]GetRoomsByFacultySemesterRow, error) {
+	rows, err := q.db.Query(ctx, getRoomsByFacultySemester, arg.Faculty, arg.Semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetRoomsByFacultySemesterRow
+	for rows.Next() {
+		var i GetRoomsByFacultySemesterRow
+		if err := rows.Scan(
+			&i.RoomID,
+			&i.Name,
+			&i.Type,
+			&i.Adapted,
+			&i.Program,
+			&i.State,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getSemesterState = `-- name: GetSemesterState :one
+SELECT state
+FROM semesters
+WHERE name = $1
+FOR SHARE
+`
+
+func (q *Queries) GetSemesterState(ctx context.Context, name string) (SemesterState, error) {
+	row := q.db.QueryRow(ctx, getSemesterState, name)
+	var state SemesterState
+	err := row.Scan(&state)
+	return state, err
+}
+
+const getSwap = `-- name: GetSwap :one
+SELECT id, semester, proposer, recipient, program, state, created_at
+FROM swaps
+WHERE id = $1
+FOR UPDATE
+`
+
+type GetSwapRow struct {
+	ID        int32              `db:"id" json:"id"`
+	Semester  string             `db:"semester" json:"semester"`
+	Proposer  string             `db:"proposer" json:"proposer"`
+	Recipient string             `db:"recipient" json:"recipient"`
+	Program   string             `db:"program" json:"program"`
+	State     SwapState          `db:"state" json:"state"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) GetSwap(ctx context.Context, id int32) (GetSwapRow, error) {
+	row := q.db.QueryRow(ctx, getSwap, id)
+	var i GetSwapRow
+	err := row.Scan(
+		&i.ID,
+		&i.Semester,
+		&i.Proposer,
+		&i.Recipient,
+		&i.Program,
+		&i.State,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getSwapRooms = `-- name: GetSwapRooms :many
+SELECT sr.room_id, r.name, sr.faculty
+FROM swap_rooms sr
+JOIN rooms r
+    ON r.id = sr.room_id
+WHERE sr.swap_id = $1
+ORDER BY sr.room_id
+`
+
+type GetSwapRoomsRow struct {
+	RoomID  int32  `db:"room_id" json:"room_id"`
+	Name    string `db:"name" json:"name"`
+	Faculty string `db:"faculty" json:"faculty"`
+}
+
+func (q *Queries) GetSwapRooms(ctx context.Context, swapID int32) ([]GetSwapRoomsRow, error) {
+	rows, err := q.db.Query(ctx, getSwapRooms, swapID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetSwapRoomsRow
+	for rows.Next() {
+		var i GetSwapRoomsRow
+		if err := rows.Scan(&i.RoomID, &i.Name, &i.Faculty); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWaitlistByFacultySemester = `-- name: GetWaitlistByFacultySemester :many
+SELECT w.program, w.classrooms, w.laboratories, w.created_at,
+    (SELECT COUNT(*) FROM waitlist o WHERE o.semester = w.semester AND o.id <= w.id)::INT AS position
+FROM waitlist w
+WHERE w.faculty = $1
+    AND w.semester = $2
+ORDER BY w.id
+`
+
+type GetWaitlistByFacultySemesterParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Semester string `db:"semester" json:"semester"`
+}
+
+type GetWaitlistByFacultySemesterRow struct {
+	Program      string             `db:"program" json:"program"`
+	Classrooms   int32              `db:"classrooms" json:"classrooms"`
+	Laboratories int32              `db:"laboratories" json:"laboratories"`
+	CreatedAt    pgtype.Timestamptz `db:"created_at" json:"created_at"`
+	Position     int32              `db:"position" json:"position"`
+}
+
+func (q *Queries) GetWaitlistByFacultySemester(ctx context.Context, arg GetWaitlistByFacultySemesterParams) ([]GetWaitlistByFacultySemesterRow, error) {
+	rows, err := q.db.Query(ctx, getWaitlistByFacultySemester, arg.Faculty, arg.Semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetWaitlistByFacultySemesterRow
+	for rows.Next() {
+		var i GetWaitlistByFacultySemesterRow
+		if err := rows.Scan(
+			&i.Program,
+			&i.Classrooms,
+			&i.Laboratories,
+			&i.CreatedAt,
+			&i.Position,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listFreeRooms = `-- name: ListFreeRooms :many
+SELECT id, name, type, capacity, building, floor, equipment
+FROM rooms
+WHERE room_is_free($1, id)
+ORDER BY id
+`
+
+func (q *Queries) ListFreeRooms(ctx context.Context, semester string) ([]Room, error) {
+	rows, err := q.db.Query(ctx, listFreeRooms, semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Room
+	for rows.Next() {
+		var i Room
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.Capacity,
+			&i.Building,
+			&i.Floor,
+			&i.Equipment,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOutages = `-- name: ListOutages :many
+SELECT o.id, r.name, o.starts_at, o.ends_at, o.reason
+FROM room_outages o
+JOIN rooms r ON r.id = o.room_id
+WHERE $1::BOOLEAN OR o.ends_at > now()
+ORDER BY o.starts_at, o.id
+`
+
+type ListOutagesRow struct {
+	ID       int32              `db:"id" json:"id"`
+	Name     string             `db:"name" json:"name"`
+	StartsAt pgtype.Timestamptz `db:"starts_at" json:"starts_at"`
+	EndsAt   pgtype.Timestamptz `db:"ends_at" json:"ends_at"`
+	Reason   string             `db:"reason" json:"reason"`
+}
+
+func (q *Queries) ListOutages(ctx context.Context, all bool) ([]ListOutagesRow, error) {
+	rows, err := q.db.Query(ctx, listOutages, all)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListOutagesRow
+	for rows.Next() {
+		var i ListOutagesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.StartsAt,
+			&i.EndsAt,
+			&i.Reason,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingSwaps = `-- name: ListPendingSwaps :many
+SELECT id, semester, proposer, recipient, program, state, created_at
+FROM swaps
+WHERE semester = $1
+    AND (proposer = $2 OR recipient = $2)
+    AND state = 'pending'
+ORDER BY id
+`
+
+type ListPendingSwapsParams struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+}
+
+type ListPendingSwapsRow struct {
+	ID        int32              `db:"id" json:"id"`
+	Semester  string             `db:"semester" json:"semester"`
+	Proposer  string             `db:"proposer" json:"proposer"`
+	Recipient string             `db:"recipient" json:"recipient"`
+	Program   string             `db:"program" json:"program"`
+	State     SwapState          `db:"state" json:"state"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) ListPendingSwaps(ctx context.Context, arg ListPendingSwapsParams) ([]ListPendingSwapsRow, error) {
+	rows, err := q.db.Query(ctx, listPendingSwaps, arg.Semester, arg.Faculty)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPendingSwapsRow
+	for rows.Next() {
+		var i ListPendingSwapsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Semester,
+			&i.Proposer,
+			&i.Recipient,
+			&i.Program,
+			&i.State,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRoomsInService = `-- name: ListRoomsInService :many
+SELECT id, name, type, capacity, building, floor, equipment
+FROM rooms
+WHERE room_in_service($1, id)
+ORDER BY id
+`
+
+func (q *Queries) ListRoomsInService(ctx context.Context, semester string) ([]Room, error) {
+	rows, err := q.db.Query(ctx, listRoomsInService, semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Room
+	for rows.Next() {
+		var i Room
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Type,
+			&i.Capacity,
+			&i.Building,
+			&i.Floor,
+			&i.Equipment,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWaitlistSemesters = `-- name: ListWaitlistSemesters :many
+SELECT DISTINCT semester
+FROM waitlist
+ORDER BY semester
+`
+
+func (q *Queries) ListWaitlistSemesters(ctx context.Context) ([]string, error) {
+	rows, err := q.db.Query(ctx, listWaitlistSemesters)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var semester string
+		if err := rows.Scan(&semester); err != nil {
+			return nil, err
+		}
+		items = append(items, semester)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockBooking = `-- name: LockBooking :exec
+UPDATE room_bookings
+SET state = 'locked'
+WHERE id = $1
+`
+
+func (q *Queries) LockBooking(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, lockBooking, id)
+	return err
+}
+
+const lockBookings = `-- name: LockBookings :execrows
+UPDATE room_bookings
+SET state = 'locked'
+WHERE faculty = $1
+    AND semester = $2
+    AND state = 'awaiting'
+    AND offered_at > now() - $3::INTERVAL
+`
+
+type LockBookingsParams struct {
+	Faculty  string          `db:"faculty" json:"faculty"`
+	Semester string          `db:"semester" json:"semester"`
+	Hold     pgtype.Interval `db:"hold" json:"hold"`
+}
+
+func (q *Queries) LockBookings(ctx context.Context, arg LockBookingsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, lockBookings, arg.Faculty, arg.Semester, arg.Hold)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const lockProgramRooms = `-- name: LockProgramRooms :exec
+UPDATE room_allocations
+SET state = 'locked'
+WHERE faculty = $1
+    AND semester = $2
+    AND program = $3
+    AND state = 'awaiting'
+    AND offered_at = now()
+`
+
+type LockProgramRoomsParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Semester string `db:"semester" json:"semester"`
+	Program  string `db:"program" json:"program"`
+}
+
+func (q *Queries) LockProgramRooms(ctx context.Context, arg LockProgramRoomsParams) error {
+	_, err := q.db.Exec(ctx, lockProgramRooms, arg.Faculty, arg.Semester, arg.Program)
+	return err
+}
+
+const lockRoom = `-- name: LockRoom :exec
+UPDATE room_allocations
+SET state = 'locked'
+WHERE semester = $1
+    AND room_id = $2
+`
+
+type LockRoomParams struct {
+	Semester string `db:"semester" json:"semester"`
+	RoomID   int32  `db:"room_id" json:"room_id"`
+}
+
+func (q *Queries) LockRoom(ctx context.Context, arg LockRoomParams) error {
+	_, err := q.db.Exec(ctx, lockRoom, arg.Semester, arg.RoomID)
+	return err
+}
+
+const lockRooms = `-- name: LockRooms :one
+SELECT lock_rooms($1, $2, $3)
+`
+
+type LockRoomsParams struct {
+	FacultyName  string          `db:"faculty_name" json:"faculty_name"`
+	SemesterName string          `db:"semester_name" json:"semester_name"`
+	Hold         pgtype.Interval `db:"hold" json:"hold"`
+}
+
+func (q *Queries) LockRooms(ctx context.Context, arg LockRoomsParams) (int32, error) {
+	row := q.db.QueryRow(ctx, lockRooms, arg.FacultyName, arg.SemesterName, arg.Hold)
+	var lock_rooms int32
+	err := row.Scan(&lock_rooms)
+	return lock_rooms, err
+}
+
+const markBookingUnplaced = `-- name: MarkBookingUnplaced :execrows
+UPDATE room_bookings
+SET unplaced = TRUE
+WHERE id = $1
+    AND NOT unplaced
+`
+
+func (q *Queries) MarkBookingUnplaced(ctx context.Context, id int32) (int64, error) {
+	result, err := q.db.Exec(ctx, markBookingUnplaced, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const markRoomUnplaced = `-- name: MarkRoomUnplaced :execrows
+UPDATE room_allocations
+SET unplaced = TRUE
+WHERE semester = $1
+    AND room_id = $2
+    AND NOT unplaced
+`
+
+type MarkRoomUnplacedParams struct {
+	Semester string `db:"semester" json:"semester"`
+	RoomID   int32  `db:"room_id" json:"room_id"`
+}
+
+func (q *Queries) MarkRoomUnplaced(ctx context.Context, arg MarkRoomUnplacedParams) (int64, error) {
+	result, err := q.db.Exec(ctx, markRoomUnplaced, arg.Semester, arg.RoomID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const offerRoom = `-- name: OfferRoom :execrows
+INSERT INTO room_allocations (state, room_id, semester, faculty, program, adapted)
+SELECT 'awaiting', r.id, $1, $2, $3, $4
+FROM rooms r
+WHERE r.id = $5
+    AND room_is_free($1, r.id)
+FOR UPDATE SKIP LOCKED
+ON CONFLICT (semester, room_id) DO NOTHING
+`
+
+type OfferRoomParams struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+	Program  string `db:"program" json:"program"`
+	Adapted  bool   `db:"adapted" json:"adapted"`
+	RoomID   int32  `db:"room_id" json:"room_id"`
+}
+
+func (q *Queries) OfferRoom(ctx context.Context, arg OfferRoomParams) (int64, error) {
+	result, err := q.db.Exec(ctx, offerRoom,
+		arg.Semester,
+		arg.Faculty,
+		arg.Program,
+		arg.Adapted,
+		arg.RoomID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const peekFacultyQuota = `-- name: PeekFacultyQuota :one
+SELECT classrooms, laboratories
+FROM faculty_quotas
+WHERE semester = $1
+    AND faculty = $2
+`
+
+type PeekFacultyQuotaParams struct {
+	Semester string `db:"semester" json:"semester"`
+	Faculty  string `db:"faculty" json:"faculty"`
+}
+
+type PeekFacultyQuotaRow struct {
+	Classrooms   int32 `db:"classrooms" json:"classrooms"`
+	Laboratories int32 `db:"laboratories" json:"laboratories"`
+}
+
+func (q *Queries) PeekFacultyQuota(ctx context.Context, arg PeekFacultyQuotaParams) (PeekFacultyQuotaRow, error) {
+	row := q.db.QueryRow(ctx, peekFacultyQuota, arg.Semester, arg.Faculty)
+	var i PeekFacultyQuotaRow
+	err := row.Scan(&i.Classrooms, &i.Laboratories)
+	return i, err
+}
+
+const peekSemesterState = `-- name: PeekSemesterState :one
+SELECT state
+FROM semesters
+WHERE name = $1
+`
+
+func (q *Queries) PeekSemesterState(ctx context.Context, name string) (SemesterState, error) {
+	row := q.db.QueryRow(ctx, peekSemesterState, name)
+	var state SemesterState
+	err := row.Scan(&state)
+	return state, err
+}
+
+const processWaitlist = `-- name: ProcessWaitlist :many
+SELECT faculty, program, classrooms, laboratories
+FROM process_waitlist($1, $2, $3)
+`
+
+type ProcessWaitlistParams struct {
+	Semester          string  `db:"_semester" json:"_semester"`
+	ClassroomRanking  []int32 `db:"_classroom_ranking" json:"_classroom_ranking"`
+	LaboratoryRanking []int32 `db:"_laboratory_ranking" json:"_laboratory_ranking"`
+}
+
+type ProcessWaitlistRow struct {
+	Faculty      string `db:"faculty" json:"faculty"`
+	Program      string `db:"program" json:"program"`
+	Classrooms   int32  `db:"classrooms" json:"classrooms"`
+	Laboratories int32  `db:"laboratories" json:"laboratories"`
+}
+
+func (q *Queries) ProcessWaitlist(ctx context.Context, arg ProcessWaitlistParams) ([]ProcessWaitlistRow, error) {
+	rows, err := q.db.Query(ctx, processWaitlist, arg.Semester, arg.ClassroomRanking, arg.LaboratoryRanking)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProcessWaitlistRow
+	for rows.Next() {
+		var i ProcessWaitlistRow
+		if err := rows.Scan(
+			&i.Faculty,
+			&i.Program,
+			&i.Classrooms,
+			&i.Laboratories,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const registerFaculty = `-- name: RegisterFaculty :exec
+INSERT INTO faculties (name)
+VALUES ($1)
+ON CONFLICT (name) DO NOTHING
+`
+
+func (q *Queries) RegisterFaculty(ctx context.Context, name string) error {
+	_, err := q.db.Exec(ctx, registerFaculty, name)
+	return err
+}
+
+const registerProgram = `-- name: RegisterProgram :exec
+INSERT INTO programs (faculty, name)
+VALUES ($1, $2)
+ON CONFLICT (faculty, name) DO NOTHING
+`
+
+type RegisterProgramParams struct {
+	Faculty string `db:"faculty" json:"faculty"`
+	Name    string `db:"name" json:"name"`
+}
+
+func (q *Queries) RegisterProgram(ctx context.Context, arg RegisterProgramParams) error {
+	_, err := q.db.Exec(ctx, registerProgram, arg.Faculty, arg.Name)
+	return err
+}
+
+const releaseBookings = `-- name: ReleaseBookings :many
+WITH released AS (
+    DELETE FROM room_bookings
+    WHERE faculty = $1
+        AND semester = $2
+        AND state = 'awaiting'
+    RETURNING room_id
+)
+SELECT DISTINCT r.name
+FROM released
+JOIN rooms r
+    ON r.id = released.room_id
+`
+
+type ReleaseBookingsParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Semester string `db:"semester" json:"semester"`
+}
+
+func (q *Queries) ReleaseBookings(ctx context.Context, arg ReleaseBookingsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, releaseBookings, arg.Faculty, arg.Semester)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const releaseRooms = `-- name: ReleaseRooms :many
+SELECT release_rooms($1, $2)::TEXT AS name
+`
+
+type ReleaseRoomsParams struct {
+	FacultyName  string `db:"faculty_name" json:"faculty_name"`
+	SemesterName string `db:"semester_name" json:"semester_name"`
+}
+
+func (q *Queries) ReleaseRooms(ctx context.Context, arg ReleaseRoomsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, releaseRooms, arg.FacultyName, arg.SemesterName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const relocateBooking = `-- name: RelocateBooking :one
+INSERT INTO room_bookings (state, room_id, semester, faculty, program, weekday, hours, adapted, offered_at)
+VALUES ('awaiting', $1, $2, $3, $4, $5,
+    int4range($6, $7), $8, $9)
+RETURNING id
+`
+
+type RelocateBookingParams struct {
+	RoomID    int32              `db:"room_id" json:"room_id"`
+	Semester  string             `db:"semester" json:"semester"`
+	Faculty   string             `db:"faculty" json:"faculty"`
+	Program   string             `db:"program" json:"program"`
+	Weekday   int32              `db:"weekday" json:"weekday"`
+	StartHour int32              `db:"start_hour" json:"start_hour"`
+	EndHour   int32              `db:"end_hour" json:"end_hour"`
+	Adapted   bool               `db:"adapted" json:"adapted"`
+	OfferedAt pgtype.Timestamptz `db:"offered_at" json:"offered_at"`
+}
+
+func (q *Queries) RelocateBooking(ctx context.Context, arg RelocateBookingParams) (int32, error) {
+	row := q.db.QueryRow(ctx, relocateBooking,
+		arg.RoomID,
+		arg.Semester,
+		arg.Faculty,
+		arg.Program,
+		arg.Weekday,
+		arg.StartHour,
+		arg.EndHour,
+		arg.Adapted,
+		arg.OfferedAt,
+	)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const relocateRoom = `-- name: RelocateRoom :execrows
+INSERT INTO room_allocations (state, room_id, semester, faculty, program, adapted, offered_at)
+SELECT 'awaiting', r.id, $1, $2, $3, $4, $5
+FROM rooms r
+WHERE r.id = $6
+    AND room_is_free($1, r.id)
+FOR UPDATE SKIP LOCKED
+ON CONFLICT (semester, room_id) DO NOTHING
+`
+
+type RelocateRoomParams struct {
+	Semester  string             `db:"semester" json:"semester"`
+	Faculty   string             `db:"faculty" json:"faculty"`
+	Program   string             `db:"program" json:"program"`
+	Adapted   bool               `db:"adapted" json:"adapted"`
+	OfferedAt pgtype.Timestamptz `db:"offered_at" json:"offered_at"`
+	RoomID    int32              `db:"room_id" json:"room_id"`
+}
+
+func (q *Queries) RelocateRoom(ctx context.Context, arg RelocateRoomParams) (int64, error) {
+	result, err := q.db.Exec(ctx, relocateRoom,
+		arg.Semester,
+		arg.Faculty,
+		arg.Program,
+		arg.Adapted,
+		arg.OfferedAt,
+		arg.RoomID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const removeFromWaitlist = `-- name: RemoveFromWaitlist :exec
+DELETE FROM waitlist
+WHERE faculty = $1
+    AND semester = $2
+`
+
+type RemoveFromWaitlistParams struct {
+	Faculty  string `db:"faculty" json:"faculty"`
+	Semester string `db:"semester" json:"semester"`
+}
+
+func (q *Queries) RemoveFromWaitlist(ctx context.Context, arg RemoveFromWaitlistParams) error {
+	_, err := q.db.Exec(ctx, removeFromWaitlist, arg.Faculty, arg.Semester)
+	return err
+}
+
+const revokeBooking = `-- name: RevokeBooking :exec
+DELETE FROM room_bookings
+WHERE id = $1
+`
+
+func (q *Queries) RevokeBooking(ctx context.Context, id int32) error {
+	_, err := q.db.Exec(ctx, revokeBooking, id)
+	return err
+}
+
+const revokeRoom = `-- name: RevokeRoom :one
+DELETE FROM room_allocations
+WHERE semester = $1
+    AND room_id = $2
+RETURNING faculty, program, adapted
+`
+
+type RevokeRoomParams struct {
+	Semester string `db:"semester" json:"semester"`
+	RoomID   int32  `db:"room_id" json:"room_id"`
+}
+
+type RevokeRoomRow struct {
+	Faculty string `db:"faculty" json:"faculty"`
+	Program string `db:"program" json:"program"`
+	Adapted bool   `db:"adapted" json:"adapted"`
+}
+
+func (q *Queries) RevokeRoom(ctx context.Context, arg RevokeRoomParams) (RevokeRoomRow, error) {
+	row := q.db.QueryRow(ctx, revokeRoom, arg.Semester, arg.RoomID)
+	var i RevokeRoomRow
+	err := row.Scan(&i.Faculty, &i.Program, &i.Adapted)
+	return i, err
+}
+
+const saveProcessedRequest = `-- name: SaveProcessedRequest :execrows
+INSERT INTO processed_requests (identity, request_id, type, response)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (identity, request_id) DO UPDATE
+SET type = EXCLUDED.type,
+    response = EXCLUDED.response,
+    created_at = now()
+WHERE processed_requests.created_at <= $5
+`
+
+type SaveProcessedRequestParams struct {
+	Identity  string             `db:"identity" json:"identity"`
+	RequestID int32              `db:"request_id" json:"request_id"`
+	Type      string             `db:"type" json:"type"`
+	Response  []byte             `db:"response" json:"response"`
+	CreatedAt pgtype.Timestamptz `db:"created_at" json:"created_at"`
+}
+
+func (q *Queries) SaveProcessedRequest(ctx context.Context, arg SaveProcessedRequestParams) (int64, error) {
+	result, err := q.db.Exec(ctx, saveProcessedRequest,
+		arg.Identity,
+		arg.RequestID,
+		arg.Type,
+		arg.Response,
+		arg.CreatedAt,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const scheduleProgram = `-- name: ScheduleProgram :one
+SELECT schedule_program($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+`
+
+type ScheduleProgramParams struct {
+	Semester     string  `db:"_semester" json:"_semester"`
+	Faculty      string  `db:"_faculty" json:"_faculty"`
+	Program      string  `db:"_program" json:"_program"`
+	Laboratories bool    `db:"_laboratories" json:"_laboratories"`
+	Blocks       int32   `db:"_blocks" json:"_blocks"`
+	Strict       bool    `db:"_strict" json:"_strict"`
+	MinCapacity  int32   `db:"_min_capacity" json:"_min_capacity"`
+	Equipment    string  `db:"_equipment" json:"_equipment"`
+	Building     string  `db:"_building" json:"_building"`
+	Ranking      []int32 `db:"_ranking" json:"_ranking"`
+}
+
+func (q *Queries) ScheduleProgram(ctx context.Context, arg ScheduleProgramParams) (int32, error) {
+	row := q.db.QueryRow(ctx, scheduleProgram,
+		arg.Semester,
+		arg.Faculty,
+		arg.Program,
+		arg.Laboratories,
+		arg.Blocks,
+		arg.Strict,
+		arg.MinCapacity,
+		arg.Equipment,
+		arg.Building,
+		arg.Ranking,
+	)
+	var schedule_program int32
+	err := row.Scan(&schedule_program)
+	return schedule_program, err
+}
+
+const setFacultyQuota = `-- name: SetFacultyQuota :exec
+INSERT INTO faculty_quotas (semester, faculty, classrooms, laboratories)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (semester, faculty) DO UPDATE
+SET classrooms = EXCLUDED.classrooms,
+    laboratories = EXCLUDED.laboratories
+`
+
+type SetFacultyQuotaParams struct {
+	Semester     string `db:"semester" json:"semester"`
+	Faculty      string `db:"faculty" json:"faculty"`
+	Classrooms   int32  `db:"classrooms" json:"classrooms"`
+	Laboratories int32  `db:"laboratories" json:"laboratories"`
+}
+
+func (q *Queries) SetFacultyQuota(ctx context.Context, arg SetFacultyQuotaParams) error {
+	_, err := q.db.Exec(ctx, setFacultyQuota,
+		arg.Semester,
+		arg.Faculty,
+		arg.Classrooms,
+		arg.Laboratories,
+	)
+	return err
+}
+
+const setReleaseReason = `-- name: SetReleaseReason :exec
+SELECT set_config('central.release_reason', $1, TRUE)
+`
+
+func (q *Queries) SetReleaseReason(ctx context.Context, newValue string) error {
+	_, err := q.db.Exec(ctx, setReleaseReason, newValue)
+	return err
+}
+
+const shrinkRooms = `-- name: ShrinkRooms :many
+SELECT shrink_rooms($1, $2, $3, $4, $5)::TEXT AS name
+`
+
+type ShrinkRoomsParams struct {
+	Semester     string `db:"_semester" json:"_semester"`
+	Faculty      string `db:"_faculty" json:"_faculty"`
+	Program      string `db:"_program" json:"_program"`
+	Laboratories bool   `db:"_laboratories" json:"_laboratories"`
+	Count        int32  `db:"_count" json:"_count"`
+}
+
+func (q *Queries) ShrinkRooms(ctx context.Context, arg ShrinkRoomsParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, shrinkRooms,
+		arg.Semester,
+		arg.Faculty,
+		arg.Program,
+		arg.Laboratories,
+		arg.Count,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		items = append(items, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const transferRooms = `-- name: TransferRooms :execrows
+UPDATE room_allocations
+SET faculty = $1, program = $2
+WHERE semester = $3
+    AND faculty = $4
+    AND state = 'locked'
+    AND room_id = ANY($5::INT[])
+`
+
+type TransferRoomsParams struct {
+	Recipient string  `db:"recipient" json:"recipient"`
+	Program   string  `db:"program" json:"program"`
+	Semester  string  `db:"semester" json:"semester"`
+	Faculty   string  `db:"faculty" json:"faculty"`
+	Rooms     []int32 `db:"rooms" json:"rooms"`
+}
+
+func (q *Queries) TransferRooms(ctx context.Context, arg TransferRoomsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, transferRooms,
+		arg.Recipient,
+		arg.Program,
+		arg.Semester,
+		arg.Faculty,
+		arg.Rooms,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const unblockRoom = `-- name: UnblockRoom :execrows
+DELETE FROM room_blocks
+WHERE semester = $1
+    AND room_id = $2
+`
+
+type UnblockRoomParams struct {
+	Semester string `db:"semester" json:"semester"`
+	RoomID   int32  `db:"room_id" json:"room_id"`
+}
+
+func (q *Queries) UnblockRoom(ctx context.Context, arg UnblockRoomParams) (int64, error) {
+	result, err := q.db.Exec(ctx, unblockRoom, arg.Semester, arg.RoomID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const updateSemesterState = `-- name: UpdateSemesterState :execrows
+UPDATE semesters
+SET state = $1, updated_at = now()
+WHERE name = $2
+    AND state::TEXT = ANY($3::TEXT[])
+`
+
+type UpdateSemesterStateParams struct {
+	State      SemesterState `db:"state" json:"state"`
+	Name       string        `db:"name" json:"name"`
+	FromStates []string      `db:"from_states" json:"from_states"`
+}
+
+func (q *Queries) UpdateSemesterState(ctx context.Context, arg UpdateSemesterStateParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateSemesterState, arg.State, arg.Name, arg.FromStates)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// AdminService holds the privileged operations of the operations staff. They
+// bypass the quotas and the semester lifecycle, except for archived semesters.
+type AdminService interface {
+	AdminAssign(ctx context.Context, request *models.AdminAssignRequest) (*models.AdminAllocation, error)
+	AdminRevoke(ctx context.Context, request *models.AdminRevokeRequest) (*models.AdminAllocation, error)
+	AdminBlockRoom(ctx context.Context, request *models.AdminBlockRequest) (*models.AdminBlockResponse, error)
+}
+
+func (s *SqlcAllocationService) AdminAssign(ctx context.Context, request *models.AdminAssignRequest) (*models.AdminAllocation, error) {
+	var notifications []*models.Notification
+
+	response := &models.AdminAllocation{}
+	if err := s.transaction(ctx, "admin-assign", response, func(querier *repository.Queries) error {
+		// 0. The semester must not be archived, the program must be registered
+		room, err := s.adminRoom(ctx, querier, request.Semester, request.Room)
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkRegistry(ctx, querier, request.Faculty, []string{request.Program}); err != nil {
+			return err
+		}
+
+		if err := checkAdapted(request.Room, room.Type == repository.RoomTypeLaboratory, request.Adapted); err != nil {
+			return err
+		}
+
+		// 1. Offer the room and confirm it right away
+		offered, err := querier.OfferRoom(ctx, repository.OfferRoomParams{
+			Semester: request.Semester,
+			Faculty:  request.Faculty,
+			Program:  request.Program,
+			Adapted:  request.Adapted,
+			RoomID:   room.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		if offered == 0 {
+			return fmt.Errorf("room %q is not free in semester %q", request.Room, request.Semester)
+		}
+
+		if err := querier.LockRoom(ctx, repository.LockRoomParams{
+			Semester: request.Semester,
+			RoomID:   room.ID,
+		}); err != nil {
+			return err
+		}
+
+		*response = models.AdminAllocation{
+			Semester: request.Semester,
+			Faculty:  request.Faculty,
+			Program:  request.Program,
+			Room:     room.Name,
+			Adapted:  request.Adapted,
+		}
+
+		// 2. Tell the faculty
+		notifications = []*models.Notification{adminNotification("assigned", response)}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *SqlcAllocationService) AdminRevoke(ctx context.Context, request *models.AdminRevokeRequest) (*models.AdminAllocation, error) {
+	var notifications []*models.Notification
+
+	response := &models.AdminAllocation{}
+	if err := s.transaction(ctx, "admin-revoke", response, func(querier *repository.Queries) error {
+		// 0. The semester must not be archived
+		room, err := s.adminRoom(ctx, querier, request.Semester, request.Room)
+		if err != nil {
+			return err
+		}
+
+		// 1. Strip the allocation
+		if err := querier.SetReleaseReason(ctx, "revoked"); err != nil {
+			return err
+		}
+
+		revoked, err := querier.RevokeRoom(ctx, repository.RevokeRoomParams{
+			Semester: request.Semester,
+			RoomID:   room.ID,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("room %q is not allocated in semester %q", request.Room, request.Semester)
+		} else if err != nil {
+			return err
+		}
+
+		*response = models.AdminAllocation{
+			Semester: request.Semester,
+			Faculty:  revoked.Faculty,
+			Program:  revoked.Program,
+			Room:     room.Name,
+			Adapted:  revoked.Adapted,
+		}
+
+		// 2. Tell the faculty and offer the room to the waitlist
+		granted, err := s.processWaitlist(ctx, querier, request.Semester)
+		if err != nil {
+			return err
+		}
+
+		notifications = append([]*models.Notification{adminNotification("revoked", response)}, granted...)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// AdminBlockRoom takes a room out of service for a semester. The allocations
+// and time blocks held in the room are relocated right away, and the ones no
+// equivalent room is free for are reported, so the staff can revoke them.
+func (s *SqlcAllocationService) AdminBlockRoom(ctx context.Context, request *models.AdminBlockRequest) (*models.AdminBlockResponse, error) {
+	var notifications []*models.Notification
+
+	response := &models.AdminBlockResponse{}
+	if err := s.transaction(ctx, "admin-block-room", response, func(querier *repository.Queries) error {
+		// 0. The semester must not be archived
+		room, err := s.adminRoom(ctx, querier, request.Semester, request.Room)
+		if err != nil {
+			return err
+		}
+
+		response.Semester = request.Semester
+		response.Room = room.Name
+
+		// 1. Put the room back in service, offering it to the waitlist
+		if request.Unblock {
+			unblocked, err := querier.UnblockRoom(ctx, repository.UnblockRoomParams{
+				Semester: request.Semester,
+				RoomID:   room.ID,
+			})
+			if err != nil {
+				return err
+			}
+
+			if unblocked == 0 {
+				return fmt.Errorf("room %q is not blocked in semester %q", request.Room, request.Semester)
+			}
+
+			notifications, err = s.processWaitlist(ctx, querier, request.Semester)
+			return err
+		}
+
+		// 2. Block the room and move its allocations elsewhere
+		if err := querier.BlockRoom(ctx, repository.BlockRoomParams{
+			Semester: request.Semester,
+			RoomID:   room.ID,
+			Reason:   request.Reason,
+		}); err != nil {
+			return err
+		}
+
+		relocated, relocatedNotifications, err := s.relocate(ctx, querier, request.Semester, room.Name)
+		if err != nil {
+			return err
+		}
+
+		response.Relocated = relocated.Relocated
+		response.Unplaced = relocated.Unplaced
+		notifications = relocatedNotifications
+
+		response.Reason = request.Reason
+		response.Blocked = true
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// adminRoom checks the semester of an admin request and finds the room. The
+// room row is locked, so no allocation can take it while the request runs.
+func (s *SqlcAllocationService) adminRoom(ctx context.Context, querier *repository.Queries, semester string, name string) (repository.GetRoomByNameRow, error) {
+	if err := s.adminSemester(ctx, querier, semester); err != nil {
+		return repository.GetRoomByNameRow{}, err
+	}
+
+	room, err := querier.GetRoomByName(ctx, name)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return room, fmt.Errorf("room %q does not exist", name)
+	}
+
+	return room, err
+}
+
+// adminSemester fails unless the semester exists and is not archived.
+func (s *SqlcAllocationService) adminSemester(ctx context.Context, querier *repository.Queries, semester string) error {
+	state, err := querier.GetSemesterState(ctx, semester)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return checkSemesterAdmin(semester, "")
+	} else if err != nil {
+		return err
+	}
+
+	return checkSemesterAdmin(semester, string(state))
+}
+
+// checkSemesterAdmin fails unless a semester in the given state (empty when
+// it does not exist) can still be changed by the staff.
+func checkSemesterAdmin(semester string, state string) error {
+	if state == "" {
+		return fmt.Errorf("semester %q does not exist", semester)
+	}
+
+	if state == models.SemesterArchived {
+		return fmt.Errorf("semester %q is archived", semester)
+	}
+
+	return nil
+}
+
+// checkAdapted fails if a laboratory is assigned as an adapted classroom.
+func checkAdapted(room string, laboratory bool, adapted bool) error {
+	if adapted && laboratory {
+		return fmt.Errorf("room %q is a laboratory, only classrooms can be adapted", room)
+	}
+
+	return nil
+}
+
+// adminNotification tells a faculty the staff assigned or revoked one of its
+// rooms.
+func adminNotification(kind string, allocation *models.AdminAllocation) *models.Notification {
+	return &models.Notification{
+		Type:     kind,
+		Semester: allocation.Semester,
+		Faculty:  allocation.Faculty,
+		Rooms:    []string{allocation.Room},
+	}
+}
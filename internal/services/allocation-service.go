@@ -2,132 +2,1006 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/foxinuni/distribuidos-central/internal/models"
 	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type AllocationService interface {
 	Allocate(ctx context.Context, request *models.AllocateRequest) (*models.AllocateResponse, error)
 	Confirm(ctx context.Context, request *models.ConfirmRequest) (*models.ConfirmResponse, error)
+	Quote(ctx context.Context, request *models.AllocateRequest) (*models.QuoteResponse, error)
+	Cancel(ctx context.Context, request *models.CancelRequest) (*models.CancelResponse, error)
+	Modify(ctx context.Context, request *models.ModifyRequest) (*models.ModifyResponse, error)
+	Availability(ctx context.Context, semester string) (*models.Availability, error)
+}
+
+// OfferExpirer releases offers that were not confirmed within the hold period,
+// and forgets the responses kept for retries once past the replay window.
+type OfferExpirer interface {
+	ExpireOffers(ctx context.Context) ([]models.ReleasedRoom, error)
+	ForgetRequests(ctx context.Context) (int64, error)
 }
 
 type SqlcAllocationService struct {
+	allocationSettings
 	pool *pgxpool.Pool
 }
 
-func NewSqlcAllocationService(pool *pgxpool.Pool) *SqlcAllocationService {
-	return &SqlcAllocationService{
-		pool: pool,
+func NewSqlcAllocationService(pool *pgxpool.Pool, options ...AllocationOptions) *SqlcAllocationService {
+	service := &SqlcAllocationService{
+		allocationSettings: defaultAllocationSettings(),
+		pool:               pool,
+	}
+
+	for _, applyOption := range options {
+		applyOption(&service.allocationSettings)
 	}
+
+	return service
 }
 
 func (s *SqlcAllocationService) Allocate(ctx context.Context, request *models.AllocateRequest) (*models.AllocateResponse, error) {
-	// 1. Create a transaction
-	tx, err := s.pool.Begin(ctx)
+	response := &models.AllocateResponse{}
+	if err := s.transaction(ctx, "allocate", response, func(querier *repository.Queries) error {
+		// 0. Only open semesters take allocations
+		if err := s.requireOpen(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		// 0.1 Only registered programs, within the faculty's quota
+		if err := s.checkDemand(ctx, querier, request.Semester, request.Faculty, request.Programs); err != nil {
+			return err
+		}
+
+		// 1. Allocate rooms (best-effort when the client asked for a partial grant or to wait)
+		shortfall, err := s.allocatePrograms(ctx, querier, request, !request.Partial && !request.Waitlist)
+		if err != nil {
+			return err
+		}
+
+		// 1.1 Queue the unmet demand until rooms are released
+		if request.Waitlist && len(shortfall) > 0 {
+			if err := s.enqueue(ctx, querier, request, shortfall); err != nil {
+				return err
+			}
+
+			response.Waitlisted = true
+		}
+
+		// 2. Get the allocated rooms
+		programs, err := s.programAllocations(ctx, querier, request)
+		if err != nil {
+			return err
+		}
+
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Deadline = time.Now().Add(s.hold)
+		response.Partial = len(shortfall) > 0
+		response.Programs = applyShortfall(programs, shortfall)
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *SqlcAllocationService) Quote(ctx context.Context, request *models.AllocateRequest) (*models.QuoteResponse, error) {
+	// 1. Create a read-only transaction (nothing is reserved or locked)
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
 	if err != nil {
 		return nil, err
 	}
+	defer tx.Rollback(ctx)
 
 	// 2. Create new querier for transaction
 	querier := repository.New(tx)
 
-	// 3. Allocate rooms
-	for _, program := range request.Programs {
-		if err := querier.AllocateClassrooms(ctx, repository.AllocateClassroomsParams{
+	// 2.1 Only open semesters take allocations
+	state, err := querier.PeekSemesterState(ctx, request.Semester)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, checkSemesterOpen(request.Semester, "")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := checkSemesterOpen(request.Semester, string(state)); err != nil {
+		return nil, err
+	}
+
+	// 2.2 Only registered programs, within the faculty's quota
+	if err := s.checkRegistry(ctx, querier, request.Faculty, programNames(request.Programs)); err != nil {
+		return nil, err
+	}
+
+	quota, err := querier.PeekFacultyQuota(ctx, repository.PeekFacultyQuotaParams{
+		Semester: request.Semester,
+		Faculty:  request.Faculty,
+	})
+	if err == nil {
+		demand, returned := programDemand(request.Programs)
+		if err := s.checkUsage(ctx, querier, request.Semester, request.Faculty,
+			roomCount{classrooms: int(quota.Classrooms), laboratories: int(quota.Laboratories)},
+			demand,
+			returned,
+		); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, err
+	}
+
+	// 3. Work out the rooms that would be allocated, without failing on shortfalls
+	programs, shortfall, err := s.quotePrograms(ctx, querier, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// 4. Generate response
+	response := &models.QuoteResponse{
+		Semester:    request.Semester,
+		Faculty:     request.Faculty,
+		Satisfiable: len(shortfall) == 0,
+		Programs:    applyShortfall(programs, shortfall),
+		Shortfall:   shortfall,
+	}
+
+	return response, nil
+}
+
+func (s *SqlcAllocationService) Confirm(ctx context.Context, request *models.ConfirmRequest) (*models.ConfirmResponse, error) {
+	var notifications []*models.Notification
+
+	response := &models.ConfirmResponse{}
+	if err := s.transaction(ctx, "confirm", response, func(querier *repository.Queries) error {
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Accepted = request.Accept
+
+		// 0. Only open semesters take confirmations
+		if err := s.requireOpen(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		if err := s.checkRegistry(ctx, querier, request.Faculty, nil); err != nil {
+			return err
+		}
+
+		// 1. Release the offered rooms if the client declined
+		if !request.Accept {
+			if err := querier.SetReleaseReason(ctx, "declined"); err != nil {
+				return err
+			}
+
+			// 1.1 A faculty that declines gives up its place in the waitlist too
+			if err := querier.RemoveFromWaitlist(ctx, repository.RemoveFromWaitlistParams{
+				Faculty:  request.Faculty,
+				Semester: request.Semester,
+			}); err != nil {
+				return err
+			}
+
+			released, err := querier.ReleaseRooms(ctx, repository.ReleaseRoomsParams{
+				FacultyName:  request.Faculty,
+				SemesterName: request.Semester,
+			})
+			if err != nil {
+				return err
+			}
+
+			// 1.2 Release the offered time blocks as well
+			blocks, err := querier.ReleaseBookings(ctx, repository.ReleaseBookingsParams{
+				Faculty:  request.Faculty,
+				Semester: request.Semester,
+			})
+			if err != nil {
+				return err
+			}
+			released = append(released, blocks...)
+
+			// 1.3 Offer the released rooms to the waitlist
+			if len(released) > 0 {
+				if notifications, err = s.processWaitlist(ctx, querier, request.Semester); err != nil {
+					return err
+				}
+			}
+
+			response.Released = released
+			return nil
+		}
+
+		// 2. Confirm allocation
+		locked, err := querier.LockRooms(ctx, repository.LockRoomsParams{
+			FacultyName:  request.Faculty,
+			SemesterName: request.Semester,
+			Hold:         s.holdInterval(),
+		})
+		if err != nil {
+			return err
+		}
+
+		blocks, err := querier.LockBookings(ctx, repository.LockBookingsParams{
+			Faculty:  request.Faculty,
 			Semester: request.Semester,
+			Hold:     s.holdInterval(),
+		})
+		if err != nil {
+			return err
+		}
+
+		// 3. Nothing to lock means the offer expired (or never existed)
+		if locked == 0 && blocks == 0 {
+			return fmt.Errorf("no rooms offered to %q for semester %q (the offer may have expired)", request.Faculty, request.Semester)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *SqlcAllocationService) Cancel(ctx context.Context, request *models.CancelRequest) (*models.CancelResponse, error) {
+	var notifications []*models.Notification
+
+	response := &models.CancelResponse{}
+	if err := s.transaction(ctx, "cancel", response, func(querier *repository.Queries) error {
+		// 0. Only registered faculties and programs
+		programs := []string{}
+		if request.Program != "" {
+			programs = append(programs, request.Program)
+		}
+
+		if err := s.checkRegistry(ctx, querier, request.Faculty, programs); err != nil {
+			return err
+		}
+
+		// 0.1 Archived semesters are kept as they ended
+		if err := s.adminSemester(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		// 1. Release the confirmed rooms
+		if err := querier.SetReleaseReason(ctx, "cancelled"); err != nil {
+			return err
+		}
+
+		released, err := querier.CancelRooms(ctx, repository.CancelRoomsParams{
+			FacultyName:  request.Faculty,
+			SemesterName: request.Semester,
+			ProgramName:  request.Program,
+		})
+		if err != nil {
+			return err
+		}
+
+		// 1.1 Release the confirmed time blocks as well
+		blocks, err := querier.CancelBookings(ctx, repository.CancelBookingsParams{
 			Faculty:  request.Faculty,
-			Program:  program.Name,
-			Count:    int32(program.Classrooms),
-		}); err != nil {
-			return nil, err
+			Semester: request.Semester,
+			Program:  request.Program,
+		})
+		if err != nil {
+			return err
+		}
+		released = append(released, blocks...)
+
+		if len(released) == 0 {
+			return fmt.Errorf("no confirmed rooms to cancel for %q in semester %q", request.Faculty, request.Semester)
+		}
+
+		// 2. Offer the released rooms to the waitlist
+		if notifications, err = s.processWaitlist(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		// 3. Generate response
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Program = request.Program
+		response.Released = released
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *SqlcAllocationService) Modify(ctx context.Context, request *models.ModifyRequest) (*models.ModifyResponse, error) {
+	var notifications []*models.Notification
+
+	response := &models.ModifyResponse{}
+	if err := s.transaction(ctx, "modify", response, func(querier *repository.Queries) error {
+		released := []string{}
+
+		// 0. Only open semesters take allocations
+		if err := s.requireOpen(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		// 0.1 Only registered programs, within the faculty's quota
+		if err := s.checkDemand(ctx, querier, request.Semester, request.Faculty, request.Programs); err != nil {
+			return err
+		}
+
+		// 0.2 Only confirmed allocations can be modified
+		for _, program := range request.Programs {
+			locked, err := querier.CountLockedProgramRooms(ctx, repository.CountLockedProgramRoomsParams{
+				Faculty:  request.Faculty,
+				Semester: request.Semester,
+				Program:  program.Name,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := checkModifiable(request, program.Name, int(locked)); err != nil {
+				return err
+			}
+		}
+
+		// 1. Give back rooms first, so growing programs can use them
+		if err := querier.SetReleaseReason(ctx, "modified"); err != nil {
+			return err
+		}
+
+		for _, program := range request.Programs {
+			rooms, err := s.shrinkProgram(ctx, querier, request, program)
+			if err != nil {
+				return err
+			}
+
+			released = append(released, rooms...)
 		}
 
-		if err := querier.AllocateLaboratories(ctx, repository.AllocateLaboratoriesParams{
+		// 2. Allocate the extra rooms, keeping the ones already held
+		grow := &models.AllocateRequest{
 			Semester: request.Semester,
 			Faculty:  request.Faculty,
-			Program:  program.Name,
-			Count:    int32(program.Laboratories),
-		}); err != nil {
+		}
+
+		for _, program := range request.Programs {
+			program.Classrooms = max(program.Classrooms, 0)
+			program.Laboratories = max(program.Laboratories, 0)
+			grow.Programs = append(grow.Programs, program)
+		}
+
+		if _, err := s.allocatePrograms(ctx, querier, grow, true); err != nil {
+			return err
+		}
+
+		// 2.1 The allocation was already confirmed, so the new rooms are too
+		// (only the rows offered by this transaction, whose offered_at is its
+		// start time)
+		for _, program := range request.Programs {
+			if err := querier.LockProgramRooms(ctx, repository.LockProgramRoomsParams{
+				Faculty:  request.Faculty,
+				Semester: request.Semester,
+				Program:  program.Name,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// 3. Offer what was given back to the waitlist
+		if len(released) > 0 {
+			var err error
+			if notifications, err = s.processWaitlist(ctx, querier, request.Semester); err != nil {
+				return err
+			}
+		}
+
+		// 4. Generate response
+		programs, err := s.programAllocations(ctx, querier, grow)
+		if err != nil {
+			return err
+		}
+
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Released = released
+		response.Programs = programs
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *SqlcAllocationService) Availability(ctx context.Context, semester string) (*models.Availability, error) {
+	// 1. Create new querier
+	querier := repository.New(s.pool)
+
+	// 2. Count the rooms nobody holds for the semester
+	counts, err := querier.CountAvailableRooms(ctx, semester)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.Availability{
+		Semester:     semester,
+		Classrooms:   int(counts.Classrooms),
+		Laboratories: int(counts.Laboratories),
+	}, nil
+}
+
+func (s *SqlcAllocationService) ExpireOffers(ctx context.Context) ([]models.ReleasedRoom, error) {
+	// 1. Create a transaction
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	// 2. Create new querier for transaction
+	querier := repository.New(tx)
+
+	// 3. Delete the offers older than the hold period
+	if err := querier.SetReleaseReason(ctx, "expired"); err != nil {
+		return nil, err
+	}
+
+	hold := s.holdInterval()
+	rows, err := querier.ExpireRooms(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3.1 Time block offers expire the same way
+	blocks, err := querier.ExpireBookings(ctx, hold)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, block := range blocks {
+		rows = append(rows, repository.ExpireRoomsRow(block))
+	}
+
+	// 4. Collect the released rooms, telling each faculty which offers expired
+	released := make([]models.ReleasedRoom, 0, len(rows))
+	notifications := []*models.Notification{}
+	expired := map[string]*models.Notification{}
+	semesters := []string{}
+	for _, row := range rows {
+		released = append(released, models.ReleasedRoom{
+			Semester: row.Semester,
+			Faculty:  row.Faculty,
+			Program:  row.Program,
+			Room:     row.Name,
+		})
+
+		key := row.Semester + "/" + row.Faculty
+		if _, ok := expired[key]; !ok {
+			expired[key] = &models.Notification{
+				Type:     "offer-expired",
+				Semester: row.Semester,
+				Faculty:  row.Faculty,
+			}
+			notifications = append(notifications, expired[key])
+		}
+		expired[key].Rooms = append(expired[key].Rooms, row.Name)
+
+		if !slices.Contains(semesters, row.Semester) {
+			semesters = append(semesters, row.Semester)
+		}
+	}
+
+	// 5. Offer the released rooms to the waitlist of each semester
+	for _, semester := range semesters {
+		granted, err := s.processWaitlist(ctx, querier, semester)
+		if err != nil {
 			return nil, err
 		}
+
+		notifications = append(notifications, granted...)
 	}
 
-	// 4. Get the allocated rooms
-	response := &models.AllocateResponse{
-		Semester: request.Semester,
-		Faculty:  request.Faculty,
-		Programs: []models.ProgramAllocation{},
+	// 6. Commit the transaction
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return released, nil
+}
+
+// raiseException is the SQLSTATE of the errors raised by the allocation
+// functions.
+const raiseException = "P0001"
+
+// databaseError unwraps the errors raised by the allocation functions, so
+// clients get the same messages as with the other backends.
+func databaseError(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == raiseException {
+		return errors.New(pgErr.Message)
+	}
+
+	return err
+}
+
+// holdInterval is the hold period as a Postgres interval.
+func (s *SqlcAllocationService) holdInterval() pgtype.Interval {
+	return pgtype.Interval{
+		Microseconds: s.hold.Microseconds(),
+		Valid:        true,
+	}
+}
+
+// transaction runs fn inside a database transaction and records the response
+// it produced under the client request found in ctx. If that request was
+// already processed, fn is skipped and the stored response is decoded into
+// response instead, so retried requests never allocate twice.
+func (s *SqlcAllocationService) transaction(ctx context.Context, kind string, response interface{}, fn func(querier *repository.Queries) error) error {
+	// 1. Create a transaction
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// 2. Create new querier for transaction
+	querier := repository.New(tx)
+
+	// 3. Replay the stored response for already processed requests
+	key, tracked := RequestKeyFromContext(ctx)
+	if tracked {
+		if found, err := s.replay(ctx, querier, key, kind, response); err != nil || found {
+			return err
+		}
+	}
+
+	// 4. Process the request
+	if err := fn(querier); err != nil {
+		return databaseError(err)
+	}
+
+	// 5. Store the response for later retries
+	if tracked {
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			return err
+		}
+
+		// 5.1 Responses older than the replay window belong to a previous client
+		// that used the same identity, so they are overwritten
+		saved, err := querier.SaveProcessedRequest(ctx, repository.SaveProcessedRequestParams{
+			Identity:  key.Identity,
+			RequestID: int32(key.ID),
+			Type:      kind,
+			Response:  encoded,
+			CreatedAt: pgtype.Timestamptz{Time: time.Now().Add(-s.replayWindow), Valid: true},
+		})
+		if err != nil {
+			return err
+		}
+
+		// 5.2 A concurrent retry finished first, discard this work and answer with its response
+		if saved == 0 {
+			tx.Rollback(ctx)
+			_, err := s.replay(ctx, repository.New(s.pool), key, kind, response)
+			return err
+		}
+	}
+
+	// 6. Commit the transaction
+	return tx.Commit(ctx)
+}
+
+func (s *SqlcAllocationService) ForgetRequests(ctx context.Context) (int64, error) {
+	// 1. Responses past the replay window are never replayed again
+	querier := repository.New(s.pool)
+	return querier.DeleteProcessedRequests(ctx, pgtype.Timestamptz{Time: time.Now().Add(-s.replayWindow), Valid: true})
+}
+
+func (s *SqlcAllocationService) replay(ctx context.Context, querier *repository.Queries, key RequestKey, kind string, response interface{}) (bool, error) {
+	stored, err := querier.GetProcessedRequest(ctx, repository.GetProcessedRequestParams{
+		Identity:  key.Identity,
+		RequestID: int32(key.ID),
+		CreatedAt: pgtype.Timestamptz{Time: time.Now().Add(-s.replayWindow), Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if stored.Type != kind {
+		return false, fmt.Errorf("request id %d was already used for a %q request", key.ID, stored.Type)
+	}
+
+	return true, json.Unmarshal(stored.Response, response)
+}
+
+// allocatePrograms allocates the rooms requested by every program and reports
+// the programs that could not get everything they asked for. In strict mode a
+// shortfall aborts the allocation with an error instead.
+func (s *SqlcAllocationService) allocatePrograms(ctx context.Context, querier *repository.Queries, request *models.AllocateRequest, strict bool) ([]models.ProgramShortfall, error) {
+	// 1. Find the free rooms for the strategy to rank
+	classroomCandidates, laboratoryCandidates, err := s.freeCandidates(ctx, querier, request.Semester)
+	if err != nil {
+		return nil, err
 	}
 
+	strategy := s.strategyFor(request.Semester)
+
+	// 2. Allocate each program in the strategy's order (labs fall back to classrooms)
+	shortfall := []models.ProgramShortfall{}
 	for _, program := range request.Programs {
-		// 4.1 Create a new program allocation
-		program := models.ProgramAllocation{
+		classroomRanking := roomIDs(strategy.Rank(classroomCandidates, program))
+		laboratoryRanking := append(roomIDs(strategy.Rank(laboratoryCandidates, program)), classroomRanking...)
+
+		classrooms, err := querier.AllocateClassrooms(ctx, repository.AllocateClassroomsParams{
+			Semester:    request.Semester,
+			Faculty:     request.Faculty,
+			Program:     program.Name,
+			Count:       int32(program.Classrooms),
+			Strict:      strict,
+			MinCapacity: int32(program.MinCapacity),
+			Building:    program.Building,
+			Ranking:     classroomRanking,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		laboratories, err := querier.AllocateLaboratories(ctx, repository.AllocateLaboratoriesParams{
+			Semester:    request.Semester,
+			Faculty:     request.Faculty,
+			Program:     program.Name,
+			Count:       int32(program.Laboratories),
+			Strict:      strict,
+			MinCapacity: int32(program.MinCapacity),
+			Equipment:   program.Equipment,
+			Building:    program.Building,
+			Ranking:     laboratoryRanking,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		missing := models.ProgramShortfall{
 			Name:         program.Name,
-			Classrooms:   []string{},
-			Laboratories: []string{},
-			Adapted:      []string{},
+			Classrooms:   program.Classrooms - int(classrooms),
+			Laboratories: program.Laboratories - int(laboratories),
+		}
+
+		if missing.Classrooms > 0 || missing.Laboratories > 0 {
+			missing.Unmet = unmetRequirements(program, missing, slices.Concat(classroomCandidates, laboratoryCandidates))
+			shortfall = append(shortfall, missing)
+		}
+	}
+
+	return shortfall, nil
+}
+
+// freeCandidates lists the free rooms of a semester, classrooms and
+// laboratories apart.
+func (s *SqlcAllocationService) freeCandidates(ctx context.Context, querier *repository.Queries, semester string) ([]models.Room, []models.Room, error) {
+	rooms, err := querier.ListFreeRooms(ctx, semester)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	classrooms := []models.Room{}
+	laboratories := []models.Room{}
+	for _, room := range rooms {
+		candidate := roomModel(room)
+		if candidate.Laboratory {
+			laboratories = append(laboratories, candidate)
+		} else {
+			classrooms = append(classrooms, candidate)
 		}
+	}
+
+	return classrooms, laboratories, nil
+}
+
+// roomModel converts a room row.
+func roomModel(room repository.Room) models.Room {
+	return models.Room{
+		ID:         int(room.ID),
+		Name:       room.Name,
+		Laboratory: room.Type == repository.RoomTypeLaboratory,
+		Capacity:   int(room.Capacity),
+		Building:   room.Building,
+		Floor:      int(room.Floor),
+		Equipment:  room.Equipment,
+	}
+}
+
+// quotePrograms works out the rooms allocatePrograms would hand out with
+// plain reads, so a quote neither reserves nor locks a room. Rooms are picked
+// in the order allocate_classrooms and allocate_laboratories use. Running those
+// in a rolled-back transaction would lock the rooms they pick and make
+// concurrent allocations skip them, so the conformance tests check instead
+// that a quote lists what the next allocation hands out.
+func (s *SqlcAllocationService) quotePrograms(ctx context.Context, querier *repository.Queries, request *models.AllocateRequest) ([]models.ProgramAllocation, []models.ProgramShortfall, error) {
+	// 1. Find the free rooms for the strategy to rank
+	classroomCandidates, laboratoryCandidates, err := s.freeCandidates(ctx, querier, request.Semester)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	strategy := s.strategyFor(request.Semester)
 
-		// 4.2 Get the allocated rooms
+	// 2. Hand out the rooms program by program, skipping the ones already quoted
+	quoted := make(map[int]bool)
+	pick := func(ranking []models.Room, count int, fits func(room models.Room) bool) []models.Room {
+		picked := []models.Room{}
+		for _, room := range ranking {
+			if len(picked) == count {
+				break
+			}
+
+			if quoted[room.ID] || !fits(room) {
+				continue
+			}
+
+			quoted[room.ID] = true
+			picked = append(picked, room)
+		}
+
+		return picked
+	}
+
+	programs := []models.ProgramAllocation{}
+	shortfall := []models.ProgramShortfall{}
+	for _, info := range request.Programs {
+		classroomRanking := strategy.Rank(classroomCandidates, info)
+		laboratoryRanking := strategy.Rank(laboratoryCandidates, info)
+
+		// 2.1 Start from the rooms the program already holds
+		held, err := querier.GetRoomsByFacultyProgramSemester(ctx, repository.GetRoomsByFacultyProgramSemesterParams{
+			Semester: request.Semester,
+			Faculty:  request.Faculty,
+			Program:  info.Name,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// 2.2 Classrooms, then laboratories falling back to adapted classrooms
+		roomFits := func(room models.Room) bool {
+			return room.Capacity >= info.MinCapacity
+		}
+
+		laboratoryFits := func(room models.Room) bool {
+			return roomFits(room) && (info.Equipment == "" || slices.Contains(room.Equipment, info.Equipment))
+		}
+
+		classrooms := pick(classroomRanking, max(info.Classrooms, 0), roomFits)
+		laboratories := pick(laboratoryRanking, max(info.Laboratories, 0), laboratoryFits)
+
+		adapted := []models.Room{}
+		if len(laboratories) < info.Laboratories && info.Equipment == "" {
+			adapted = pick(classroomRanking, info.Laboratories-len(laboratories), roomFits)
+		}
+
+		for _, room := range classrooms {
+			held = append(held, quotedRoom(room, repository.RoomTypeClassroom, false))
+		}
+
+		for _, room := range laboratories {
+			held = append(held, quotedRoom(room, repository.RoomTypeLaboratory, false))
+		}
+
+		for _, room := range adapted {
+			held = append(held, quotedRoom(room, repository.RoomTypeClassroom, true))
+		}
+
+		programs = append(programs, programAllocation(info, held))
+
+		// 2.3 Record what could not be quoted
+		missing := models.ProgramShortfall{
+			Name:         info.Name,
+			Classrooms:   info.Classrooms - len(classrooms),
+			Laboratories: info.Laboratories - len(laboratories) - len(adapted),
+		}
+
+		if missing.Classrooms > 0 || missing.Laboratories > 0 {
+			missing.Unmet = unmetRequirements(info, missing, slices.Concat(classroomCandidates, laboratoryCandidates))
+			shortfall = append(shortfall, missing)
+		}
+	}
+
+	return programs, shortfall, nil
+}
+
+// quotedRoom describes a quoted room like one the program already holds.
+func quotedRoom(room models.Room, kind repository.RoomType, adapted bool) repository.GetRoomsByFacultyProgramSemesterRow {
+	return repository.GetRoomsByFacultyProgramSemesterRow{
+		ID:       int32(room.ID),
+		Name:     room.Name,
+		Type:     kind,
+		Building: room.Building,
+		Adapted:  adapted,
+	}
+}
+
+// programAllocations lists the rooms each requested program holds.
+func (s *SqlcAllocationService) programAllocations(ctx context.Context, querier *repository.Queries, request *models.AllocateRequest) ([]models.ProgramAllocation, error) {
+	programs := []models.ProgramAllocation{}
+	for _, info := range request.Programs {
+		// 1. Get the allocated rooms
 		rooms, err := querier.GetRoomsByFacultyProgramSemester(ctx, repository.GetRoomsByFacultyProgramSemesterParams{
 			Semester: request.Semester,
 			Faculty:  request.Faculty,
-			Program:  program.Name,
+			Program:  info.Name,
 		})
 		if err != nil {
 			return nil, err
 		}
 
-		// 4.3 Add the allocated rooms to the response
-		for _, room := range rooms {
-			if room.Type == repository.RoomTypeClassroom {
-				if room.Adapted {
-					program.Adapted = append(program.Adapted, room.Name)
-				} else {
-					program.Classrooms = append(program.Classrooms, room.Name)
-				}
+		// 2. Add the program allocation to the response
+		programs = append(programs, programAllocation(info, rooms))
+	}
+
+	return programs, nil
+}
+
+// programAllocation sorts the rooms a program holds by type.
+func programAllocation(info models.ProgramInfo, rooms []repository.GetRoomsByFacultyProgramSemesterRow) models.ProgramAllocation {
+	// 1. Create a new program allocation
+	program := models.ProgramAllocation{
+		Name:         info.Name,
+		Classrooms:   []string{},
+		Laboratories: []string{},
+		Adapted:      []string{},
+	}
+
+	// 2. Add the allocated rooms
+	elsewhere := 0
+	for _, room := range rooms {
+		if info.Building != "" && room.Building != info.Building {
+			elsewhere++
+		}
+
+		if room.Type == repository.RoomTypeClassroom {
+			if room.Adapted {
+				program.Adapted = append(program.Adapted, room.Name)
 			} else {
-				program.Laboratories = append(program.Laboratories, room.Name)
+				program.Classrooms = append(program.Classrooms, room.Name)
 			}
+		} else {
+			program.Laboratories = append(program.Laboratories, room.Name)
 		}
+	}
 
-		// 4.4 Add the program allocation to the response
-		response.Programs = append(response.Programs, program)
+	// 3. Report when the preferred building could not be honoured
+	if elsewhere > 0 {
+		program.Unmet = append(program.Unmet, fmt.Sprintf("building %q (%d rooms elsewhere)", info.Building, elsewhere))
 	}
 
-	// 5. Commit the transaction
-	if err := tx.Commit(ctx); err != nil {
-		return nil, err
+	return program
+}
+
+// shrinkProgram releases the confirmed rooms a program gives back in a modify
+// request (its negative deltas).
+func (s *SqlcAllocationService) shrinkProgram(ctx context.Context, querier *repository.Queries, request *models.ModifyRequest, program models.ProgramInfo) ([]string, error) {
+	released := []string{}
+
+	if program.Classrooms < 0 {
+		rooms, err := querier.ShrinkRooms(ctx, repository.ShrinkRoomsParams{
+			Semester:     request.Semester,
+			Faculty:      request.Faculty,
+			Program:      program.Name,
+			Laboratories: false,
+			Count:        int32(-program.Classrooms),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		released = append(released, rooms...)
 	}
 
-	return response, nil
+	if program.Laboratories < 0 {
+		rooms, err := querier.ShrinkRooms(ctx, repository.ShrinkRoomsParams{
+			Semester:     request.Semester,
+			Faculty:      request.Faculty,
+			Program:      program.Name,
+			Laboratories: true,
+			Count:        int32(-program.Laboratories),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		released = append(released, rooms...)
+	}
+
+	return released, nil
 }
 
-func (s *SqlcAllocationService) Confirm(ctx context.Context, request *models.ConfirmRequest) (*models.ConfirmResponse, error) {
-	// 1. Check that the client accepted
-	if !request.Accept {
-		return nil, fmt.Errorf("expected accept to be true")
+// roomIDs returns the IDs of ranked rooms, in order.
+func roomIDs(rooms []models.Room) []int32 {
+	ids := make([]int32, len(rooms))
+	for i, room := range rooms {
+		ids[i] = int32(room.ID)
 	}
 
-	// 2. Create new querier
-	querier := repository.New(s.pool)
+	return ids
+}
 
-	// 3. Confirm allocation
-	if err := querier.LockRooms(ctx, repository.LockRoomsParams{
-		FacultyName:  request.Faculty,
-		SemesterName: request.Semester,
-	}); err != nil {
-		return nil, err
+// applyShortfall records on each program allocation what is still missing.
+func applyShortfall(programs []models.ProgramAllocation, shortfall []models.ProgramShortfall) []models.ProgramAllocation {
+	for _, missing := range shortfall {
+		for i := range programs {
+			if programs[i].Name == missing.Name {
+				programs[i].MissingClassrooms = missing.Classrooms
+				programs[i].MissingLaboratories = missing.Laboratories
+				programs[i].Unmet = append(programs[i].Unmet, missing.Unmet...)
+			}
+		}
 	}
 
-	// 4. Generate response
-	response := &models.ConfirmResponse{
-		Semester: request.Semester,
-		Faculty:  request.Faculty,
+	return programs
+}
+
+// unmetRequirements describes the requirements that explain a program's
+// shortfall. A requirement is only reported when some of the candidate rooms
+// of a missing type fail it; otherwise there were just not enough rooms.
+func unmetRequirements(program models.ProgramInfo, missing models.ProgramShortfall, candidates []models.Room) []string {
+	tooSmall, unequipped := false, false
+	for _, room := range candidates {
+		// Laboratories fall back to classrooms unless they need equipment
+		wanted := missing.Laboratories > 0 && (room.Laboratory || program.Equipment == "")
+		if !room.Laboratory && missing.Classrooms > 0 {
+			wanted = true
+		}
+
+		if !wanted {
+			continue
+		}
+
+		if room.Capacity < program.MinCapacity {
+			tooSmall = true
+		}
+
+		if room.Laboratory && program.Equipment != "" && !slices.Contains(room.Equipment, program.Equipment) {
+			unequipped = true
+		}
 	}
 
-	return response, nil
+	unmet := []string{}
+	if tooSmall {
+		unmet = append(unmet, fmt.Sprintf("min capacity %d", program.MinCapacity))
+	}
+
+	if unequipped {
+		unmet = append(unmet, fmt.Sprintf("equipment %q", program.Equipment))
+	}
+
+	return unmet
+}
+
+// checkModifiable fails unless the program holds confirmed rooms, since only
+// a confirmed allocation can be modified.
+func checkModifiable(request *models.ModifyRequest, program string, locked int) error {
+	if locked == 0 {
+		return fmt.Errorf("program %q of faculty %q holds no confirmed rooms in semester %q", program, request.Faculty, request.Semester)
+	}
+
+	return nil
 }
@@ -0,0 +1,833 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5/pgxpool"
+	_ "modernc.org/sqlite"
+)
+
+// testDatabaseEnv names the Postgres database the conformance tests run on.
+// It must be a migrated database whose rooms, allocations and bookings can be
+// thrown away; the Postgres cases are skipped when it is not set.
+const testDatabaseEnv = "CENTRAL_TEST_DATABASE_URL"
+
+// conformanceService is what the conformance tests need from a backend.
+type conformanceService interface {
+	AllocationService
+	OfferExpirer
+	SemesterService
+	RegistryService
+	AdminService
+	RenewalService
+	RelocationService
+	OutageService
+	ScheduleService
+}
+
+// conformanceBackend opens a backend holding the rooms built by
+// GenerateRooms(classrooms, laboratories) and nothing else.
+type conformanceBackend struct {
+	name string
+	open func(t *testing.T, classrooms int, laboratories int, options ...AllocationOptions) conformanceService
+}
+
+var conformanceBackends = []conformanceBackend{
+	{"memory", openMemory},
+	{"sqlite", openSqlite},
+	{"postgres", openPostgres},
+}
+
+func openMemory(t *testing.T, classrooms int, laboratories int, options ...AllocationOptions) conformanceService {
+	return NewMemoryAllocationService(GenerateRooms(classrooms, laboratories), options...)
+}
+
+// openSqlite creates a SQLite database in a temporary directory holding the
+// generated rooms.
+func openSqlite(t *testing.T, classrooms int, laboratories int, options ...AllocationOptions) conformanceService {
+	t.Helper()
+
+	db := migrateSqlite(t, filepath.Join(t.TempDir(), "central.db"))
+	service := NewSqliteAllocationService(db, options...)
+	if _, err := service.Seed(context.Background(), GenerateRooms(classrooms, laboratories)); err != nil {
+		t.Fatalf("seed sqlite: %v", err)
+	}
+
+	return service
+}
+
+// migrateSqlite opens the database at path and applies migrations-sqlite.
+func migrateSqlite(t *testing.T, path string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", SqliteDSN(path))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrations, err := filepath.Glob("../../migrations-sqlite/*.up.sql")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("find sqlite migrations: %v", err)
+	}
+	slices.Sort(migrations)
+
+	for _, migration := range migrations {
+		script, err := os.ReadFile(migration)
+		if err != nil {
+			t.Fatalf("read %s: %v", migration, err)
+		}
+
+		if _, err := db.Exec(string(script)); err != nil {
+			t.Fatalf("apply %s: %v", migration, err)
+		}
+	}
+
+	return db
+}
+
+// openPostgres regenerates the rooms of the test database, which also drops
+// every allocation and booking.
+func openPostgres(t *testing.T, classrooms int, laboratories int, options ...AllocationOptions) conformanceService {
+	t.Helper()
+
+	url := os.Getenv(testDatabaseEnv)
+	if url == "" {
+		t.Skipf("%s is not set", testDatabaseEnv)
+	}
+
+	pool, err := pgxpool.New(context.Background(), url)
+	if err != nil {
+		t.Fatalf("connect postgres: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	if err := repository.New(pool).GenerateRooms(context.Background(), repository.GenerateRoomsParams{
+		NormalRooms:  int32(classrooms),
+		Laboratories: int32(laboratories),
+	}); err != nil {
+		t.Fatalf("generate rooms: %v", err)
+	}
+
+	return NewSqlcAllocationService(pool, options...)
+}
+
+// prepare opens a semester unique to the test and registers the faculties,
+// each with the programs "Sistemas" and "Quimica".
+func prepare(t *testing.T, service conformanceService, faculties ...string) string {
+	t.Helper()
+
+	ctx := context.Background()
+	semester := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	if _, err := service.CreateSemester(ctx, &models.SemesterRequest{Semester: semester, Open: true}); err != nil {
+		t.Fatalf("create semester: %v", err)
+	}
+
+	for _, faculty := range faculties {
+		if _, err := service.RegisterFaculty(ctx, &models.RegisterFacultyRequest{Faculty: faculty, Programs: []string{"Sistemas", "Quimica"}}); err != nil {
+			t.Fatalf("register %s: %v", faculty, err)
+		}
+	}
+
+	return semester
+}
+
+// offeredRooms lists every room of an allocation response.
+func offeredRooms(programs []models.ProgramAllocation) []string {
+	rooms := []string{}
+	for _, program := range programs {
+		rooms = append(rooms, program.Classrooms...)
+		rooms = append(rooms, program.Laboratories...)
+		rooms = append(rooms, program.Adapted...)
+	}
+
+	return rooms
+}
+
+func TestConformance(t *testing.T) {
+	scenarios := []struct {
+		name string
+		run  func(t *testing.T, backend conformanceBackend)
+	}{
+		{"double allocation", conformDoubleAllocation},
+		{"adapted laboratories", conformAdaptedLaboratories},
+		{"awaiting to locked", conformAwaitingToLocked},
+		{"decline", conformDecline},
+		{"expiry", conformExpiry},
+		{"blocked room relocation", conformBlockedRelocation},
+		{"upcoming outage", conformUpcomingOutage},
+		{"quote matches allocate", conformQuoteMatchesAllocate},
+		{"schedule", conformSchedule},
+		{"renewal within quota", conformRenewalQuota},
+		{"unplaced notified once", conformUnplacedNotice},
+		{"forget processed requests", conformForgetRequests},
+		{"allocation events", conformAllocationEvents},
+		{"concurrent workers", conformConcurrentWorkers},
+	}
+
+	for _, scenario := range scenarios {
+		for _, backend := range conformanceBackends {
+			t.Run(scenario.name+"/"+backend.name, func(t *testing.T) {
+				scenario.run(t, backend)
+			})
+		}
+	}
+}
+
+func conformDoubleAllocation(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 3, 0)
+	semester := prepare(t, service, "Ingenieria", "Ciencias")
+
+	// 1. The first faculty takes two of the three classrooms
+	first, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}})
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	// 2. A strict request for two more fails with the same message everywhere
+	_, err = service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ciencias", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}})
+	if want := "Not enough available classroom rooms to allocate (1 out of 2)"; err == nil || err.Error() != want {
+		t.Fatalf("strict allocate: got %v, want %q", err, want)
+	}
+
+	// 3. A partial request gets the one left, never a room already held
+	second, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ciencias", Partial: true, Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}})
+	if err != nil {
+		t.Fatalf("partial allocate: %v", err)
+	}
+
+	held := offeredRooms(second.Programs)
+	if len(held) != 1 || slices.Contains(offeredRooms(first.Programs), held[0]) {
+		t.Errorf("second faculty got %v, first holds %v", held, offeredRooms(first.Programs))
+	}
+}
+
+func conformAdaptedLaboratories(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 2, 1)
+	semester := prepare(t, service, "Ciencias")
+
+	// 1. Two laboratories are asked for, one is a classroom adapted for it
+	response, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ciencias", Programs: []models.ProgramInfo{{Name: "Quimica", Laboratories: 2}}})
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	program := response.Programs[0]
+	if len(program.Laboratories) != 1 || len(program.Adapted) != 1 {
+		t.Errorf("got laboratories %v and adapted %v, want one of each", program.Laboratories, program.Adapted)
+	}
+
+	// 2. Equipment cannot be adapted, so the last classroom is not enough
+	_, err = service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ciencias", Programs: []models.ProgramInfo{{Name: "Sistemas", Laboratories: 1, Equipment: "chemistry"}}})
+	if want := "Not enough rooms to allocate for labs (0 out of 1)"; err == nil || err.Error() != want {
+		t.Errorf("equipped allocate: got %v, want %q", err, want)
+	}
+}
+
+func conformAwaitingToLocked(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 2, 0)
+	semester := prepare(t, service, "Ingenieria")
+
+	if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 1}}}); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	// 1. Confirming locks the awaiting offer
+	if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ingenieria", Accept: true}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	// 2. There is nothing left to confirm afterwards
+	_, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ingenieria", Accept: true})
+	if want := fmt.Sprintf("no rooms offered to %q for semester %q (the offer may have expired)", "Ingenieria", semester); err == nil || err.Error() != want {
+		t.Errorf("second confirm: got %v, want %q", err, want)
+	}
+
+	// 3. Locked rooms survive a decline
+	declined, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ingenieria", Accept: false})
+	if err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+
+	if len(declined.Released) != 0 {
+		t.Errorf("decline released locked rooms %v", declined.Released)
+	}
+}
+
+func conformDecline(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 3, 0)
+	semester := prepare(t, service, "Ingenieria")
+
+	offered, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}})
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	// 1. Declining releases every offered room
+	declined, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ingenieria", Accept: false})
+	if err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+
+	released := slices.Sorted(slices.Values(declined.Released))
+	if want := slices.Sorted(slices.Values(offeredRooms(offered.Programs))); !slices.Equal(released, want) {
+		t.Errorf("released %v, want %v", released, want)
+	}
+
+	// 2. The rooms are free again
+	availability, err := service.Availability(ctx, semester)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+
+	if availability.Classrooms != 3 {
+		t.Errorf("%d classrooms free after decline, want 3", availability.Classrooms)
+	}
+}
+
+func conformExpiry(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 2, 0, WithHoldPeriod(200*time.Millisecond))
+	semester := prepare(t, service, "Ingenieria")
+
+	if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}}); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	// 1. Offers within the hold period stay
+	released, err := service.ExpireOffers(ctx)
+	if err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+
+	if slices.ContainsFunc(released, func(room models.ReleasedRoom) bool { return room.Semester == semester }) {
+		t.Fatalf("offers expired within the hold period: %v", released)
+	}
+
+	// 2. Offers past the hold period are released and cannot be confirmed
+	time.Sleep(300 * time.Millisecond)
+	released, err = service.ExpireOffers(ctx)
+	if err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+
+	expired := 0
+	for _, room := range released {
+		if room.Semester == semester {
+			expired++
+		}
+	}
+
+	if expired != 2 {
+		t.Errorf("%d offers expired, want 2", expired)
+	}
+
+	if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ingenieria", Accept: true}); err == nil {
+		t.Errorf("confirmed an expired offer")
+	}
+}
+
+func conformBlockedRelocation(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 3, 0, WithHoldPeriod(300*time.Millisecond))
+	semester := prepare(t, service, "Ingenieria")
+
+	offered, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 1}}})
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+	room := offeredRooms(offered.Programs)[0]
+
+	// 1. Blocking the room moves the offer elsewhere right away, or reports
+	// it when no room as large is free
+	time.Sleep(200 * time.Millisecond)
+	blocked, err := service.AdminBlockRoom(ctx, &models.AdminBlockRequest{Semester: semester, Room: room, Reason: "test"})
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+
+	moved := append(blocked.Relocated, blocked.Unplaced...)
+	if len(moved) != 1 || moved[0].Previous != room || moved[0].State != "awaiting" {
+		t.Fatalf("blocking %s relocated %v and left %v", room, blocked.Relocated, blocked.Unplaced)
+	}
+
+	if len(blocked.Relocated) == 1 && blocked.Relocated[0].Room == room {
+		t.Errorf("offer relocated to the blocked room %s", room)
+	}
+
+	// 2. The offer kept the time it was made, so it expires on schedule
+	time.Sleep(150 * time.Millisecond)
+	released, err := service.ExpireOffers(ctx)
+	if err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+
+	if !slices.ContainsFunc(released, func(room models.ReleasedRoom) bool { return room.Semester == semester }) {
+		t.Errorf("relocated offer did not expire with the hold period, released %v", released)
+	}
+}
+
+func conformUpcomingOutage(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 3, 0)
+	semester := prepare(t, service, "Ingenieria")
+
+	// 1. An outage starting tomorrow keeps the room from being offered today
+	if _, err := service.DeclareOutage(ctx, &models.OutageRequest{Room: "AUL-002", StartsAt: time.Now().Add(24 * time.Hour), EndsAt: time.Now().Add(48 * time.Hour), Reason: "test"}); err != nil {
+		t.Fatalf("declare outage: %v", err)
+	}
+
+	offered, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Partial: true, Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 3}}})
+	if err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	rooms := offeredRooms(offered.Programs)
+	if len(rooms) != 2 || slices.Contains(rooms, "AUL-002") {
+		t.Errorf("offered %v, want every classroom but AUL-002", rooms)
+	}
+
+	// 2. Nor is it counted as available
+	availability, err := service.Availability(ctx, semester)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+
+	if availability.Classrooms != 0 {
+		t.Errorf("%d classrooms available, want none", availability.Classrooms)
+	}
+}
+
+func conformQuoteMatchesAllocate(t *testing.T, backend conformanceBackend) {
+	names := make([]string, 0, len(Strategies))
+	for name := range Strategies {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	// 1. Each faculty in turn asks for rooms the ones before it may have taken,
+	// with every requirement a program can set
+	requests := [][]models.ProgramInfo{
+		{{Name: "Sistemas", Classrooms: 3, MinCapacity: 40}, {Name: "Quimica", Laboratories: 2, Equipment: "chemistry"}},
+		{{Name: "Sistemas", Classrooms: 2, Laboratories: 3, Building: "B"}},
+		{{Name: "Quimica", Classrooms: 6, Laboratories: 4, Equipment: "biology"}},
+		{{Name: "Sistemas", Classrooms: 4, Laboratories: 4}},
+	}
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			service := backend.open(t, 12, 8, WithStrategy(Strategies[name]))
+
+			faculties := []string{}
+			for i := range requests {
+				faculties = append(faculties, fmt.Sprintf("Facultad-%d", i))
+			}
+			semester := prepare(t, service, faculties...)
+
+			// 2. The quote lists exactly the rooms the allocation hands out next
+			for i, programs := range requests {
+				request := &models.AllocateRequest{Semester: semester, Faculty: faculties[i], Partial: true, Programs: programs}
+
+				quote, err := service.Quote(ctx, request)
+				if err != nil {
+					t.Fatalf("%s: quote: %v", faculties[i], err)
+				}
+
+				allocated, err := service.Allocate(ctx, request)
+				if err != nil {
+					t.Fatalf("%s: allocate: %v", faculties[i], err)
+				}
+
+				if fmt.Sprint(quote.Programs) != fmt.Sprint(allocated.Programs) {
+					t.Errorf("%s: quoted %v, allocated %v", faculties[i], quote.Programs, allocated.Programs)
+				}
+
+				if quote.Satisfiable == allocated.Partial {
+					t.Errorf("%s: quote satisfiable %v, allocation partial %v", faculties[i], quote.Satisfiable, allocated.Partial)
+				}
+			}
+		})
+	}
+}
+
+// timetableSlots lists the slots of a schedule response as room@day-start.
+func timetableSlots(programs []models.ProgramTimetable) []string {
+	slots := []string{}
+	for _, program := range programs {
+		for _, slot := range program.Slots {
+			slots = append(slots, fmt.Sprintf("%s@%s-%s", slot.Room, slot.Day, slot.Start))
+		}
+	}
+
+	return slots
+}
+
+func conformSchedule(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 2, 1)
+	semester := prepare(t, service, "Ingenieria", "Medicina")
+
+	// 1. Blocks are taken day by day and hour by hour, the program never in
+	// two rooms at once and laboratories in real laboratories first
+	scheduled, err := service.Schedule(ctx, &models.ScheduleRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", ClassroomHours: 4, LaboratoryHours: 2}}})
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	want := []string{"AUL-001@monday-07:00", "AUL-001@monday-09:00", "LAB-001@monday-11:00"}
+	if got := timetableSlots(scheduled.Programs); !slices.Equal(got, want) || scheduled.Partial {
+		t.Errorf("scheduled %v (partial %v), want %v", got, scheduled.Partial, want)
+	}
+
+	// 2. Another faculty gets the rooms still free at each hour
+	other, err := service.Schedule(ctx, &models.ScheduleRequest{Semester: semester, Faculty: "Medicina", Programs: []models.ProgramInfo{{Name: "Quimica", ClassroomHours: 2}}})
+	if err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	if got := timetableSlots(other.Programs); !slices.Equal(got, []string{"AUL-002@monday-07:00"}) {
+		t.Errorf("second faculty scheduled %v, want AUL-002 on monday at 07:00", got)
+	}
+
+	// 3. Booked rooms are not free for the whole semester
+	availability, err := service.Availability(ctx, semester)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+
+	if availability.Classrooms != 0 || availability.Laboratories != 0 {
+		t.Errorf("%d classrooms and %d laboratories available, want none", availability.Classrooms, availability.Laboratories)
+	}
+
+	// 4. A strict request that does not fit books nothing, a partial one
+	// reports the missing hours
+	if _, err := service.Schedule(ctx, &models.ScheduleRequest{Semester: semester, Faculty: "Medicina", Programs: []models.ProgramInfo{{Name: "Sistemas", LaboratoryHours: 200}}}); err == nil {
+		t.Errorf("scheduled more laboratory hours than the week has")
+	}
+
+	partial, err := service.Schedule(ctx, &models.ScheduleRequest{Semester: semester, Faculty: "Medicina", Partial: true, Programs: []models.ProgramInfo{{Name: "Sistemas", LaboratoryHours: 200}}})
+	if err != nil {
+		t.Fatalf("partial schedule: %v", err)
+	}
+
+	if !partial.Partial || partial.Programs[0].MissingLaboratoryHours == 0 {
+		t.Errorf("partial schedule reported no missing hours: %+v", partial.Programs)
+	}
+
+	// 5. Declining releases the offered blocks, accepting keeps them
+	declined, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Medicina", Accept: false})
+	if err != nil || !slices.Contains(declined.Released, "AUL-002") {
+		t.Errorf("decline: %v (released %v)", err, declined)
+	}
+
+	if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ingenieria", Accept: true}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	// 6. A blocked room's time blocks are relocated, or reported when no
+	// equivalent room is free: no classroom has the laboratory's equipment
+	blocked, err := service.AdminBlockRoom(ctx, &models.AdminBlockRequest{Semester: semester, Room: "LAB-001", Reason: "test"})
+	if err != nil {
+		t.Fatalf("block: %v", err)
+	}
+
+	if len(blocked.Relocated) != 0 || len(blocked.Unplaced) != 1 || blocked.Unplaced[0].Day != "monday" || blocked.Unplaced[0].Start != 11 {
+		t.Errorf("blocking LAB-001 relocated %v and left %v, want its monday 11:00 block unplaced", blocked.Relocated, blocked.Unplaced)
+	}
+
+	// 7. Confirmed blocks are cancelled
+	cancelled, err := service.Cancel(ctx, &models.CancelRequest{Semester: semester, Faculty: "Ingenieria"})
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	slices.Sort(cancelled.Released)
+	if !slices.Equal(cancelled.Released, []string{"AUL-001", "LAB-001"}) {
+		t.Errorf("cancel released %v, want AUL-001 and LAB-001", cancelled.Released)
+	}
+}
+
+func conformRenewalQuota(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 4, 0)
+	source := prepare(t, service, "Ingenieria")
+
+	target := source + "-next"
+	if _, err := service.CreateSemester(ctx, &models.SemesterRequest{Semester: target, Open: true}); err != nil {
+		t.Fatalf("create semester: %v", err)
+	}
+
+	if _, err := service.SetQuota(ctx, &models.FacultyQuota{Semester: target, Faculty: "Ingenieria", Classrooms: 2}); err != nil {
+		t.Fatalf("set quota: %v", err)
+	}
+
+	if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: source, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}}); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: source, Faculty: "Ingenieria", Accept: true}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+
+	// 1. Renewing takes the whole quota
+	request := &models.RenewRequest{Source: source, Target: target, Faculty: "Ingenieria"}
+	if _, err := service.Renew(ctx, request); err != nil {
+		t.Fatalf("renew: %v", err)
+	}
+
+	// 2. Renewing again keeps the rooms already held, which are not counted
+	// twice against the quota
+	if _, err := service.Renew(ctx, request); err != nil {
+		t.Errorf("second renew: %v", err)
+	}
+
+	availability, err := service.Availability(ctx, target)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+
+	if availability.Classrooms != 2 {
+		t.Errorf("%d classrooms free after renewing twice, want 2", availability.Classrooms)
+	}
+}
+
+func conformUnplacedNotice(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	buffer := &notificationBuffer{}
+	service := backend.open(t, 1, 0, WithNotifier(buffer))
+	semester := prepare(t, service, "Ingenieria")
+
+	if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 1}}}); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	block := func(unblock bool) {
+		t.Helper()
+		if _, err := service.AdminBlockRoom(ctx, &models.AdminBlockRequest{Semester: semester, Room: "AUL-001", Reason: "test", Unblock: unblock}); err != nil {
+			t.Fatalf("block %v: %v", unblock, err)
+		}
+	}
+
+	notices := func() int {
+		count := 0
+		for _, notification := range buffer.notifications {
+			if notification.Type == "unplaced" && notification.Semester == semester && slices.Equal(notification.Rooms, []string{"AUL-001"}) {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	// 1. The only room is blocked, there is nowhere to move the offer
+	block(false)
+	if got := notices(); got != 1 {
+		t.Fatalf("%d unplaced notifications after blocking, want 1", got)
+	}
+
+	// 2. Trying again does not tell the faculty again
+	for i := 0; i < 2; i++ {
+		if _, err := service.RelocateOutages(ctx); err != nil {
+			t.Fatalf("relocate outages: %v", err)
+		}
+	}
+
+	if got := notices(); got != 1 {
+		t.Errorf("%d unplaced notifications after retrying, want 1", got)
+	}
+
+	// 3. Once the room is back in service, a new block is told again
+	block(true)
+	if _, err := service.RelocateOutages(ctx); err != nil {
+		t.Fatalf("relocate outages: %v", err)
+	}
+
+	block(false)
+	if got := notices(); got != 2 {
+		t.Errorf("%d unplaced notifications after blocking again, want 2", got)
+	}
+}
+
+func conformForgetRequests(t *testing.T, backend conformanceBackend) {
+	service := backend.open(t, 2, 0, WithReplayWindow(time.Second))
+	semester := prepare(t, service, "Ingenieria")
+
+	ctx := WithRequestKey(context.Background(), fmt.Sprintf("forget-%d", time.Now().UnixNano()), 1)
+	if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 1}}}); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	// 1. Past the replay window the stored response is deleted
+	time.Sleep(2100 * time.Millisecond)
+	forgotten, err := service.ForgetRequests(context.Background())
+	if err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+
+	if forgotten < 1 {
+		t.Errorf("%d processed requests forgotten, want the allocation's", forgotten)
+	}
+
+	// 2. Nothing is left to forget
+	forgotten, err = service.ForgetRequests(context.Background())
+	if err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+
+	if forgotten != 0 {
+		t.Errorf("%d processed requests forgotten twice", forgotten)
+	}
+}
+
+// eventLines describes the events as "type room", with the weekday and hours
+// of time blocks.
+func eventLines(events []models.AllocationEvent) []string {
+	lines := []string{}
+	for _, event := range events {
+		line := event.Type + " " + event.Room
+		if event.Weekday != 0 {
+			line += fmt.Sprintf("@%d-%d-%d", event.Weekday, event.StartHour, event.EndHour)
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func conformAllocationEvents(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+
+	// 0. The memory storage appends its events to a file, the databases
+	// record them themselves
+	path := filepath.Join(t.TempDir(), "events.jsonl")
+	eventLog, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create event log: %v", err)
+	}
+	t.Cleanup(func() { eventLog.Close() })
+
+	service := backend.open(t, 3, 0, WithEventLog(eventLog))
+	history, ok := service.(EventService)
+	if !ok {
+		history = NewFileEventService(path)
+	}
+
+	semester := prepare(t, service, "Ingenieria", "Ciencias")
+
+	// 1. Offers, a decline, a confirmation and a cancellation
+	if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}}); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: "Ciencias", Programs: []models.ProgramInfo{{Name: "Quimica", Classrooms: 1}}}); err != nil {
+		t.Fatalf("allocate: %v", err)
+	}
+
+	if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ciencias", Accept: false}); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+
+	if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ingenieria", Accept: true}); err != nil {
+		t.Fatalf("confirm: %v", err)
+	}
+	confirmedAt := time.Now()
+
+	if _, err := service.Cancel(ctx, &models.CancelRequest{Semester: semester, Faculty: "Ingenieria"}); err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+
+	// 2. Time blocks are recorded with their weekday and hours
+	if _, err := service.Schedule(ctx, &models.ScheduleRequest{Semester: semester, Faculty: "Ciencias", Programs: []models.ProgramInfo{{Name: "Quimica", ClassroomHours: 2}}}); err != nil {
+		t.Fatalf("schedule: %v", err)
+	}
+
+	if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: "Ciencias", Accept: false}); err != nil {
+		t.Fatalf("decline: %v", err)
+	}
+
+	// 3. Every change is there, in order, and releases say why
+	events, err := history.Events(ctx, time.Now(), semester)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+
+	want := []string{
+		"offered AUL-001", "offered AUL-002",
+		"offered AUL-003", "declined AUL-003",
+		"confirmed AUL-001", "confirmed AUL-002",
+		"cancelled AUL-001", "cancelled AUL-002",
+		"offered AUL-001@1-7-9", "declined AUL-001@1-7-9",
+	}
+	if got := eventLines(events); !slices.Equal(got, want) {
+		t.Errorf("events %v, want %v", got, want)
+	}
+
+	// 4. Reading up to a point in time stops there
+	events, err = history.Events(ctx, confirmedAt, semester)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+
+	if got := eventLines(events); !slices.Equal(got, want[:6]) {
+		t.Errorf("events until the confirmation %v, want %v", got, want[:6])
+	}
+}
+
+func conformConcurrentWorkers(t *testing.T, backend conformanceBackend) {
+	ctx := context.Background()
+	service := backend.open(t, 20, 0)
+
+	faculties := []string{}
+	for i := 0; i < 10; i++ {
+		faculties = append(faculties, fmt.Sprintf("Facultad-%d", i))
+	}
+	semester := prepare(t, service, faculties...)
+
+	// 1. Ten workers ask for three classrooms each, there are only twenty
+	var waitgroup sync.WaitGroup
+	responses := make([]*models.AllocateResponse, len(faculties))
+	errs := make([]error, len(faculties))
+	for i, faculty := range faculties {
+		waitgroup.Add(1)
+		go func() {
+			defer waitgroup.Done()
+			responses[i], errs[i] = service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: faculty, Partial: true, Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 3}}})
+		}()
+	}
+	waitgroup.Wait()
+
+	// 2. Every classroom is handed out exactly once
+	holders := map[string]string{}
+	for i, response := range responses {
+		if errs[i] != nil {
+			t.Fatalf("%s: %v", faculties[i], errs[i])
+		}
+
+		for _, room := range offeredRooms(response.Programs) {
+			if holder, taken := holders[room]; taken {
+				t.Errorf("room %s given to %s and %s", room, holder, faculties[i])
+			}
+			holders[room] = faculties[i]
+		}
+	}
+
+	if len(holders) != 20 {
+		t.Errorf("%d classrooms handed out, want 20", len(holders))
+	}
+}
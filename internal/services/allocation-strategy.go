@@ -0,0 +1,165 @@
+package services
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+)
+
+// AllocationStrategy decides the order in which free rooms are handed out to
+// a program. The database still decides which rooms are free, so a strategy
+// only ranks the candidates it is given.
+type AllocationStrategy interface {
+	Name() string
+	Rank(candidates []models.Room, program models.ProgramInfo) []models.Room
+}
+
+// Strategies lists the available allocation strategies by name.
+var Strategies = map[string]AllocationStrategy{
+	"first-fit":        FirstFitStrategy{},
+	"best-fit":         BestFitStrategy{},
+	"spread":           SpreadStrategy{},
+	"pack-by-building": PackByBuildingStrategy{},
+}
+
+// StrategyByName returns the allocation strategy with the given name.
+func StrategyByName(name string) (AllocationStrategy, error) {
+	strategy, ok := Strategies[name]
+	if !ok {
+		names := make([]string, 0, len(Strategies))
+		for known := range Strategies {
+			names = append(names, known)
+		}
+		slices.Sort(names)
+
+		return nil, fmt.Errorf("unknown allocation strategy %q (expected one of %s)", name, strings.Join(names, ", "))
+	}
+
+	return strategy, nil
+}
+
+// FirstFitStrategy hands out the lowest numbered rooms first.
+type FirstFitStrategy struct{}
+
+func (FirstFitStrategy) Name() string {
+	return "first-fit"
+}
+
+func (FirstFitStrategy) Rank(candidates []models.Room, program models.ProgramInfo) []models.Room {
+	return rankRooms(candidates, program, func(a, b models.Room) int {
+		return a.ID - b.ID
+	})
+}
+
+// BestFitStrategy hands out the smallest rooms that meet the program's minimum
+// capacity first, keeping the large rooms for the programs that need them.
+type BestFitStrategy struct{}
+
+func (BestFitStrategy) Name() string {
+	return "best-fit"
+}
+
+func (BestFitStrategy) Rank(candidates []models.Room, program models.ProgramInfo) []models.Room {
+	return rankRooms(candidates, program, func(a, b models.Room) int {
+		if a.Capacity != b.Capacity {
+			return a.Capacity - b.Capacity
+		}
+
+		return a.ID - b.ID
+	})
+}
+
+// SpreadStrategy takes rooms from every building in turn, so the load is
+// spread evenly over the campus.
+type SpreadStrategy struct{}
+
+func (SpreadStrategy) Name() string {
+	return "spread"
+}
+
+func (SpreadStrategy) Rank(candidates []models.Room, program models.ProgramInfo) []models.Room {
+	// 1. Number the rooms of each building in order
+	turns := make(map[int]int, len(candidates))
+	seen := make(map[string]int)
+	for _, room := range sortedByID(candidates) {
+		turns[room.ID] = seen[room.Building]
+		seen[room.Building]++
+	}
+
+	// 2. Take the first room of every building, then the second, ...
+	return rankRooms(candidates, program, func(a, b models.Room) int {
+		if turns[a.ID] != turns[b.ID] {
+			return turns[a.ID] - turns[b.ID]
+		}
+
+		if a.Building != b.Building {
+			return strings.Compare(a.Building, b.Building)
+		}
+
+		return a.ID - b.ID
+	})
+}
+
+// PackByBuildingStrategy keeps a program's rooms together, taking them from
+// the building with the most free rooms and filling it floor by floor.
+type PackByBuildingStrategy struct{}
+
+func (PackByBuildingStrategy) Name() string {
+	return "pack-by-building"
+}
+
+func (PackByBuildingStrategy) Rank(candidates []models.Room, program models.ProgramInfo) []models.Room {
+	// 1. Count the free rooms of each building
+	free := make(map[string]int)
+	for _, room := range candidates {
+		free[room.Building]++
+	}
+
+	// 2. Fullest buildings first, then by floor
+	return rankRooms(candidates, program, func(a, b models.Room) int {
+		if free[a.Building] != free[b.Building] {
+			return free[b.Building] - free[a.Building]
+		}
+
+		if a.Building != b.Building {
+			return strings.Compare(a.Building, b.Building)
+		}
+
+		if a.Floor != b.Floor {
+			return a.Floor - b.Floor
+		}
+
+		return a.ID - b.ID
+	})
+}
+
+// rankRooms returns a sorted copy of the candidates. Rooms in the program's
+// preferred building always come first, whatever the strategy.
+func rankRooms(candidates []models.Room, program models.ProgramInfo, compare func(a, b models.Room) int) []models.Room {
+	ranked := slices.Clone(candidates)
+	slices.SortStableFunc(ranked, func(a, b models.Room) int {
+		if program.Building != "" {
+			if preferA, preferB := a.Building == program.Building, b.Building == program.Building; preferA != preferB {
+				if preferA {
+					return -1
+				}
+				return 1
+			}
+		}
+
+		return compare(a, b)
+	})
+
+	return ranked
+}
+
+func sortedByID(candidates []models.Room) []models.Room {
+	sorted := slices.Clone(candidates)
+	slices.SortFunc(sorted, func(a, b models.Room) int {
+		return a.ID - b.ID
+	})
+
+	return sorted
+}
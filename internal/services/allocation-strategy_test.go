@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+)
+
+// strategyRun allocates the given requests in order, each from its own
+// faculty, with a fresh in-memory backend using the strategy.
+func strategyRun(t *testing.T, strategy AllocationStrategy, rooms []models.Room, requests []models.ProgramInfo) []*models.AllocateResponse {
+	t.Helper()
+
+	ctx := context.Background()
+	service := NewMemoryAllocationService(rooms, WithStrategy(strategy))
+	if _, err := service.CreateSemester(ctx, &models.SemesterRequest{Semester: "2025-1", Open: true}); err != nil {
+		t.Fatalf("create semester: %v", err)
+	}
+
+	responses := []*models.AllocateResponse{}
+	for i, program := range requests {
+		faculty := fmt.Sprintf("faculty-%d", i)
+		if _, err := service.RegisterFaculty(ctx, &models.RegisterFacultyRequest{Faculty: faculty, Programs: []string{program.Name}}); err != nil {
+			t.Fatalf("register %s: %v", faculty, err)
+		}
+
+		response, err := service.Allocate(ctx, &models.AllocateRequest{
+			Semester: "2025-1",
+			Faculty:  faculty,
+			Programs: []models.ProgramInfo{program},
+		})
+		if err != nil {
+			t.Fatalf("%s: allocate %s: %v", strategy.Name(), faculty, err)
+		}
+
+		responses = append(responses, response)
+	}
+
+	return responses
+}
+
+// fragmentation is the average number of buildings each program's rooms are
+// spread over.
+func fragmentation(rooms []models.Room, responses []*models.AllocateResponse) float64 {
+	buildings := make(map[string]string, len(rooms))
+	for _, room := range rooms {
+		buildings[room.Name] = room.Building
+	}
+
+	total, programs := 0, 0
+	for _, response := range responses {
+		for _, program := range response.Programs {
+			used := map[string]bool{}
+			for _, names := range [][]string{program.Classrooms, program.Laboratories, program.Adapted} {
+				for _, name := range names {
+					used[buildings[name]] = true
+				}
+			}
+
+			total += len(used)
+			programs++
+		}
+	}
+
+	return float64(total) / float64(programs)
+}
+
+// fallbackRate is the share of the laboratories handed out as adapted
+// classrooms.
+func fallbackRate(responses []*models.AllocateResponse) float64 {
+	adapted, laboratories := 0, 0
+	for _, response := range responses {
+		for _, program := range response.Programs {
+			adapted += len(program.Adapted)
+			laboratories += len(program.Laboratories) + len(program.Adapted)
+		}
+	}
+
+	return float64(adapted) / float64(laboratories)
+}
+
+func TestStrategyFragmentation(t *testing.T) {
+	// 1. Twelve equal classrooms, the buildings taking turns by id
+	rooms := []models.Room{}
+	for i := 1; i <= 12; i++ {
+		rooms = append(rooms, models.Room{
+			ID:       i,
+			Name:     fmt.Sprintf("AUL-%03d", i),
+			Capacity: 30,
+			Building: []string{"A", "B", "C"}[i%3],
+			Floor:    1 + i/6,
+		})
+	}
+
+	requests := []models.ProgramInfo{
+		{Name: "Sistemas", Classrooms: 3},
+		{Name: "Civil", Classrooms: 3},
+		{Name: "Industrial", Classrooms: 3},
+	}
+
+	// 2. Measure every strategy
+	measured := map[string]float64{}
+	for _, name := range slices.Sorted(maps.Keys(Strategies)) {
+		measured[name] = fragmentation(rooms, strategyRun(t, Strategies[name], rooms, requests))
+		t.Logf("%-16s %.2f buildings per program", name, measured[name])
+	}
+
+	// 3. Packing keeps programs together, spreading does the opposite
+	for name, value := range measured {
+		if measured["pack-by-building"] > value {
+			t.Errorf("pack-by-building spreads programs over %.2f buildings, more than %s (%.2f)", measured["pack-by-building"], name, value)
+		}
+
+		if measured["spread"] < value {
+			t.Errorf("spread keeps programs in %.2f buildings, fewer than %s (%.2f)", measured["spread"], name, value)
+		}
+	}
+
+	if measured["pack-by-building"] != 1 {
+		t.Errorf("pack-by-building spreads programs over %.2f buildings, want 1", measured["pack-by-building"])
+	}
+}
+
+func TestStrategyAdaptedFallback(t *testing.T) {
+	// 1. Large and small laboratories in turns, plus classrooms to fall back to
+	rooms := []models.Room{}
+	for i := 1; i <= 6; i++ {
+		rooms = append(rooms, models.Room{ID: i, Name: fmt.Sprintf("AUL-%03d", i), Capacity: 50, Building: "A"})
+	}
+
+	for i := 1; i <= 6; i++ {
+		rooms = append(rooms, models.Room{
+			ID:         6 + i,
+			Name:       fmt.Sprintf("LAB-%03d", i),
+			Laboratory: true,
+			Capacity:   []int{40, 20}[i%2],
+			Building:   []string{"A", "B"}[i%2],
+		})
+	}
+
+	// 2. A program that fits anywhere asks first, one that needs the large
+	// laboratories asks second
+	requests := []models.ProgramInfo{
+		{Name: "Biologia", Laboratories: 3},
+		{Name: "Quimica", Laboratories: 3, MinCapacity: 30},
+	}
+
+	measured := map[string]float64{}
+	for _, name := range slices.Sorted(maps.Keys(Strategies)) {
+		measured[name] = fallbackRate(strategyRun(t, Strategies[name], rooms, requests))
+		t.Logf("%-16s %.2f of the laboratories adapted", name, measured[name])
+	}
+
+	// 3. Best-fit keeps the large laboratories for the program that needs them
+	if measured["best-fit"] != 0 {
+		t.Errorf("best-fit adapted %.2f of the laboratories, want 0", measured["best-fit"])
+	}
+
+	if measured["first-fit"] == 0 {
+		t.Errorf("first-fit adapted no laboratories, the scenario should make it fall back")
+	}
+
+	for name, value := range measured {
+		if measured["best-fit"] > value {
+			t.Errorf("best-fit adapted %.2f of the laboratories, more than %s (%.2f)", measured["best-fit"], name, value)
+		}
+	}
+}
+
+func TestWaitlistUsesStrategy(t *testing.T) {
+	ctx := context.Background()
+	rooms := []models.Room{
+		{ID: 1, Name: "AUL-001", Capacity: 60, Building: "A"},
+		{ID: 2, Name: "AUL-002", Capacity: 30, Building: "A"},
+	}
+
+	for _, test := range []struct {
+		strategy AllocationStrategy
+		room     string
+	}{
+		{FirstFitStrategy{}, "AUL-001"},
+		{BestFitStrategy{}, "AUL-002"},
+	} {
+		t.Run(test.strategy.Name(), func(t *testing.T) {
+			service := NewMemoryAllocationService(rooms, WithStrategy(test.strategy))
+			service.CreateSemester(ctx, &models.SemesterRequest{Semester: "2025-1", Open: true})
+			service.RegisterFaculty(ctx, &models.RegisterFacultyRequest{Faculty: "Ingenieria", Programs: []string{"Sistemas"}})
+			service.RegisterFaculty(ctx, &models.RegisterFacultyRequest{Faculty: "Artes", Programs: []string{"Musica"}})
+
+			// 1. One faculty takes every room, the other waits for one
+			if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: "2025-1", Faculty: "Ingenieria", Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}}); err != nil {
+				t.Fatalf("allocate: %v", err)
+			}
+
+			waiting, err := service.Allocate(ctx, &models.AllocateRequest{Semester: "2025-1", Faculty: "Artes", Waitlist: true, Programs: []models.ProgramInfo{{Name: "Musica", Classrooms: 1}}})
+			if err != nil || !waiting.Waitlisted {
+				t.Fatalf("waitlist: %v (waitlisted: %v)", err, waiting != nil && waiting.Waitlisted)
+			}
+
+			// 2. Declining frees both rooms, the waitlist takes the strategy's pick
+			if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: "2025-1", Faculty: "Ingenieria", Accept: false}); err != nil {
+				t.Fatalf("decline: %v", err)
+			}
+
+			quote, err := service.Quote(ctx, &models.AllocateRequest{Semester: "2025-1", Faculty: "Artes", Programs: []models.ProgramInfo{{Name: "Musica"}}})
+			if err != nil {
+				t.Fatalf("quote: %v", err)
+			}
+
+			if got := quote.Programs[0].Classrooms; len(got) != 1 || got[0] != test.room {
+				t.Errorf("waitlist granted %v, want [%s]", got, test.room)
+			}
+		})
+	}
+}
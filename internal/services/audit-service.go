@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditService keeps the audit trail of every request received by the server,
+// newest records first.
+type AuditService interface {
+	Record(ctx context.Context, record *models.AuditRecord) error
+	Audit(ctx context.Context, request *models.AuditRequest) (*models.AuditResponse, error)
+}
+
+// parseSince parses the Since filter of an audit request.
+func parseSince(request *models.AuditRequest) (time.Time, error) {
+	if request.Since == "" {
+		return time.Time{}, nil
+	}
+
+	since, err := time.Parse(time.RFC3339, request.Since)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid since timestamp %q: %w", request.Since, err)
+	}
+
+	return since, nil
+}
+
+type SqlcAuditService struct {
+	pool *pgxpool.Pool
+}
+
+func NewSqlcAuditService(pool *pgxpool.Pool) *SqlcAuditService {
+	return &SqlcAuditService{
+		pool: pool,
+	}
+}
+
+func (s *SqlcAuditService) Record(ctx context.Context, record *models.AuditRecord) error {
+	return repository.New(s.pool).AddAuditRecord(ctx, repository.AddAuditRecordParams{
+		Identity:    record.Identity,
+		RequestID:   int32(record.RequestID),
+		Faculty:     record.Faculty,
+		Type:        record.Type,
+		PayloadHash: record.PayloadHash,
+		Outcome:     record.Outcome,
+		Error:       record.Error,
+		LatencyUs:   record.LatencyUs,
+		Semester:    record.Semester,
+		Program:     record.Program,
+		Room:        record.Room,
+	})
+}
+
+func (s *SqlcAuditService) Audit(ctx context.Context, request *models.AuditRequest) (*models.AuditResponse, error) {
+	// 1. Parse the filters
+	since, err := parseSince(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Get the matching records
+	rows, err := repository.New(s.pool).GetAuditRecords(ctx, repository.GetAuditRecordsParams{
+		Faculty:    request.Faculty,
+		Type:       request.Type,
+		Outcome:    request.Outcome,
+		Since:      pgtype.Timestamptz{Time: since, Valid: !since.IsZero()},
+		MaxRecords: int32(request.Limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Generate response
+	response := &models.AuditResponse{Records: []models.AuditRecord{}}
+	for _, row := range rows {
+		response.Records = append(response.Records, models.AuditRecord{
+			ID:          row.ID,
+			CreatedAt:   row.CreatedAt.Time,
+			Identity:    row.Identity,
+			RequestID:   int(row.RequestID),
+			Faculty:     row.Faculty,
+			Type:        row.Type,
+			PayloadHash: row.PayloadHash,
+			Outcome:     row.Outcome,
+			Error:       row.Error,
+			LatencyUs:   row.LatencyUs,
+			Semester:    row.Semester,
+			Program:     row.Program,
+			Room:        row.Room,
+		})
+	}
+
+	return response, nil
+}
+
+type SqliteAuditService struct {
+	db *sql.DB
+}
+
+func NewSqliteAuditService(db *sql.DB) *SqliteAuditService {
+	return &SqliteAuditService{
+		db: db,
+	}
+}
+
+func (s *SqliteAuditService) Record(ctx context.Context, record *models.AuditRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO audit_log (created_at, identity, request_id, faculty, type, payload_hash, outcome, error, latency_us, semester, program, room) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+		time.Now().UTC(),
+		record.Identity,
+		record.RequestID,
+		record.Faculty,
+		record.Type,
+		record.PayloadHash,
+		record.Outcome,
+		record.Error,
+		record.LatencyUs,
+		record.Semester,
+		record.Program,
+		record.Room,
+	)
+
+	return err
+}
+
+func (s *SqliteAuditService) Audit(ctx context.Context, request *models.AuditRequest) (*models.AuditResponse, error) {
+	// 1. Parse the filters
+	since, err := parseSince(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Get the matching records (times are stored in UTC so they compare as
+	// text; a negative LIMIT means no limit in SQLite)
+	limit := request.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, created_at, identity, request_id, faculty, type, payload_hash, outcome, error, latency_us, semester, program, room
+		FROM audit_log
+		WHERE (? = '' OR faculty = ?) AND (? = '' OR type = ?) AND (? = '' OR outcome = ?) AND created_at >= ?
+		ORDER BY id DESC
+		LIMIT ?`,
+		request.Faculty, request.Faculty,
+		request.Type, request.Type,
+		request.Outcome, request.Outcome,
+		since.UTC(),
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// 3. Generate response
+	response := &models.AuditResponse{Records: []models.AuditRecord{}}
+	for rows.Next() {
+		var record models.AuditRecord
+		if err := rows.Scan(
+			&record.ID,
+			&record.CreatedAt,
+			&record.Identity,
+			&record.RequestID,
+			&record.Faculty,
+			&record.Type,
+			&record.PayloadHash,
+			&record.Outcome,
+			&record.Error,
+			&record.LatencyUs,
+			&record.Semester,
+			&record.Program,
+			&record.Room,
+		); err != nil {
+			return nil, err
+		}
+
+		response.Records = append(response.Records, record)
+	}
+
+	return response, rows.Err()
+}
+
+// MemoryAuditService keeps the audit trail in memory, for the memory storage.
+type MemoryAuditService struct {
+	mutex   sync.Mutex
+	records []models.AuditRecord
+}
+
+func NewMemoryAuditService() *MemoryAuditService {
+	return &MemoryAuditService{}
+}
+
+func (s *MemoryAuditService) Record(ctx context.Context, record *models.AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stored := *record
+	stored.ID = int64(len(s.records) + 1)
+	stored.CreatedAt = time.Now()
+	s.records = append(s.records, stored)
+
+	return nil
+}
+
+func (s *MemoryAuditService) Audit(ctx context.Context, request *models.AuditRequest) (*models.AuditResponse, error) {
+	since, err := parseSince(request)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	response := &models.AuditResponse{Records: []models.AuditRecord{}}
+	for _, record := range slices.Backward(s.records) {
+		if request.Limit > 0 && len(response.Records) == request.Limit {
+			break
+		}
+
+		if (request.Faculty != "" && record.Faculty != request.Faculty) ||
+			(request.Type != "" && record.Type != request.Type) ||
+			(request.Outcome != "" && record.Outcome != request.Outcome) ||
+			record.CreatedAt.Before(since) {
+			continue
+		}
+
+		response.Records = append(response.Records, record)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+)
+
+type BatchAllocationService interface {
+	AllocateBatch(ctx context.Context, request *models.BatchAllocateRequest) (*models.BatchAllocateResponse, error)
+}
+
+// BatchAllocationError names the request of a batch that failed. Nothing in
+// the batch is allocated.
+type BatchAllocationError struct {
+	Failure models.BatchFailure
+}
+
+func (e *BatchAllocationError) Error() string {
+	return fmt.Sprintf("request %d of the batch (%s) failed: %s", e.Failure.Index, e.Failure.Faculty, e.Failure.Reason)
+}
+
+// Details is sent to the client along with the error.
+func (e *BatchAllocationError) Details() interface{} {
+	return e.Failure
+}
+
+// AllocateBatch allocates the requests of several faculties in a single
+// transaction: either every request gets all its rooms or none does.
+func (s *SqlcAllocationService) AllocateBatch(ctx context.Context, request *models.BatchAllocateRequest) (*models.BatchAllocateResponse, error) {
+	if err := validateBatch(request); err != nil {
+		return nil, err
+	}
+
+	response := &models.BatchAllocateResponse{}
+	if err := s.transaction(ctx, "allocate-batch", response, func(querier *repository.Queries) error {
+		response.Responses = []models.AllocateResponse{}
+
+		for i := range request.Requests {
+			allocate := &request.Requests[i]
+
+			// 1. Same checks as a single allocate request
+			if err := s.requireOpen(ctx, querier, allocate.Semester); err != nil {
+				return batchError(i, allocate, err)
+			}
+
+			if err := s.checkDemand(ctx, querier, allocate.Semester, allocate.Faculty, allocate.Programs); err != nil {
+				return batchError(i, allocate, err)
+			}
+
+			// 2. Allocate without failing, so the program that ran short can be named
+			shortfall, err := s.allocatePrograms(ctx, querier, allocate, false)
+			if err != nil {
+				return batchError(i, allocate, err)
+			}
+
+			if len(shortfall) > 0 {
+				return batchShortfall(i, allocate, shortfall[0])
+			}
+
+			// 3. Get the allocated rooms
+			programs, err := s.programAllocations(ctx, querier, allocate)
+			if err != nil {
+				return err
+			}
+
+			response.Responses = append(response.Responses, models.AllocateResponse{
+				Semester: allocate.Semester,
+				Faculty:  allocate.Faculty,
+				Deadline: time.Now().Add(s.hold),
+				Programs: programs,
+			})
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// validateBatch rejects empty batches and requests that could be granted only
+// in part, which a batch cannot honour.
+func validateBatch(request *models.BatchAllocateRequest) error {
+	if len(request.Requests) == 0 {
+		return fmt.Errorf("the batch has no requests")
+	}
+
+	for i, allocate := range request.Requests {
+		if allocate.Partial || allocate.Waitlist {
+			return fmt.Errorf("request %d of the batch (%s) cannot be partial or waitlisted", i, allocate.Faculty)
+		}
+	}
+
+	return nil
+}
+
+// batchSemesters lists the semesters a batch touches.
+func batchSemesters(request *models.BatchAllocateRequest) []string {
+	semesters := []string{}
+	for _, allocate := range request.Requests {
+		if !slices.Contains(semesters, allocate.Semester) {
+			semesters = append(semesters, allocate.Semester)
+		}
+	}
+
+	slices.Sort(semesters)
+	return semesters
+}
+
+func batchError(index int, request *models.AllocateRequest, err error) error {
+	return &BatchAllocationError{
+		Failure: models.BatchFailure{
+			Index:    index,
+			Semester: request.Semester,
+			Faculty:  request.Faculty,
+			Reason:   err.Error(),
+		},
+	}
+}
+
+func batchShortfall(index int, request *models.AllocateRequest, missing models.ProgramShortfall) error {
+	return &BatchAllocationError{
+		Failure: models.BatchFailure{
+			Index:               index,
+			Semester:            request.Semester,
+			Faculty:             request.Faculty,
+			Program:             missing.Name,
+			Reason:              fmt.Sprintf("program %q ran short (%d classrooms and %d laboratories missing)", missing.Name, max(missing.Classrooms, 0), max(missing.Laboratories, 0)),
+			MissingClassrooms:   max(missing.Classrooms, 0),
+			MissingLaboratories: max(missing.Laboratories, 0),
+			Unmet:               missing.Unmet,
+		},
+	}
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"maps"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EventService reads back the allocation events recorded up to a point in
+// time, oldest first. An empty semester reads every semester.
+type EventService interface {
+	Events(ctx context.Context, until time.Time, semester string) ([]models.AllocationEvent, error)
+}
+
+// Events reads the events the allocation_events triggers recorded.
+func (s *SqlcAllocationService) Events(ctx context.Context, until time.Time, semester string) ([]models.AllocationEvent, error) {
+	rows, err := repository.New(s.pool).GetEventsUntil(ctx, repository.GetEventsUntilParams{
+		Until:    pgtype.Timestamptz{Time: until, Valid: true},
+		Semester: semester,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]models.AllocationEvent, 0, len(rows))
+	for _, row := range rows {
+		event := models.AllocationEvent{
+			ID:         row.ID,
+			OccurredAt: row.OccurredAt.Time,
+			Type:       row.Type,
+			Semester:   row.Semester,
+			Faculty:    row.Faculty,
+			Program:    row.Program,
+			RoomID:     int(row.RoomID),
+			Room:       row.Room,
+			Adapted:    row.Adapted,
+		}
+
+		if row.Weekday.Valid {
+			event.Weekday = int(row.Weekday.Int32)
+			event.StartHour = int(row.Hours.Lower.Int32)
+			event.EndHour = int(row.Hours.Upper.Int32)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// Events reads the events save recorded in allocation_events.
+func (s *SqliteAllocationService) Events(ctx context.Context, until time.Time, semester string) ([]models.AllocationEvent, error) {
+	// Times are stored in UTC so they compare as text
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, occurred_at, type, semester, faculty, program, room_id, room, adapted, weekday, start_hour, end_hour
+		FROM allocation_events
+		WHERE occurred_at <= ? AND (? = '' OR semester = ?)
+		ORDER BY id`,
+		until.UTC(),
+		semester, semester,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AllocationEvent{}
+	for rows.Next() {
+		var event models.AllocationEvent
+		var weekday, start, end sql.NullInt64
+		if err := rows.Scan(
+			&event.ID,
+			&event.OccurredAt,
+			&event.Type,
+			&event.Semester,
+			&event.Faculty,
+			&event.Program,
+			&event.RoomID,
+			&event.Room,
+			&event.Adapted,
+			&weekday,
+			&start,
+			&end,
+		); err != nil {
+			return nil, err
+		}
+
+		event.Weekday = int(weekday.Int64)
+		event.StartHour = int(start.Int64)
+		event.EndHour = int(end.Int64)
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// insertEvents appends the events of a change to allocation_events.
+func (s *SqliteAllocationService) insertEvents(ctx context.Context, tx *sql.Tx, events []models.AllocationEvent) error {
+	for _, event := range events {
+		// Rooms held the whole semester have no weekday nor hours
+		var weekday, start, end sql.NullInt64
+		if event.Weekday != 0 {
+			weekday = sql.NullInt64{Int64: int64(event.Weekday), Valid: true}
+			start = sql.NullInt64{Int64: int64(event.StartHour), Valid: true}
+			end = sql.NullInt64{Int64: int64(event.EndHour), Valid: true}
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO allocation_events (occurred_at, type, semester, faculty, program, room_id, room, adapted, weekday, start_hour, end_hour) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			event.OccurredAt.UTC(),
+			event.Type,
+			event.Semester,
+			event.Faculty,
+			event.Program,
+			event.RoomID,
+			event.Room,
+			event.Adapted,
+			weekday,
+			start,
+			end,
+		); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// FileEventService reads the allocation events the memory storage appended
+// to a file (see WithEventLog), one JSON object per line.
+type FileEventService struct {
+	path string
+}
+
+func NewFileEventService(path string) *FileEventService {
+	return &FileEventService{
+		path: path,
+	}
+}
+
+func (s *FileEventService) Events(ctx context.Context, until time.Time, semester string) ([]models.AllocationEvent, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	// Events are appended in order, so the first one past until ends the read
+	events := []models.AllocationEvent{}
+	decoder := json.NewDecoder(bufio.NewReader(file))
+	for decoder.More() {
+		var event models.AllocationEvent
+		if err := decoder.Decode(&event); err != nil {
+			return nil, err
+		}
+
+		if event.OccurredAt.After(until) {
+			break
+		}
+
+		if semester == "" || event.Semester == semester {
+			events = append(events, event)
+		}
+	}
+
+	return events, nil
+}
+
+// allocationEvents lists the changes from before to after as the events the
+// allocation_events triggers record in Postgres: new rooms are offered, rooms
+// changing hands are swapped, rooms changing state are confirmed or unlocked,
+// and the rest are released for the reason after was given. Releases go first,
+// as a released room may be offered again in the same change.
+func allocationEvents(semester string, before *memorySemester, after *memorySemester, now time.Time) []models.AllocationEvent {
+	events := []models.AllocationEvent{}
+	reason := cmp.Or(after.reason, "released")
+
+	// 1. Index both sides by their own ID, relocated rooms are new ones
+	allocations := make(map[int]*memoryAllocation, len(after.allocations))
+	for _, allocation := range after.allocations {
+		allocations[allocation.id] = allocation
+	}
+
+	bookings := make(map[int]*memoryBooking, len(after.bookings))
+	for _, booking := range after.bookings {
+		bookings[booking.id] = booking
+	}
+
+	previous := make(map[int]*memoryAllocation, len(before.allocations))
+	for _, allocation := range before.sorted() {
+		previous[allocation.id] = allocation
+		if _, kept := allocations[allocation.id]; !kept {
+			events = append(events, allocation.event(reason, semester, now))
+		}
+	}
+
+	blocks := make(map[int]*memoryBooking, len(before.bookings))
+	for _, booking := range before.sortedBookings() {
+		blocks[booking.id] = booking
+		if _, kept := bookings[booking.id]; !kept {
+			events = append(events, booking.event(reason, semester, now))
+		}
+	}
+
+	// 2. Then the rooms offered or changed
+	for _, allocation := range after.sorted() {
+		old, existed := previous[allocation.id]
+
+		var kind string
+		switch {
+		case !existed:
+			kind = "offered"
+		case old.faculty != allocation.faculty || old.program != allocation.program:
+			kind = "swapped"
+		case old.locked != allocation.locked:
+			kind = lockEvent(allocation.locked)
+		}
+
+		if kind != "" {
+			events = append(events, allocation.event(kind, semester, now))
+		}
+	}
+
+	for _, booking := range after.sortedBookings() {
+		old, existed := blocks[booking.id]
+
+		var kind string
+		switch {
+		case !existed:
+			kind = "offered"
+		case old.faculty != booking.faculty || old.program != booking.program:
+			kind = "swapped"
+		case old.locked != booking.locked:
+			kind = lockEvent(booking.locked)
+		}
+
+		if kind != "" {
+			events = append(events, booking.event(kind, semester, now))
+		}
+	}
+
+	return events
+}
+
+// lockEvent names the event of a room changing state.
+func lockEvent(locked bool) string {
+	if locked {
+		return "confirmed"
+	}
+
+	return "unlocked"
+}
+
+func (a *memoryAllocation) event(kind string, semester string, now time.Time) models.AllocationEvent {
+	return models.AllocationEvent{
+		OccurredAt: now,
+		Type:       kind,
+		Semester:   semester,
+		Faculty:    a.faculty,
+		Program:    a.program,
+		RoomID:     a.room.ID,
+		Room:       a.room.Name,
+		Adapted:    a.adapted,
+	}
+}
+
+func (b *memoryBooking) event(kind string, semester string, now time.Time) models.AllocationEvent {
+	return models.AllocationEvent{
+		OccurredAt: now,
+		Type:       kind,
+		Semester:   semester,
+		Faculty:    b.faculty,
+		Program:    b.program,
+		RoomID:     b.room.ID,
+		Room:       b.room.Name,
+		Adapted:    b.adapted,
+		Weekday:    b.weekday,
+		StartHour:  b.start,
+		EndHour:    b.start + blockHours,
+	}
+}
+
+// snapshot copies the semesters before a change made in place, to record its
+// allocation events, or returns nil when there is no event log. Must be called
+// with the mutex held.
+func (s *MemoryAllocationService) snapshot() map[string]*memorySemester {
+	if s.eventLog == nil {
+		return nil
+	}
+
+	snapshot := make(map[string]*memorySemester, len(s.semesters))
+	for semester, state := range s.semesters {
+		snapshot[semester] = state.clone()
+	}
+
+	return snapshot
+}
+
+// recordEvents appends the changes from before to after to the event log,
+// one JSON object per line. Must be called with the mutex held.
+func (s *MemoryAllocationService) recordEvents(before map[string]*memorySemester, after map[string]*memorySemester) error {
+	if s.eventLog == nil {
+		return nil
+	}
+
+	now := time.Now()
+	encoder := json.NewEncoder(s.eventLog)
+	for _, semester := range slices.Sorted(maps.Keys(after)) {
+		previous, ok := before[semester]
+		if !ok {
+			previous = newMemorySemester()
+		}
+
+		for _, event := range allocationEvents(semester, previous, after[semester], now) {
+			s.events++
+			event.ID = s.events
+			if err := encoder.Encode(event); err != nil {
+				return err
+			}
+		}
+
+		// The reason only holds for the change just recorded
+		after[semester].reason = ""
+	}
+
+	return nil
+}
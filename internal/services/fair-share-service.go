@@ -0,0 +1,287 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/rs/zerolog/log"
+)
+
+type admissionResult struct {
+	response *models.AllocateResponse
+	err      error
+}
+
+type admissionEntry struct {
+	ctx     context.Context
+	request *models.AllocateRequest
+	result  chan admissionResult
+}
+
+// FairShareAllocationService wraps another AllocationService so that the
+// allocate requests of a semester are collected during an admission window
+// and then served together. When demand exceeds supply every faculty gets a
+// share proportional to its demand times its weight, instead of the first
+// faculty to connect taking everything. Requests that are not partial fail
+// when their share falls short of what they asked for.
+//
+// Requests wait for the window to close, so the server should answer them
+// outside its worker pool (see handler.WithDeferredRoutes).
+type FairShareAllocationService struct {
+	AllocationService
+
+	window    time.Duration
+	weights   map[string]float64
+	semesters map[string]bool
+
+	mutex   sync.Mutex
+	pending map[string][]*admissionEntry
+}
+
+// NewFairShareAllocationService enables fair-share for the given semesters, or
+// for every semester when none are given. Faculties without a weight weigh 1.
+func NewFairShareAllocationService(inner AllocationService, window time.Duration, weights map[string]float64, semesters []string) *FairShareAllocationService {
+	service := &FairShareAllocationService{
+		AllocationService: inner,
+		window:            window,
+		weights:           weights,
+		semesters:         make(map[string]bool),
+		pending:           make(map[string][]*admissionEntry),
+	}
+
+	for _, semester := range semesters {
+		service.semesters[semester] = true
+	}
+
+	return service
+}
+
+func (s *FairShareAllocationService) Allocate(ctx context.Context, request *models.AllocateRequest) (*models.AllocateResponse, error) {
+	// 1. Semesters outside fair-share mode are served right away
+	if len(s.semesters) > 0 && !s.semesters[request.Semester] {
+		return s.AllocationService.Allocate(ctx, request)
+	}
+
+	entry := &admissionEntry{
+		ctx:     ctx,
+		request: request,
+		result:  make(chan admissionResult, 1),
+	}
+
+	// 2. Join the semester's admission window, opening it if needed
+	s.mutex.Lock()
+	if _, open := s.pending[request.Semester]; !open {
+		log.Info().Msgf("Opening admission window for semester %q (%s)", request.Semester, s.window)
+		time.AfterFunc(s.window, func() { s.admit(request.Semester) })
+	}
+	s.pending[request.Semester] = append(s.pending[request.Semester], entry)
+	s.mutex.Unlock()
+
+	// 3. Wait for the window to close
+	result := <-entry.result
+	if result.err != nil {
+		return nil, result.err
+	}
+
+	return s.reportMissing(request, result.response), nil
+}
+
+// admit closes the admission window of a semester and allocates every request
+// collected in it.
+func (s *FairShareAllocationService) admit(semester string) {
+	s.mutex.Lock()
+	entries := s.pending[semester]
+	delete(s.pending, semester)
+	s.mutex.Unlock()
+
+	log.Info().Msgf("Closing admission window for semester %q with %d requests", semester, len(entries))
+
+	// 1. Find out how many rooms are left for the semester
+	available, err := s.AllocationService.Availability(context.Background(), semester)
+	if err != nil {
+		for _, entry := range entries {
+			entry.result <- admissionResult{err: err}
+		}
+		return
+	}
+
+	// 2. Scale every request down to its fair share. Strict requests that fall
+	// short fail, and the shares are worked out again without them
+	requests := s.shareRequests(entries, available)
+	for {
+		admitted := []*admissionEntry{}
+		for i, entry := range entries {
+			if !entry.request.Partial && shortOfDemand(entry.request, requests[i]) {
+				entry.result <- admissionResult{err: fmt.Errorf("the fair share of faculty %q in semester %q does not cover its request (ask for a partial allocation to take it)", entry.request.Faculty, semester)}
+				continue
+			}
+
+			admitted = append(admitted, entry)
+		}
+
+		if len(admitted) == len(entries) {
+			break
+		}
+
+		entries = admitted
+		requests = s.shareRequests(entries, available)
+	}
+
+	// 3. Allocate every share
+	for i, request := range requests {
+		response, err := s.AllocationService.Allocate(entries[i].ctx, request)
+		entries[i].result <- admissionResult{response: response, err: err}
+	}
+}
+
+// shortOfDemand tells whether a shared request asks for less than the
+// original one.
+func shortOfDemand(original *models.AllocateRequest, shared *models.AllocateRequest) bool {
+	for i, program := range original.Programs {
+		if shared.Programs[i].Classrooms < program.Classrooms || shared.Programs[i].Laboratories < program.Laboratories {
+			return true
+		}
+	}
+
+	return false
+}
+
+// shareRequests returns, for every entry, a request asking only for the
+// entry's share of the available rooms.
+func (s *FairShareAllocationService) shareRequests(entries []*admissionEntry, available *models.Availability) []*models.AllocateRequest {
+	// 1. Add up the demand of every faculty
+	weights := make([]float64, len(entries))
+	classrooms := make([]int, len(entries))
+	laboratories := make([]int, len(entries))
+	for i, entry := range entries {
+		weights[i] = s.weight(entry.request.Faculty)
+		for _, program := range entry.request.Programs {
+			classrooms[i] += program.Classrooms
+			laboratories[i] += program.Laboratories
+		}
+	}
+
+	// 2. Share the classrooms, then the laboratories (which may fall back to
+	// the classrooms nobody was granted)
+	classroomGrants := shareRooms(classrooms, weights, available.Classrooms)
+	leftover := available.Classrooms
+	for _, granted := range classroomGrants {
+		leftover -= granted
+	}
+	laboratoryGrants := shareRooms(laboratories, weights, available.Laboratories+leftover)
+
+	// 3. Split each faculty's grant among its programs
+	requests := make([]*models.AllocateRequest, len(entries))
+	for i, entry := range entries {
+		request := *entry.request
+		request.Programs = make([]models.ProgramInfo, len(entry.request.Programs))
+
+		programClassrooms := make([]int, len(request.Programs))
+		programLaboratories := make([]int, len(request.Programs))
+		programWeights := make([]float64, len(request.Programs))
+		for j, program := range entry.request.Programs {
+			programClassrooms[j] = program.Classrooms
+			programLaboratories[j] = program.Laboratories
+			programWeights[j] = 1
+		}
+
+		programClassrooms = shareRooms(programClassrooms, programWeights, classroomGrants[i])
+		programLaboratories = shareRooms(programLaboratories, programWeights, laboratoryGrants[i])
+		for j, program := range entry.request.Programs {
+			program.Classrooms = programClassrooms[j]
+			program.Laboratories = programLaboratories[j]
+			request.Programs[j] = program
+		}
+
+		requests[i] = &request
+	}
+
+	return requests
+}
+
+// reportMissing fills in what each program is missing compared to what the
+// faculty originally asked for, rather than to its scaled-down share.
+func (s *FairShareAllocationService) reportMissing(request *models.AllocateRequest, response *models.AllocateResponse) *models.AllocateResponse {
+	response.Partial = false
+	for _, program := range request.Programs {
+		for i := range response.Programs {
+			allocation := &response.Programs[i]
+			if allocation.Name != program.Name {
+				continue
+			}
+
+			allocation.MissingClassrooms = max(0, program.Classrooms-len(allocation.Classrooms))
+			allocation.MissingLaboratories = max(0, program.Laboratories-len(allocation.Laboratories)-len(allocation.Adapted))
+			if allocation.MissingClassrooms > 0 || allocation.MissingLaboratories > 0 {
+				response.Partial = true
+			}
+		}
+	}
+
+	return response
+}
+
+func (s *FairShareAllocationService) weight(faculty string) float64 {
+	if weight, ok := s.weights[faculty]; ok && weight > 0 {
+		return weight
+	}
+
+	return 1
+}
+
+// shareRooms splits supply among demands in proportion to weight times
+// demand, never granting more than was demanded. Rooms left over by rounding
+// go to the largest remainders, ties broken by arrival order.
+func shareRooms(demands []int, weights []float64, supply int) []int {
+	grants := make([]int, len(demands))
+
+	remaining := supply
+	for remaining > 0 {
+		// 1. Add up the claims of those still missing rooms
+		total := 0.0
+		for i, demand := range demands {
+			if grants[i] < demand {
+				total += weights[i] * float64(demand)
+			}
+		}
+		if total == 0 {
+			break
+		}
+
+		// 2. Hand out the whole part of every proportional share
+		given := 0
+		best, bestRemainder := -1, -1.0
+		for i, demand := range demands {
+			if grants[i] >= demand {
+				continue
+			}
+
+			exact := float64(remaining) * weights[i] * float64(demand) / total
+			whole := min(int(math.Floor(exact)), demand-grants[i])
+			grants[i] += whole
+			given += whole
+
+			if remainder := exact - math.Floor(exact); grants[i] < demand && remainder > bestRemainder {
+				best, bestRemainder = i, remainder
+			}
+		}
+
+		// 3. When only fractions are left, give a single room to the largest one
+		if given == 0 {
+			if best < 0 {
+				break
+			}
+
+			grants[best]++
+			given = 1
+		}
+
+		remaining -= given
+	}
+
+	return grants
+}
@@ -0,0 +1,2558 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+)
+
+type memoryAllocation struct {
+	id        int
+	room      models.Room
+	faculty   string
+	program   string
+	adapted   bool
+	locked    bool
+	offeredAt time.Time
+
+	// The holder was told no equivalent room was free to relocate it to
+	unplaced bool
+}
+
+// memoryBlock identifies a weekly time block of a room. Blocks all last
+// blockHours and start at the same hours, so two of them overlap only when
+// they are the same.
+type memoryBlock struct {
+	room    int
+	weekday int
+	start   int
+}
+
+type memoryBooking struct {
+	id        int
+	room      models.Room
+	faculty   string
+	program   string
+	weekday   int
+	start     int
+	adapted   bool
+	locked    bool
+	offeredAt time.Time
+
+	// The holder was told no equivalent room was free to relocate it to
+	unplaced bool
+}
+
+// block returns the time block the booking holds.
+func (b *memoryBooking) block() memoryBlock {
+	return memoryBlock{room: b.room.ID, weekday: b.weekday, start: b.start}
+}
+
+type memoryWaitlistEntry struct {
+	id           int
+	faculty      string
+	program      string
+	classrooms   int
+	laboratories int
+	minCapacity  int
+	equipment    string
+	building     string
+	createdAt    time.Time
+}
+
+type memorySwap struct {
+	id        int
+	proposer  string
+	recipient string
+	program   string
+	state     string
+	offered   []models.Room
+	requested []models.Room
+	createdAt time.Time
+}
+
+// memorySemester holds what the semesters, faculty_quotas, room_allocations,
+// room_bookings, waitlist, swaps and room_blocks tables hold for a single semester. The state is empty until the
+// semester is created.
+type memorySemester struct {
+	state       string
+	quotas      map[string]roomCount
+	allocations map[int]*memoryAllocation
+	bookings    map[memoryBlock]*memoryBooking
+	waitlist    []*memoryWaitlistEntry
+	swaps       map[int]*memorySwap
+	blocked     map[int]string
+
+	// Why the change being made releases rooms, for its allocation events
+	// (see allocationEvents). Copies start without one
+	reason string
+}
+
+func newMemorySemester() *memorySemester {
+	return &memorySemester{
+		quotas:      make(map[string]roomCount),
+		allocations: make(map[int]*memoryAllocation),
+		bookings:    make(map[memoryBlock]*memoryBooking),
+		waitlist:    []*memoryWaitlistEntry{},
+		swaps:       make(map[int]*memorySwap),
+		blocked:     make(map[int]string),
+	}
+}
+
+func (m *memorySemester) clone() *memorySemester {
+	clone := newMemorySemester()
+	clone.state = m.state
+	for faculty, quota := range m.quotas {
+		clone.quotas[faculty] = quota
+	}
+
+	for id, allocation := range m.allocations {
+		copied := *allocation
+		clone.allocations[id] = &copied
+	}
+
+	for block, booking := range m.bookings {
+		copied := *booking
+		clone.bookings[block] = &copied
+	}
+
+	for _, entry := range m.waitlist {
+		copied := *entry
+		clone.waitlist = append(clone.waitlist, &copied)
+	}
+
+	for id, swap := range m.swaps {
+		copied := *swap
+		clone.swaps[id] = &copied
+	}
+
+	for room, reason := range m.blocked {
+		clone.blocked[room] = reason
+	}
+
+	return clone
+}
+
+// transition moves the semester to the given state, failing if it does not
+// exist or its current state does not allow it.
+func (m *memorySemester) transition(semester string, action string, to string) error {
+	if m.state == "" {
+		return fmt.Errorf("semester %q does not exist", semester)
+	}
+
+	if !slices.Contains(semesterTransitions[to], m.state) {
+		return fmt.Errorf("semester %q is %s, cannot %s it", semester, m.state, action)
+	}
+
+	m.state = to
+	return nil
+}
+
+// usage counts the rooms a faculty holds or waits for, each time block as a
+// room. Labs include adapted classrooms.
+func (m *memorySemester) usage(faculty string) roomCount {
+	used := roomCount{}
+	for _, allocation := range m.allocations {
+		if allocation.faculty != faculty {
+			continue
+		}
+
+		if allocation.room.Laboratory || allocation.adapted {
+			used.laboratories++
+		} else {
+			used.classrooms++
+		}
+	}
+
+	for _, booking := range m.bookings {
+		if booking.faculty != faculty {
+			continue
+		}
+
+		if booking.room.Laboratory || booking.adapted {
+			used.laboratories++
+		} else {
+			used.classrooms++
+		}
+	}
+
+	for _, entry := range m.waitlist {
+		if entry.faculty == faculty {
+			used.classrooms += entry.classrooms
+			used.laboratories += entry.laboratories
+		}
+	}
+
+	return used
+}
+
+// sorted returns the allocations in the order they were made.
+func (m *memorySemester) sorted() []*memoryAllocation {
+	allocations := make([]*memoryAllocation, 0, len(m.allocations))
+	for _, allocation := range m.allocations {
+		allocations = append(allocations, allocation)
+	}
+
+	slices.SortFunc(allocations, func(a, b *memoryAllocation) int {
+		return a.id - b.id
+	})
+
+	return allocations
+}
+
+// sortedBookings returns the bookings in the order they were made.
+func (m *memorySemester) sortedBookings() []*memoryBooking {
+	bookings := make([]*memoryBooking, 0, len(m.bookings))
+	for _, booking := range m.bookings {
+		bookings = append(bookings, booking)
+	}
+
+	slices.SortFunc(bookings, func(a, b *memoryBooking) int {
+		return a.id - b.id
+	})
+
+	return bookings
+}
+
+// booked tells whether the room has time blocks in the semester.
+func (m *memorySemester) booked(room int) bool {
+	for block := range m.bookings {
+		if block.room == room {
+			return true
+		}
+	}
+
+	return false
+}
+
+// facultyRooms lists the rooms a faculty holds, by program and in the order
+// they were allocated.
+func (m *memorySemester) facultyRooms(faculty string, confirmed bool) []facultyRoom {
+	rooms := []facultyRoom{}
+	for _, allocation := range m.sorted() {
+		if allocation.faculty != faculty || (confirmed && !allocation.locked) {
+			continue
+		}
+
+		rooms = append(rooms, facultyRoom{
+			id:         allocation.room.ID,
+			name:       allocation.room.Name,
+			program:    allocation.program,
+			laboratory: allocation.room.Laboratory,
+			adapted:    allocation.adapted,
+		})
+	}
+
+	slices.SortStableFunc(rooms, func(a, b facultyRoom) int {
+		return strings.Compare(a.program, b.program)
+	})
+
+	return rooms
+}
+
+// model returns the swap as sent to the clients.
+func (m *memorySwap) model(semester string) *models.Swap {
+	swap := &models.Swap{
+		ID:        m.id,
+		Semester:  semester,
+		Proposer:  m.proposer,
+		Recipient: m.recipient,
+		Program:   m.program,
+		State:     m.state,
+		CreatedAt: m.createdAt,
+		Offered:   []string{},
+		Requested: []string{},
+	}
+
+	for _, room := range m.offered {
+		swap.Offered = append(swap.Offered, room.Name)
+	}
+
+	for _, room := range m.requested {
+		swap.Requested = append(swap.Requested, room.Name)
+	}
+
+	return swap
+}
+
+type memoryProcessedRequest struct {
+	kind      string
+	response  []byte
+	createdAt time.Time
+}
+
+// MemoryAllocationService keeps rooms and allocations in memory, with the same
+// rules as SqlcAllocationService: a room is held once per semester, labs fall
+// back to adapted classrooms, offers await confirmation and retried requests
+// are answered with their first response. A single mutex serializes every
+// request, and each one works on a copy of its semester that is only kept if
+// it succeeds, so a failed request changes nothing.
+type MemoryAllocationService struct {
+	allocationSettings
+
+	mutex     sync.Mutex
+	rooms     []models.Room
+	faculties map[string][]string
+	semesters map[string]*memorySemester
+	outages   map[int]models.RoomOutage
+	sequence  int
+	processed map[RequestKey]memoryProcessedRequest
+	events    int64
+}
+
+func NewMemoryAllocationService(rooms []models.Room, options ...AllocationOptions) *MemoryAllocationService {
+	service := &MemoryAllocationService{
+		allocationSettings: defaultAllocationSettings(),
+		rooms:              slices.Clone(rooms),
+		faculties:          make(map[string][]string),
+		semesters:          make(map[string]*memorySemester),
+		outages:            make(map[int]models.RoomOutage),
+		processed:          make(map[RequestKey]memoryProcessedRequest),
+	}
+
+	for _, applyOption := range options {
+		applyOption(&service.allocationSettings)
+	}
+
+	slices.SortFunc(service.rooms, func(a, b models.Room) int {
+		return a.ID - b.ID
+	})
+
+	return service
+}
+
+// GenerateRooms builds the same rooms as the generate_rooms database function.
+func GenerateRooms(classrooms int, laboratories int) []models.Room {
+	buildings := []string{"A", "B", "C", "D"}
+	equipment := []string{"computers", "chemistry", "electronics", "biology"}
+
+	rooms := make([]models.Room, 0, classrooms+laboratories)
+	for i := 1; i <= classrooms; i++ {
+		rooms = append(rooms, models.Room{
+			ID:        len(rooms) + 1,
+			Name:      fmt.Sprintf("AUL-%03d", i),
+			Capacity:  20 + (i*7)%41,
+			Building:  buildings[i%4],
+			Floor:     1 + (i/4)%5,
+			Equipment: []string{},
+		})
+	}
+
+	for i := 1; i <= laboratories; i++ {
+		rooms = append(rooms, models.Room{
+			ID:         len(rooms) + 1,
+			Name:       fmt.Sprintf("LAB-%03d", i),
+			Laboratory: true,
+			Capacity:   15 + (i*5)%26,
+			Building:   buildings[i%4],
+			Floor:      1 + (i/4)%5,
+			Equipment:  []string{equipment[i%4]},
+		})
+	}
+
+	return rooms
+}
+
+func (s *MemoryAllocationService) Allocate(ctx context.Context, request *models.AllocateRequest) (*models.AllocateResponse, error) {
+	response := &models.AllocateResponse{}
+	if _, err := s.transaction(ctx, "allocate", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. Only open semesters take allocations
+		if err := checkSemesterOpen(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		// 0.1 Only registered programs, within the faculty's quota
+		if err := s.checkDemand(state, request.Semester, request.Faculty, request.Programs); err != nil {
+			return nil, err
+		}
+
+		// 1. Allocate rooms (best-effort when the client asked for a partial grant or to wait)
+		shortfall, err := s.allocatePrograms(state, request, !request.Partial && !request.Waitlist)
+		if err != nil {
+			return nil, err
+		}
+
+		// 1.1 Queue the unmet demand until rooms are released
+		if request.Waitlist && len(shortfall) > 0 {
+			for _, missing := range shortfall {
+				program := programInfo(request.Programs, missing.Name)
+
+				s.sequence++
+				state.waitlist = append(state.waitlist, &memoryWaitlistEntry{
+					id:           s.sequence,
+					faculty:      request.Faculty,
+					program:      missing.Name,
+					classrooms:   max(missing.Classrooms, 0),
+					laboratories: max(missing.Laboratories, 0),
+					minCapacity:  program.MinCapacity,
+					equipment:    program.Equipment,
+					building:     program.Building,
+					createdAt:    time.Now(),
+				})
+			}
+
+			response.Waitlisted = true
+		}
+
+		// 2. Get the allocated rooms
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Deadline = time.Now().Add(s.hold)
+		response.Partial = len(shortfall) > 0
+		response.Programs = applyShortfall(s.programAllocations(state, request), shortfall)
+
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *MemoryAllocationService) Quote(ctx context.Context, request *models.AllocateRequest) (*models.QuoteResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 1. Work on a copy that is thrown away, nothing is reserved
+	state := s.semester(request.Semester).clone()
+	sequence := s.sequence
+	defer func() { s.sequence = sequence }()
+
+	// 1.1 Only open semesters take allocations
+	if err := checkSemesterOpen(request.Semester, state.state); err != nil {
+		return nil, err
+	}
+
+	// 1.2 Only registered programs, within the faculty's quota
+	if err := s.checkDemand(state, request.Semester, request.Faculty, request.Programs); err != nil {
+		return nil, err
+	}
+
+	// 2. Run the allocation without failing on shortfalls
+	shortfall, err := s.allocatePrograms(state, request, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Generate response
+	response := &models.QuoteResponse{
+		Semester:    request.Semester,
+		Faculty:     request.Faculty,
+		Satisfiable: len(shortfall) == 0,
+		Programs:    applyShortfall(s.programAllocations(state, request), shortfall),
+		Shortfall:   shortfall,
+	}
+
+	return response, nil
+}
+
+func (s *MemoryAllocationService) Confirm(ctx context.Context, request *models.ConfirmRequest) (*models.ConfirmResponse, error) {
+	response := &models.ConfirmResponse{}
+	notifications, err := s.transaction(ctx, "confirm", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Accepted = request.Accept
+
+		// 0. Only open semesters take confirmations
+		if err := checkSemesterOpen(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		if err := s.checkRegistry(request.Faculty, nil); err != nil {
+			return nil, err
+		}
+
+		// 1. Release the offered rooms if the client declined
+		if !request.Accept {
+			// 1.1 A faculty that declines gives up its place in the waitlist too
+			state.waitlist = slices.DeleteFunc(state.waitlist, func(entry *memoryWaitlistEntry) bool {
+				return entry.faculty == request.Faculty
+			})
+
+			state.reason = "declined"
+			released := s.release(state, func(allocation *memoryAllocation) bool {
+				return allocation.faculty == request.Faculty && !allocation.locked
+			})
+
+			// 1.2 Release the offered time blocks as well
+			released = append(released, s.releaseBookings(state, func(booking *memoryBooking) bool {
+				return booking.faculty == request.Faculty && !booking.locked
+			})...)
+
+			// 1.3 Offer the released rooms to the waitlist
+			var notifications []*models.Notification
+			if len(released) > 0 {
+				notifications = s.processWaitlist(state, request.Semester)
+			}
+
+			response.Released = released
+			return notifications, nil
+		}
+
+		// 2. Confirm the offers still within the hold period
+		locked := 0
+		deadline := time.Now().Add(-s.hold)
+		for _, allocation := range state.allocations {
+			if allocation.faculty == request.Faculty && !allocation.locked && allocation.offeredAt.After(deadline) {
+				allocation.locked = true
+				locked++
+			}
+		}
+
+		for _, booking := range state.bookings {
+			if booking.faculty == request.Faculty && !booking.locked && booking.offeredAt.After(deadline) {
+				booking.locked = true
+				locked++
+			}
+		}
+
+		// 3. Nothing to lock means the offer expired (or never existed)
+		if locked == 0 {
+			return nil, fmt.Errorf("no rooms offered to %q for semester %q (the offer may have expired)", request.Faculty, request.Semester)
+		}
+
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *MemoryAllocationService) Cancel(ctx context.Context, request *models.CancelRequest) (*models.CancelResponse, error) {
+	response := &models.CancelResponse{}
+	notifications, err := s.transaction(ctx, "cancel", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. Only registered faculties and programs
+		programs := []string{}
+		if request.Program != "" {
+			programs = append(programs, request.Program)
+		}
+
+		if err := s.checkRegistry(request.Faculty, programs); err != nil {
+			return nil, err
+		}
+
+		// 0.1 Archived semesters are kept as they ended
+		if err := checkSemesterAdmin(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		// 1. Release the confirmed rooms
+		state.reason = "cancelled"
+		released := s.release(state, func(allocation *memoryAllocation) bool {
+			return allocation.faculty == request.Faculty && allocation.locked &&
+				(request.Program == "" || allocation.program == request.Program)
+		})
+
+		// 1.1 Release the confirmed time blocks as well
+		released = append(released, s.releaseBookings(state, func(booking *memoryBooking) bool {
+			return booking.faculty == request.Faculty && booking.locked &&
+				(request.Program == "" || booking.program == request.Program)
+		})...)
+
+		if len(released) == 0 {
+			return nil, fmt.Errorf("no confirmed rooms to cancel for %q in semester %q", request.Faculty, request.Semester)
+		}
+
+		// 2. Generate response
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Program = request.Program
+		response.Released = released
+
+		// 3. Offer the released rooms to the waitlist
+		return s.processWaitlist(state, request.Semester), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *MemoryAllocationService) Modify(ctx context.Context, request *models.ModifyRequest) (*models.ModifyResponse, error) {
+	response := &models.ModifyResponse{}
+	notifications, err := s.transaction(ctx, "modify", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		released := []string{}
+
+		// 0. Only open semesters take allocations
+		if err := checkSemesterOpen(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		// 0.1 Only registered programs, within the faculty's quota
+		if err := s.checkDemand(state, request.Semester, request.Faculty, request.Programs); err != nil {
+			return nil, err
+		}
+
+		// 0.2 Only confirmed allocations can be modified
+		for _, program := range request.Programs {
+			locked := 0
+			for _, allocation := range state.allocations {
+				if allocation.faculty == request.Faculty && allocation.program == program.Name && allocation.locked {
+					locked++
+				}
+			}
+
+			if err := checkModifiable(request, program.Name, locked); err != nil {
+				return nil, err
+			}
+		}
+
+		// 1. Give back rooms first, so growing programs can use them
+		state.reason = "modified"
+		for _, program := range request.Programs {
+			if program.Classrooms < 0 {
+				rooms, err := s.shrink(state, request.Faculty, program.Name, false, -program.Classrooms)
+				if err != nil {
+					return nil, err
+				}
+
+				released = append(released, rooms...)
+			}
+
+			if program.Laboratories < 0 {
+				rooms, err := s.shrink(state, request.Faculty, program.Name, true, -program.Laboratories)
+				if err != nil {
+					return nil, err
+				}
+
+				released = append(released, rooms...)
+			}
+		}
+
+		// 2. Allocate the extra rooms, keeping the ones already held
+		grow := &models.AllocateRequest{
+			Semester: request.Semester,
+			Faculty:  request.Faculty,
+		}
+
+		for _, program := range request.Programs {
+			program.Classrooms = max(program.Classrooms, 0)
+			program.Laboratories = max(program.Laboratories, 0)
+			grow.Programs = append(grow.Programs, program)
+		}
+
+		offered := s.sequence
+		if _, err := s.allocatePrograms(state, grow, true); err != nil {
+			return nil, err
+		}
+
+		// 2.1 The allocation was already confirmed, so the new rooms are too
+		// (only the ones just offered)
+		for _, allocation := range state.allocations {
+			if allocation.id > offered {
+				allocation.locked = true
+			}
+		}
+
+		// 3. Offer what was given back to the waitlist
+		var notifications []*models.Notification
+		if len(released) > 0 {
+			notifications = s.processWaitlist(state, request.Semester)
+		}
+
+		// 4. Generate response
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Released = released
+		response.Programs = s.programAllocations(state, grow)
+
+		return notifications, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *MemoryAllocationService) Availability(ctx context.Context, semester string) (*models.Availability, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 1. Count the rooms nobody holds for the semester
+	availability := &models.Availability{Semester: semester}
+	for _, room := range s.freeRooms(s.semester(semester)) {
+		if room.Laboratory {
+			availability.Laboratories++
+		} else {
+			availability.Classrooms++
+		}
+	}
+
+	return availability, nil
+}
+
+func (s *MemoryAllocationService) ForgetRequests(ctx context.Context) (int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 1. Responses past the replay window are never replayed again
+	deadline := time.Now().Add(-s.replayWindow)
+
+	forgotten := int64(0)
+	for key, stored := range s.processed {
+		if stored.createdAt.After(deadline) {
+			continue
+		}
+
+		delete(s.processed, key)
+		forgotten++
+	}
+
+	return forgotten, nil
+}
+
+func (s *MemoryAllocationService) ExpireOffers(ctx context.Context) ([]models.ReleasedRoom, error) {
+	s.mutex.Lock()
+	before := s.snapshot()
+
+	// 1. Delete the offers older than the hold period, semester by semester
+	released := []models.ReleasedRoom{}
+	notifications := []*models.Notification{}
+	deadline := time.Now().Add(-s.hold)
+
+	semesters := make([]string, 0, len(s.semesters))
+	for semester := range s.semesters {
+		semesters = append(semesters, semester)
+	}
+	slices.Sort(semesters)
+
+	for _, semester := range semesters {
+		state := s.semesters[semester]
+		state.reason = "expired"
+
+		// 1.1 Collect the released rooms, telling each faculty which offers expired
+		expired := map[string]*models.Notification{}
+		for _, allocation := range state.sorted() {
+			if allocation.locked || !allocation.offeredAt.Before(deadline) {
+				continue
+			}
+
+			delete(state.allocations, allocation.room.ID)
+			released = append(released, models.ReleasedRoom{
+				Semester: semester,
+				Faculty:  allocation.faculty,
+				Program:  allocation.program,
+				Room:     allocation.room.Name,
+			})
+
+			if _, ok := expired[allocation.faculty]; !ok {
+				expired[allocation.faculty] = &models.Notification{
+					Type:     "offer-expired",
+					Semester: semester,
+					Faculty:  allocation.faculty,
+				}
+				notifications = append(notifications, expired[allocation.faculty])
+			}
+			expired[allocation.faculty].Rooms = append(expired[allocation.faculty].Rooms, allocation.room.Name)
+		}
+
+		// 1.2 Time block offers expire the same way, each room once per program
+		expiredBookings := s.releaseBookingOffers(state, semester, func(booking *memoryBooking) bool {
+			return booking.offeredAt.Before(deadline)
+		})
+
+		for _, room := range expiredBookings {
+			released = append(released, room)
+
+			if _, ok := expired[room.Faculty]; !ok {
+				expired[room.Faculty] = &models.Notification{
+					Type:     "offer-expired",
+					Semester: semester,
+					Faculty:  room.Faculty,
+				}
+				notifications = append(notifications, expired[room.Faculty])
+			}
+			expired[room.Faculty].Rooms = append(expired[room.Faculty].Rooms, room.Room)
+		}
+
+		// 1.3 Offer the released rooms to the waitlist
+		if len(expired) > 0 {
+			notifications = append(notifications, s.processWaitlist(state, semester)...)
+		}
+	}
+
+	// 2. Record the allocation events
+	err := s.recordEvents(before, s.semesters)
+	s.mutex.Unlock()
+
+	s.notify(notifications)
+	return released, err
+}
+
+func (s *MemoryAllocationService) WaitlistStatus(ctx context.Context, request *models.WaitlistStatusRequest) (*models.WaitlistStatusResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	response := &models.WaitlistStatusResponse{
+		Semester: request.Semester,
+		Faculty:  request.Faculty,
+		Entries:  []models.WaitlistEntry{},
+	}
+
+	for position, entry := range s.semester(request.Semester).waitlist {
+		if entry.faculty != request.Faculty {
+			continue
+		}
+
+		response.Entries = append(response.Entries, models.WaitlistEntry{
+			Program:      entry.program,
+			Classrooms:   entry.classrooms,
+			Laboratories: entry.laboratories,
+			Position:     position + 1,
+			Since:        entry.createdAt,
+		})
+	}
+
+	return response, nil
+}
+
+func (s *MemoryAllocationService) CreateSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	if strings.TrimSpace(request.Semester) == "" {
+		return nil, fmt.Errorf("semester name is required")
+	}
+
+	response := &models.SemesterResponse{}
+	if _, err := s.transaction(ctx, "semester-create", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		if state.state != "" {
+			return nil, fmt.Errorf("semester %q already exists", request.Semester)
+		}
+
+		state.state = models.SemesterDraft
+		if request.Open {
+			state.state = models.SemesterOpen
+		}
+
+		response.Semester = request.Semester
+		response.State = state.state
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *MemoryAllocationService) OpenSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	return s.transitionSemester(ctx, "semester-open", request, "open", models.SemesterOpen)
+}
+
+// CloseSemester mirrors SqlcAllocationService.CloseSemester: the offers still
+// awaiting confirmation are released and the waitlist is dropped.
+func (s *MemoryAllocationService) CloseSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	response := &models.SemesterResponse{}
+	notifications, err := s.transaction(ctx, "semester-close", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 1. Close the semester
+		if err := state.transition(request.Semester, "close", models.SemesterClosed); err != nil {
+			return nil, err
+		}
+
+		// 2. Release the offers nobody confirmed
+		state.reason = "closed"
+		released := []models.ReleasedRoom{}
+		for _, allocation := range state.sorted() {
+			if allocation.locked {
+				continue
+			}
+
+			delete(state.allocations, allocation.room.ID)
+			released = append(released, models.ReleasedRoom{
+				Semester: request.Semester,
+				Faculty:  allocation.faculty,
+				Program:  allocation.program,
+				Room:     allocation.room.Name,
+			})
+		}
+
+		// 2.1 Time block offers are released the same way
+		released = append(released, s.releaseBookingOffers(state, request.Semester, func(booking *memoryBooking) bool {
+			return true
+		})...)
+
+		// 3. Drop the waitlist
+		state.waitlist = []*memoryWaitlistEntry{}
+
+		// 4. Tell each faculty which offers it lost
+		response.Semester = request.Semester
+		response.State = state.state
+		response.Released = released
+
+		return semesterClosedNotifications(request.Semester, released), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *MemoryAllocationService) ArchiveSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	return s.transitionSemester(ctx, "semester-archive", request, "archive", models.SemesterArchived)
+}
+
+// transitionSemester moves a semester to the given state, with nothing else
+// to do on the way.
+func (s *MemoryAllocationService) transitionSemester(ctx context.Context, kind string, request *models.SemesterRequest, action string, to string) (*models.SemesterResponse, error) {
+	response := &models.SemesterResponse{}
+	if _, err := s.transaction(ctx, kind, request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		if err := state.transition(request.Semester, action, to); err != nil {
+			return nil, err
+		}
+
+		response.Semester = request.Semester
+		response.State = state.state
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// RegisterFaculty registers a faculty and its programs. Names already
+// registered are left alone.
+func (s *MemoryAllocationService) RegisterFaculty(ctx context.Context, request *models.RegisterFacultyRequest) (*models.RegisterFacultyResponse, error) {
+	if err := validateRegistration(request); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 1. Register the faculty and its programs
+	programs := s.faculties[request.Faculty]
+	if programs == nil {
+		programs = []string{}
+	}
+
+	for _, program := range request.Programs {
+		if !slices.Contains(programs, program) {
+			programs = append(programs, program)
+		}
+	}
+
+	slices.Sort(programs)
+	s.faculties[request.Faculty] = programs
+
+	// 2. Answer with every program of the faculty
+	return &models.RegisterFacultyResponse{
+		Faculty:  request.Faculty,
+		Programs: slices.Clone(programs),
+	}, nil
+}
+
+func (s *MemoryAllocationService) SetQuota(ctx context.Context, request *models.FacultyQuota) (*models.FacultyQuota, error) {
+	if request.Classrooms < 0 || request.Laboratories < 0 {
+		return nil, fmt.Errorf("quotas cannot be negative")
+	}
+
+	response := &models.FacultyQuota{}
+	if _, err := s.transaction(ctx, "set-quota", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 1. The semester and the faculty must exist
+		if state.state == "" {
+			return nil, fmt.Errorf("semester %q does not exist", request.Semester)
+		}
+
+		if err := s.checkRegistry(request.Faculty, nil); err != nil {
+			return nil, err
+		}
+
+		// 2. Set the quota
+		state.quotas[request.Faculty] = roomCount{
+			classrooms:   request.Classrooms,
+			laboratories: request.Laboratories,
+		}
+
+		*response = *request
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// AllocateBatch mirrors SqlcAllocationService.AllocateBatch.
+func (s *MemoryAllocationService) AllocateBatch(ctx context.Context, request *models.BatchAllocateRequest) (*models.BatchAllocateResponse, error) {
+	if err := validateBatch(request); err != nil {
+		return nil, err
+	}
+
+	response := &models.BatchAllocateResponse{}
+	if _, err := s.transactionMany(ctx, "allocate-batch", batchSemesters(request), response, func(states map[string]*memorySemester) ([]*models.Notification, error) {
+		response.Responses = []models.AllocateResponse{}
+
+		for i := range request.Requests {
+			allocate := &request.Requests[i]
+			state := states[allocate.Semester]
+
+			// 1. Same checks as a single allocate request
+			if err := checkSemesterOpen(allocate.Semester, state.state); err != nil {
+				return nil, batchError(i, allocate, err)
+			}
+
+			if err := s.checkDemand(state, allocate.Semester, allocate.Faculty, allocate.Programs); err != nil {
+				return nil, batchError(i, allocate, err)
+			}
+
+			// 2. Allocate without failing, so the program that ran short can be named
+			shortfall, err := s.allocatePrograms(state, allocate, false)
+			if err != nil {
+				return nil, batchError(i, allocate, err)
+			}
+
+			if len(shortfall) > 0 {
+				return nil, batchShortfall(i, allocate, shortfall[0])
+			}
+
+			// 3. Get the allocated rooms
+			response.Responses = append(response.Responses, models.AllocateResponse{
+				Semester: allocate.Semester,
+				Faculty:  allocate.Faculty,
+				Deadline: time.Now().Add(s.hold),
+				Programs: s.programAllocations(state, allocate),
+			})
+		}
+
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// Renew mirrors SqlcAllocationService.Renew.
+func (s *MemoryAllocationService) Renew(ctx context.Context, request *models.RenewRequest) (*models.RenewResponse, error) {
+	if err := validateRenewal(request); err != nil {
+		return nil, err
+	}
+
+	response := &models.RenewResponse{}
+	if _, err := s.transactionMany(ctx, "renew", []string{request.Source, request.Target}, response, func(states map[string]*memorySemester) ([]*models.Notification, error) {
+		origin, state := states[request.Source], states[request.Target]
+
+		// 0. The source semester must exist and the target must be open
+		if origin.state == "" {
+			return nil, fmt.Errorf("semester %q does not exist", request.Source)
+		}
+
+		if err := checkSemesterOpen(request.Target, state.state); err != nil {
+			return nil, err
+		}
+
+		// 1. Find the rooms the faculty confirmed in the source semester
+		source := origin.facultyRooms(request.Faculty, true)
+		if len(source) == 0 {
+			return nil, fmt.Errorf("faculty %q has no confirmed rooms in semester %q", request.Faculty, request.Source)
+		}
+
+		// 2. Keep the rooms nobody took in the target semester
+		before := state.facultyRooms(request.Faculty, false)
+		kept := heldRooms(before)
+
+		// 2.1 Only registered programs, within the faculty's quota. Rooms it
+		// already holds in the target semester count in its usage already
+		if err := s.checkDemand(state, request.Target, request.Faculty, renewalDemand(source, kept)); err != nil {
+			return nil, err
+		}
+
+		for _, room := range source {
+			allocation := origin.allocations[room.id]
+			if kept[room.id] || !s.isFree(state, allocation.room) {
+				continue
+			}
+
+			s.sequence++
+			state.allocations[allocation.room.ID] = &memoryAllocation{
+				id:        s.sequence,
+				room:      allocation.room,
+				faculty:   request.Faculty,
+				program:   allocation.program,
+				adapted:   allocation.adapted,
+				offeredAt: time.Now(),
+			}
+			kept[allocation.room.ID] = true
+		}
+
+		// 3. Allocate substitutes for the rest (best-effort for partial renewals)
+		shortfall, err := s.allocatePrograms(state, &models.AllocateRequest{
+			Semester: request.Target,
+			Faculty:  request.Faculty,
+			Programs: renewalDemand(source, kept),
+		}, !request.Partial)
+		if err != nil {
+			return nil, err
+		}
+
+		// 4. Pair the substitutes with the rooms they replace
+		after := state.facultyRooms(request.Faculty, false)
+
+		response.Source = request.Source
+		response.Target = request.Target
+		response.Faculty = request.Faculty
+		response.Deadline = time.Now().Add(s.hold)
+		response.Partial = len(shortfall) > 0
+		response.Programs = renewalPrograms(source, kept, addedRooms(before, after, kept))
+
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ProposeSwap mirrors SqlcAllocationService.ProposeSwap.
+func (s *MemoryAllocationService) ProposeSwap(ctx context.Context, request *models.SwapProposal) (*models.Swap, error) {
+	if err := validateSwap(request); err != nil {
+		return nil, err
+	}
+
+	response := &models.Swap{}
+	notifications, err := s.transaction(ctx, "swap-propose", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. Only open semesters take swaps, between registered faculties
+		if err := checkSemesterOpen(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		if err := s.checkRegistry(request.Faculty, []string{request.Program}); err != nil {
+			return nil, err
+		}
+
+		if err := s.checkRegistry(request.To, nil); err != nil {
+			return nil, err
+		}
+
+		// 1. Each side must hold the rooms it gives, confirmed
+		offered, err := s.swapRooms(state, request.Semester, request.Faculty, request.Offered)
+		if err != nil {
+			return nil, err
+		}
+
+		requested, err := s.swapRooms(state, request.Semester, request.To, request.Requested)
+		if err != nil {
+			return nil, err
+		}
+
+		// 2. Record the proposal
+		s.sequence++
+		swap := &memorySwap{
+			id:        s.sequence,
+			proposer:  request.Faculty,
+			recipient: request.To,
+			program:   request.Program,
+			state:     models.SwapPending,
+			offered:   offered,
+			requested: requested,
+			createdAt: time.Now(),
+		}
+		state.swaps[swap.id] = swap
+
+		// 3. Tell the other faculty
+		*response = *swap.model(request.Semester)
+		return []*models.Notification{swapNotification("swap-proposed", request.To, response)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// RespondSwap mirrors SqlcAllocationService.RespondSwap.
+func (s *MemoryAllocationService) RespondSwap(ctx context.Context, request *models.SwapDecision) (*models.Swap, error) {
+	response := &models.Swap{}
+	notifications, err := s.transaction(ctx, "swap-respond", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 1. Find the swap
+		swap, ok := state.swaps[request.Swap]
+		if !ok {
+			return nil, fmt.Errorf("swap %d does not exist in semester %q", request.Swap, request.Semester)
+		}
+
+		*response = *swap.model(request.Semester)
+		if err := checkSwapDecision(response, request); err != nil {
+			return nil, err
+		}
+
+		// 2. Rejections only close the proposal
+		if !request.Accept {
+			swap.state = models.SwapRejected
+			response.State = swap.state
+			return []*models.Notification{swapNotification("swap-rejected", swapCounterpart(response, request.Faculty), response)}, nil
+		}
+
+		// 3. Only open semesters take swaps, into registered programs
+		if err := checkSemesterOpen(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		if err := s.checkRegistry(swap.recipient, []string{request.Program}); err != nil {
+			return nil, err
+		}
+
+		// 3.1 Each side must still hold the rooms it gives
+		offered, err := pickRooms(state.facultyRooms(swap.proposer, true), request.Semester, swap.proposer, response.Offered)
+		if err != nil {
+			return nil, staleSwap(request.Swap, err)
+		}
+
+		requested, err := pickRooms(state.facultyRooms(swap.recipient, true), request.Semester, swap.recipient, response.Requested)
+		if err != nil {
+			return nil, staleSwap(request.Swap, err)
+		}
+
+		// 3.2 Both faculties must stay within their quotas
+		if err := s.checkQuota(state, request.Semester, swap.recipient, swapCount(offered), swapCount(requested)); err != nil {
+			return nil, err
+		}
+
+		if err := s.checkQuota(state, request.Semester, swap.proposer, swapCount(requested), swapCount(offered)); err != nil {
+			return nil, err
+		}
+
+		// 4. Move the rooms of both sides
+		for _, room := range offered {
+			state.allocations[room.id].faculty = swap.recipient
+			state.allocations[room.id].program = request.Program
+		}
+
+		for _, room := range requested {
+			state.allocations[room.id].faculty = swap.proposer
+			state.allocations[room.id].program = swap.program
+		}
+
+		swap.state = models.SwapAccepted
+		response.State = swap.state
+		return []*models.Notification{swapNotification("swap-accepted", swap.proposer, response)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *MemoryAllocationService) ListSwaps(ctx context.Context, request *models.SwapListRequest) (*models.SwapListResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	response := &models.SwapListResponse{
+		Semester: request.Semester,
+		Faculty:  request.Faculty,
+		Swaps:    []models.Swap{},
+	}
+
+	swaps := []*memorySwap{}
+	for _, swap := range s.semester(request.Semester).swaps {
+		if swap.state == models.SwapPending && (swap.proposer == request.Faculty || swap.recipient == request.Faculty) {
+			swaps = append(swaps, swap)
+		}
+	}
+
+	slices.SortFunc(swaps, func(a, b *memorySwap) int {
+		return a.id - b.id
+	})
+
+	for _, swap := range swaps {
+		response.Swaps = append(response.Swaps, *swap.model(request.Semester))
+	}
+
+	return response, nil
+}
+
+// swapRooms mirrors SqlcAllocationService.swapRooms, returning the rooms
+// themselves.
+func (s *MemoryAllocationService) swapRooms(state *memorySemester, semester string, faculty string, names []string) ([]models.Room, error) {
+	picked, err := pickRooms(state.facultyRooms(faculty, true), semester, faculty, names)
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make([]models.Room, len(picked))
+	for i, room := range picked {
+		rooms[i] = state.allocations[room.id].room
+	}
+
+	return rooms, nil
+}
+
+// AdminAssign mirrors SqlcAllocationService.AdminAssign.
+func (s *MemoryAllocationService) AdminAssign(ctx context.Context, request *models.AdminAssignRequest) (*models.AdminAllocation, error) {
+	response := &models.AdminAllocation{}
+	notifications, err := s.transaction(ctx, "admin-assign", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. The semester must not be archived, the program must be registered
+		if err := checkSemesterAdmin(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		room, err := s.roomByName(request.Room)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.checkRegistry(request.Faculty, []string{request.Program}); err != nil {
+			return nil, err
+		}
+
+		if err := checkAdapted(request.Room, room.Laboratory, request.Adapted); err != nil {
+			return nil, err
+		}
+
+		// 1. Give the room, already confirmed
+		if !s.isFree(state, room) {
+			return nil, fmt.Errorf("room %q is not free in semester %q", request.Room, request.Semester)
+		}
+
+		s.sequence++
+		state.allocations[room.ID] = &memoryAllocation{
+			id:        s.sequence,
+			room:      room,
+			faculty:   request.Faculty,
+			program:   request.Program,
+			adapted:   request.Adapted,
+			locked:    true,
+			offeredAt: time.Now(),
+		}
+
+		*response = models.AdminAllocation{
+			Semester: request.Semester,
+			Faculty:  request.Faculty,
+			Program:  request.Program,
+			Room:     room.Name,
+			Adapted:  request.Adapted,
+		}
+
+		// 2. Tell the faculty
+		return []*models.Notification{adminNotification("assigned", response)}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// AdminRevoke mirrors SqlcAllocationService.AdminRevoke.
+func (s *MemoryAllocationService) AdminRevoke(ctx context.Context, request *models.AdminRevokeRequest) (*models.AdminAllocation, error) {
+	response := &models.AdminAllocation{}
+	notifications, err := s.transaction(ctx, "admin-revoke", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. The semester must not be archived
+		if err := checkSemesterAdmin(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		room, err := s.roomByName(request.Room)
+		if err != nil {
+			return nil, err
+		}
+
+		// 1. Strip the allocation
+		allocation, ok := state.allocations[room.ID]
+		if !ok {
+			return nil, fmt.Errorf("room %q is not allocated in semester %q", request.Room, request.Semester)
+		}
+
+		state.reason = "revoked"
+		delete(state.allocations, room.ID)
+		*response = models.AdminAllocation{
+			Semester: request.Semester,
+			Faculty:  allocation.faculty,
+			Program:  allocation.program,
+			Room:     room.Name,
+			Adapted:  allocation.adapted,
+		}
+
+		// 2. Tell the faculty and offer the room to the waitlist
+		return append([]*models.Notification{adminNotification("revoked", response)}, s.processWaitlist(state, request.Semester)...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// AdminBlockRoom mirrors SqlcAllocationService.AdminBlockRoom.
+func (s *MemoryAllocationService) AdminBlockRoom(ctx context.Context, request *models.AdminBlockRequest) (*models.AdminBlockResponse, error) {
+	response := &models.AdminBlockResponse{}
+	notifications, err := s.transaction(ctx, "admin-block-room", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. The semester must not be archived
+		if err := checkSemesterAdmin(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		room, err := s.roomByName(request.Room)
+		if err != nil {
+			return nil, err
+		}
+
+		response.Semester = request.Semester
+		response.Room = room.Name
+
+		// 1. Put the room back in service, offering it to the waitlist
+		if request.Unblock {
+			if _, blocked := state.blocked[room.ID]; !blocked {
+				return nil, fmt.Errorf("room %q is not blocked in semester %q", request.Room, request.Semester)
+			}
+
+			delete(state.blocked, room.ID)
+			return s.processWaitlist(state, request.Semester), nil
+		}
+
+		// 2. Block the room and move its allocation elsewhere
+		state.blocked[room.ID] = request.Reason
+		response.Reason = request.Reason
+		response.Blocked = true
+
+		relocated, notifications := s.relocate(state, request.Semester, room.Name)
+		response.Relocated = relocated.Relocated
+		response.Unplaced = relocated.Unplaced
+		return notifications, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *MemoryAllocationService) DeclareOutage(ctx context.Context, request *models.OutageRequest) (*models.OutageResponse, error) {
+	if err := validateOutage(request); err != nil {
+		return nil, err
+	}
+
+	response, notifications, err := s.declareOutage(request)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// declareOutage stores the outage and relocates what it overlaps, returning
+// the notifications to send once the lock is released.
+func (s *MemoryAllocationService) declareOutage(request *models.OutageRequest) (*models.OutageResponse, []*models.Notification, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 1. Find the room and store the outage
+	room, err := s.roomByName(request.Room)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	before := s.snapshot()
+
+	s.sequence++
+	outage := models.RoomOutage{
+		ID:       s.sequence,
+		Room:     room.Name,
+		StartsAt: request.StartsAt,
+		EndsAt:   request.EndsAt,
+		Reason:   request.Reason,
+	}
+	s.outages[outage.ID] = outage
+
+	// 2. Report the allocations and time blocks it overlaps
+	response := &models.OutageResponse{Outage: outage, Conflicts: []models.OutageConflict{}}
+	for _, semester := range slices.Sorted(maps.Keys(s.semesters)) {
+		state := s.semesters[semester]
+		if state.state == models.SemesterArchived {
+			continue
+		}
+
+		conflicts := []models.OutageConflict{}
+		if allocation, ok := state.allocations[room.ID]; ok {
+			conflicts = append(conflicts, models.OutageConflict{
+				Semester: semester,
+				Faculty:  allocation.faculty,
+				Program:  allocation.program,
+				State:    holdState(allocation.locked),
+			})
+		}
+
+		for _, booking := range state.bookings {
+			conflict := models.OutageConflict{
+				Semester: semester,
+				Faculty:  booking.faculty,
+				Program:  booking.program,
+				State:    holdState(booking.locked),
+			}
+
+			if booking.room.ID == room.ID && !slices.Contains(conflicts, conflict) {
+				conflicts = append(conflicts, conflict)
+			}
+		}
+
+		slices.SortFunc(conflicts, func(a, b models.OutageConflict) int {
+			return strings.Compare(a.Faculty+"/"+a.Program+"/"+a.State, b.Faculty+"/"+b.Program+"/"+b.State)
+		})
+		response.Conflicts = append(response.Conflicts, conflicts...)
+	}
+
+	// 3. Relocate them if the outage already started
+	notifications := s.relocateConflicts(response)
+	return response, notifications, s.recordEvents(before, s.semesters)
+}
+
+// relocateConflicts moves the allocations an outage overlaps when it has
+// already started, the offer reaper does it otherwise once it starts.
+func (s *MemoryAllocationService) relocateConflicts(response *models.OutageResponse) []*models.Notification {
+	notifications := []*models.Notification{}
+
+	response.Relocations = []models.RelocateResponse{}
+	if !outageActive(response.Outage, time.Now()) {
+		return notifications
+	}
+
+	for _, semester := range conflictSemesters(response.Conflicts) {
+		relocated, relocatedNotifications := s.relocate(s.semesters[semester], semester, response.Outage.Room)
+		response.Relocations = append(response.Relocations, *relocated)
+		notifications = append(notifications, relocatedNotifications...)
+	}
+
+	return notifications
+}
+
+func (s *MemoryAllocationService) ListOutages(ctx context.Context, request *models.OutageListRequest) (*models.OutageListResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return &models.OutageListResponse{Outages: listOutages(s.outages, request.All)}, nil
+}
+
+func (s *MemoryAllocationService) RemoveOutage(ctx context.Context, request *models.OutageRemoveRequest) (*models.RoomOutage, error) {
+	outage, notifications, err := s.removeOutage(request)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return outage, nil
+}
+
+// removeOutage deletes the outage and offers its room to the waitlists.
+func (s *MemoryAllocationService) removeOutage(request *models.OutageRemoveRequest) (*models.RoomOutage, []*models.Notification, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	outage, ok := s.outages[request.ID]
+	if !ok {
+		return nil, nil, fmt.Errorf("outage %d does not exist", request.ID)
+	}
+
+	delete(s.outages, request.ID)
+
+	before := s.snapshot()
+	notifications := []*models.Notification{}
+	for _, semester := range slices.Sorted(maps.Keys(s.semesters)) {
+		notifications = append(notifications, s.processWaitlist(s.semesters[semester], semester)...)
+	}
+
+	return &outage, notifications, s.recordEvents(before, s.semesters)
+}
+
+// Relocate mirrors SqlcAllocationService.Relocate.
+func (s *MemoryAllocationService) Relocate(ctx context.Context, request *models.RelocateRequest) (*models.RelocateResponse, error) {
+	response := &models.RelocateResponse{}
+	notifications, err := s.transaction(ctx, "admin-relocate", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. The semester must not be archived
+		if err := checkSemesterAdmin(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		// 1. Move the allocations
+		relocated, notifications := s.relocate(state, request.Semester, request.Room)
+		if request.Room != "" && len(relocated.Relocated) == 0 && len(relocated.Unplaced) == 0 {
+			return nil, fmt.Errorf("room %q is not allocated out of service in semester %q", request.Room, request.Semester)
+		}
+
+		// 2. Tell the faculties
+		*response = *relocated
+		return notifications, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// RelocateOutages mirrors SqlcAllocationService.RelocateOutages.
+func (s *MemoryAllocationService) RelocateOutages(ctx context.Context) ([]models.RelocateResponse, error) {
+	responses, notifications, err := s.relocateOutages()
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return responses, nil
+}
+
+// relocateOutages relocates the allocations held out of service in every
+// semester not archived, returning the notifications to send once the lock
+// is released.
+func (s *MemoryAllocationService) relocateOutages() ([]models.RelocateResponse, []*models.Notification, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	before := s.snapshot()
+	responses := []models.RelocateResponse{}
+	notifications := []*models.Notification{}
+	for _, semester := range slices.Sorted(maps.Keys(s.semesters)) {
+		state := s.semesters[semester]
+
+		// 1. Rooms back in service may leave their holders unplaced again
+		for _, allocation := range state.allocations {
+			if allocation.unplaced && s.inService(state, allocation.room) {
+				allocation.unplaced = false
+			}
+		}
+
+		for _, booking := range state.bookings {
+			if booking.unplaced && s.inService(state, booking.room) {
+				booking.unplaced = false
+			}
+		}
+
+		// 2. Relocate the semesters not archived
+		if state.state == models.SemesterArchived {
+			continue
+		}
+
+		relocated, relocatedNotifications := s.relocate(state, semester, "")
+		if len(relocated.Relocated) == 0 && len(relocated.Unplaced) == 0 {
+			continue
+		}
+
+		responses = append(responses, *relocated)
+		notifications = append(notifications, relocatedNotifications...)
+	}
+
+	return responses, notifications, s.recordEvents(before, s.semesters)
+}
+
+// relocate mirrors SqlcAllocationService.relocate.
+func (s *MemoryAllocationService) relocate(state *memorySemester, semester string, room string) (*models.RelocateResponse, []*models.Notification) {
+	response := &models.RelocateResponse{
+		Semester:  semester,
+		Relocated: []models.Relocation{},
+		Unplaced:  []models.Relocation{},
+	}
+
+	// 1. Find the allocations in rooms out of service
+	moving := []*memoryAllocation{}
+	for _, allocation := range state.allocations {
+		if !s.inService(state, allocation.room) && (room == "" || allocation.room.Name == room) {
+			moving = append(moving, allocation)
+		}
+	}
+
+	slices.SortFunc(moving, func(a, b *memoryAllocation) int {
+		if c := strings.Compare(a.faculty, b.faculty); c != 0 {
+			return c
+		}
+
+		if c := strings.Compare(a.program, b.program); c != 0 {
+			return c
+		}
+
+		return a.room.ID - b.room.ID
+	})
+
+	// 2. Move each one to the best equivalent room
+	state.reason = "relocated"
+	unplaced := []models.Relocation{}
+	for _, allocation := range moving {
+		relocation := models.Relocation{
+			Faculty:  allocation.faculty,
+			Program:  allocation.program,
+			State:    holdState(allocation.locked),
+			Previous: allocation.room.Name,
+			Adapted:  allocation.adapted,
+		}
+
+		laboratory := allocation.room.Laboratory || allocation.adapted
+		target, found := relocationRoom(s.freeRooms(state), allocation.room, laboratory)
+		if !found {
+			// The faculty is told once, later runs only try again
+			if !allocation.unplaced {
+				allocation.unplaced = true
+				unplaced = append(unplaced, relocation)
+			}
+
+			response.Unplaced = append(response.Unplaced, relocation)
+			continue
+		}
+
+		// 2.1 Release the previous room and give the new one in the same
+		// state, awaiting offers keeping the time they were made
+		delete(state.allocations, allocation.room.ID)
+
+		relocation.Room = target.Name
+		relocation.Adapted = laboratory && !target.Laboratory
+
+		s.sequence++
+		state.allocations[target.ID] = &memoryAllocation{
+			id:        s.sequence,
+			room:      target,
+			faculty:   allocation.faculty,
+			program:   allocation.program,
+			adapted:   relocation.Adapted,
+			locked:    allocation.locked,
+			offeredAt: allocation.offeredAt,
+		}
+
+		response.Relocated = append(response.Relocated, relocation)
+	}
+
+	// 3. Move each time block to an equivalent room free at the same hours
+	blocks := []*memoryBooking{}
+	for _, booking := range state.bookings {
+		if !s.inService(state, booking.room) && (room == "" || booking.room.Name == room) {
+			blocks = append(blocks, booking)
+		}
+	}
+
+	slices.SortFunc(blocks, func(a, b *memoryBooking) int {
+		if c := strings.Compare(a.faculty, b.faculty); c != 0 {
+			return c
+		}
+
+		if c := strings.Compare(a.program, b.program); c != 0 {
+			return c
+		}
+
+		if a.weekday != b.weekday {
+			return a.weekday - b.weekday
+		}
+
+		if a.start != b.start {
+			return a.start - b.start
+		}
+
+		return a.room.ID - b.room.ID
+	})
+
+	for _, booking := range blocks {
+		relocation := models.Relocation{
+			Faculty:  booking.faculty,
+			Program:  booking.program,
+			State:    holdState(booking.locked),
+			Previous: booking.room.Name,
+			Adapted:  booking.adapted,
+			Day:      weekdays[booking.weekday],
+			Start:    booking.start,
+			End:      booking.start + blockHours,
+		}
+
+		free := []models.Room{}
+		for _, candidate := range s.rooms {
+			if s.blockFree(state, candidate, booking.weekday, booking.start) {
+				free = append(free, candidate)
+			}
+		}
+
+		laboratory := booking.room.Laboratory || booking.adapted
+		target, found := relocationRoom(free, booking.room, laboratory)
+		if !found {
+			if !booking.unplaced {
+				booking.unplaced = true
+				unplaced = append(unplaced, relocation)
+			}
+
+			response.Unplaced = append(response.Unplaced, relocation)
+			continue
+		}
+
+		// 3.1 Release the previous block and book the new one in the same state
+		delete(state.bookings, booking.block())
+
+		relocation.Room = target.Name
+		relocation.Adapted = laboratory && !target.Laboratory
+
+		s.sequence++
+		moved := &memoryBooking{
+			id:        s.sequence,
+			room:      target,
+			faculty:   booking.faculty,
+			program:   booking.program,
+			weekday:   booking.weekday,
+			start:     booking.start,
+			adapted:   relocation.Adapted,
+			locked:    booking.locked,
+			offeredAt: booking.offeredAt,
+		}
+		state.bookings[moved.block()] = moved
+
+		response.Relocated = append(response.Relocated, relocation)
+	}
+
+	// 4. Tell the faculties where their rooms went, or that they stay
+	notifications := relocationNotifications(semester, response.Relocated)
+	return response, append(notifications, unplacedNotifications(semester, unplaced)...)
+}
+
+// Schedule mirrors SqlcAllocationService.Schedule. The mutex serializes the
+// bookings, so no two of them can take the same block.
+func (s *MemoryAllocationService) Schedule(ctx context.Context, request *models.ScheduleRequest) (*models.ScheduleResponse, error) {
+	response := &models.ScheduleResponse{}
+	if _, err := s.transaction(ctx, "schedule", request.Semester, response, func(state *memorySemester) ([]*models.Notification, error) {
+		// 0. Only open semesters take bookings
+		if err := checkSemesterOpen(request.Semester, state.state); err != nil {
+			return nil, err
+		}
+
+		// 0.1 Only registered programs, within the faculty's quota
+		if err := s.checkDemand(state, request.Semester, request.Faculty, scheduleDemand(request.Programs)); err != nil {
+			return nil, err
+		}
+
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Deadline = time.Now().Add(s.hold)
+		response.Programs = []models.ProgramTimetable{}
+
+		// 0.2 Rooms are ranked by the semester's strategy among those in service
+		candidates := []models.Room{}
+		classroomCandidates := []models.Room{}
+		laboratoryCandidates := []models.Room{}
+		for _, room := range s.rooms {
+			if !s.inService(state, room) {
+				continue
+			}
+
+			candidates = append(candidates, room)
+			if room.Laboratory {
+				laboratoryCandidates = append(laboratoryCandidates, room)
+			} else {
+				classroomCandidates = append(classroomCandidates, room)
+			}
+		}
+
+		strategy := s.strategyFor(request.Semester)
+
+		for _, program := range request.Programs {
+			classroomRanking := strategy.Rank(classroomCandidates, program)
+			laboratoryRanking := append(strategy.Rank(laboratoryCandidates, program), classroomRanking...)
+
+			// 1. Book the classroom and laboratory blocks
+			classrooms, err := s.scheduleBlocks(state, request, program, false, program.ClassroomHours, classroomRanking)
+			if err != nil {
+				return nil, err
+			}
+
+			laboratories, err := s.scheduleBlocks(state, request, program, true, program.LaboratoryHours, laboratoryRanking)
+			if err != nil {
+				return nil, err
+			}
+
+			// 2. Build the program's timetable
+			timetable := s.programTimetable(state, request.Faculty, program.Name)
+			timetable.MissingClassroomHours = max(0, program.ClassroomHours-classrooms*blockHours)
+			timetable.MissingLaboratoryHours = max(0, program.LaboratoryHours-laboratories*blockHours)
+			if timetable.MissingClassroomHours > 0 || timetable.MissingLaboratoryHours > 0 {
+				timetable.Unmet = unmetRequirements(program, models.ProgramShortfall{
+					Classrooms:   timetable.MissingClassroomHours,
+					Laboratories: timetable.MissingLaboratoryHours,
+				}, candidates)
+				response.Partial = true
+			}
+
+			response.Programs = append(response.Programs, *timetable)
+		}
+
+		return nil, nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// scheduleBlocks mirrors the schedule_program database function: it walks the
+// week block by block, taking real laboratories before adapted classrooms and
+// then the rooms in the ranking's order, until the weekly hours are covered.
+// It returns how many blocks were booked.
+func (s *MemoryAllocationService) scheduleBlocks(state *memorySemester, request *models.ScheduleRequest, program models.ProgramInfo, laboratories bool, hours int, ranking []models.Room) (int, error) {
+	if hours <= 0 {
+		return 0, nil
+	}
+
+	blocks := (hours + blockHours - 1) / blockHours
+
+	// 1. Find the rooms that meet the program's requirements
+	position := make(map[int]int, len(ranking))
+	for i, room := range ranking {
+		if _, ranked := position[room.ID]; !ranked {
+			position[room.ID] = i
+		}
+	}
+
+	rooms := []models.Room{}
+	for _, room := range s.rooms {
+		if room.Capacity < program.MinCapacity || !s.offerable(state, room) {
+			continue
+		}
+
+		if room.Laboratory && (!laboratories || (program.Equipment != "" && !slices.Contains(room.Equipment, program.Equipment))) {
+			continue
+		}
+
+		if !room.Laboratory && laboratories && program.Equipment != "" {
+			continue
+		}
+
+		rooms = append(rooms, room)
+	}
+
+	rank := func(room models.Room) int {
+		if ranked, ok := position[room.ID]; ok {
+			return ranked
+		}
+
+		return len(ranking)
+	}
+
+	slices.SortFunc(rooms, func(a, b models.Room) int {
+		if a.Laboratory != b.Laboratory {
+			if a.Laboratory {
+				return -1
+			}
+			return 1
+		}
+
+		if c := rank(a) - rank(b); c != 0 {
+			return c
+		}
+
+		if (a.Building == program.Building) != (b.Building == program.Building) {
+			if a.Building == program.Building {
+				return -1
+			}
+			return 1
+		}
+
+		return a.ID - b.ID
+	})
+
+	// 2. Walk the week, skipping blocks where the room is taken or the program
+	// is already in class
+	booked := 0
+	for weekday := 1; weekday < len(weekdays); weekday++ {
+		for start := firstBlockStart; start <= lastBlockStart; start += blockHours {
+			for _, room := range rooms {
+				if booked == blocks {
+					break
+				}
+
+				if !s.blockFree(state, room, weekday, start) || s.programBooked(state, request.Faculty, program.Name, weekday, start) {
+					continue
+				}
+
+				s.sequence++
+				booking := &memoryBooking{
+					id:        s.sequence,
+					room:      room,
+					faculty:   request.Faculty,
+					program:   program.Name,
+					weekday:   weekday,
+					start:     start,
+					adapted:   laboratories && !room.Laboratory,
+					offeredAt: time.Now(),
+				}
+				state.bookings[booking.block()] = booking
+				booked++
+			}
+		}
+	}
+
+	// 3. If not enough blocks were booked, fail
+	if !request.Partial && booked < blocks {
+		return booked, fmt.Errorf("Not enough free time blocks to schedule (%d out of %d)", booked, blocks)
+	}
+
+	return booked, nil
+}
+
+// programTimetable lists the time blocks a program holds, ordered by day and
+// hour.
+func (s *MemoryAllocationService) programTimetable(state *memorySemester, faculty string, program string) *models.ProgramTimetable {
+	bookings := []*memoryBooking{}
+	for _, booking := range state.bookings {
+		if booking.faculty == faculty && booking.program == program {
+			bookings = append(bookings, booking)
+		}
+	}
+
+	slices.SortFunc(bookings, func(a, b *memoryBooking) int {
+		if a.weekday != b.weekday {
+			return a.weekday - b.weekday
+		}
+
+		if a.start != b.start {
+			return a.start - b.start
+		}
+
+		return strings.Compare(a.room.Name, b.room.Name)
+	})
+
+	timetable := &models.ProgramTimetable{
+		Name:  program,
+		Slots: []models.TimeSlot{},
+	}
+
+	for _, booking := range bookings {
+		kind := "classroom"
+		if booking.room.Laboratory {
+			kind = "laboratory"
+		}
+
+		timetable.Slots = append(timetable.Slots, models.TimeSlot{
+			Room:    booking.room.Name,
+			Type:    kind,
+			Day:     weekdays[booking.weekday],
+			Start:   fmt.Sprintf("%02d:00", booking.start),
+			End:     fmt.Sprintf("%02d:00", booking.start+blockHours),
+			Adapted: booking.adapted,
+		})
+	}
+
+	return timetable
+}
+
+// blockFree tells whether the room can be offered and nobody holds it in the
+// given semester, neither whole nor at the given block.
+func (s *MemoryAllocationService) blockFree(state *memorySemester, room models.Room, weekday int, start int) bool {
+	if !s.offerable(state, room) {
+		return false
+	}
+
+	if _, held := state.allocations[room.ID]; held {
+		return false
+	}
+
+	_, taken := state.bookings[memoryBlock{room: room.ID, weekday: weekday, start: start}]
+	return !taken
+}
+
+// programBooked tells whether the program already holds a block at the given
+// hours, in any room.
+func (s *MemoryAllocationService) programBooked(state *memorySemester, faculty string, program string, weekday int, start int) bool {
+	for _, booking := range state.bookings {
+		if booking.faculty == faculty && booking.program == program && booking.weekday == weekday && booking.start == start {
+			return true
+		}
+	}
+
+	return false
+}
+
+// transaction runs fn on a copy of the semester and keeps the copy only if fn
+// succeeds. Like SqlcAllocationService.transaction, the response is recorded
+// under the client request found in ctx and replayed for retries.
+func (s *MemoryAllocationService) transaction(ctx context.Context, kind string, semester string, response interface{}, fn func(state *memorySemester) ([]*models.Notification, error)) ([]*models.Notification, error) {
+	return s.transactionMany(ctx, kind, []string{semester}, response, func(states map[string]*memorySemester) ([]*models.Notification, error) {
+		return fn(states[semester])
+	})
+}
+
+// transactionMany is transaction for requests that span several semesters.
+func (s *MemoryAllocationService) transactionMany(ctx context.Context, kind string, semesters []string, response interface{}, fn func(states map[string]*memorySemester) ([]*models.Notification, error)) ([]*models.Notification, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 1. Replay the stored response for already processed requests
+	key, tracked := RequestKeyFromContext(ctx)
+	if tracked {
+		if stored, ok := s.processed[key]; ok && stored.createdAt.After(time.Now().Add(-s.replayWindow)) {
+			if stored.kind != kind {
+				return nil, fmt.Errorf("request id %d was already used for a %q request", key.ID, stored.kind)
+			}
+
+			return nil, json.Unmarshal(stored.response, response)
+		}
+	}
+
+	// 2. Process the request on a copy of the semesters
+	states := make(map[string]*memorySemester, len(semesters))
+	for _, semester := range semesters {
+		states[semester] = s.semester(semester).clone()
+	}
+
+	notifications, err := fn(states)
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Record the allocation events
+	committed := make(map[string]*memorySemester, len(states))
+	for semester := range states {
+		committed[semester] = s.semesters[semester]
+	}
+
+	if err := s.recordEvents(committed, states); err != nil {
+		return nil, err
+	}
+
+	// 4. Store the response for later retries
+	if tracked {
+		encoded, err := json.Marshal(response)
+		if err != nil {
+			return nil, err
+		}
+
+		s.processed[key] = memoryProcessedRequest{kind: kind, response: encoded, createdAt: time.Now()}
+	}
+
+	// 5. Commit the copies
+	for semester, state := range states {
+		s.semesters[semester] = state
+	}
+
+	return notifications, nil
+}
+
+// semester returns the state of a semester. Must be called with the mutex
+// held.
+func (s *MemoryAllocationService) semester(semester string) *memorySemester {
+	state, ok := s.semesters[semester]
+	if !ok {
+		state = newMemorySemester()
+		s.semesters[semester] = state
+	}
+
+	return state
+}
+
+// checkDemand mirrors SqlcAllocationService.checkDemand. Must be called with
+// the mutex held.
+func (s *MemoryAllocationService) checkDemand(state *memorySemester, semester string, faculty string, programs []models.ProgramInfo) error {
+	if err := s.checkRegistry(faculty, programNames(programs)); err != nil {
+		return err
+	}
+
+	demand, returned := programDemand(programs)
+	return s.checkQuota(state, semester, faculty, demand, returned)
+}
+
+// checkQuota mirrors SqlcAllocationService.checkQuota.
+func (s *MemoryAllocationService) checkQuota(state *memorySemester, semester string, faculty string, demand roomCount, returned roomCount) error {
+	// Faculties without a quota are not limited
+	quota, limited := state.quotas[faculty]
+	if !limited || demand == (roomCount{}) {
+		return nil
+	}
+
+	used := state.usage(faculty)
+	used.classrooms -= returned.classrooms
+	used.laboratories -= returned.laboratories
+	return compareQuota(semester, faculty, quota, used, demand)
+}
+
+// checkRegistry fails unless the faculty and every given program are
+// registered. Must be called with the mutex held.
+func (s *MemoryAllocationService) checkRegistry(faculty string, programs []string) error {
+	registered, known := s.faculties[faculty]
+	return checkRegistration(faculty, known, registered, programs)
+}
+
+// freeRooms lists the rooms nobody holds in the given semester.
+func (s *MemoryAllocationService) freeRooms(state *memorySemester) []models.Room {
+	free := []models.Room{}
+	for _, room := range s.rooms {
+		if s.isFree(state, room) {
+			free = append(free, room)
+		}
+	}
+
+	return free
+}
+
+// isFree tells whether nobody holds the room or any of its time blocks in the
+// given semester and it can be offered.
+func (s *MemoryAllocationService) isFree(state *memorySemester, room models.Room) bool {
+	if !s.offerable(state, room) {
+		return false
+	}
+
+	_, taken := state.allocations[room.ID]
+	return !taken && !state.booked(room.ID)
+}
+
+// inService tells whether the room is neither blocked in the given semester
+// nor under an outage.
+func (s *MemoryAllocationService) inService(state *memorySemester, room models.Room) bool {
+	if _, blocked := state.blocked[room.ID]; blocked {
+		return false
+	}
+
+	now := time.Now()
+	for _, outage := range s.outages {
+		if outage.Room == room.Name && outageActive(outage, now) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// offerable tells whether the room is in service and has no outage declared
+// ahead either: an offer made now would have to be relocated once it starts.
+func (s *MemoryAllocationService) offerable(state *memorySemester, room models.Room) bool {
+	if !s.inService(state, room) {
+		return false
+	}
+
+	now := time.Now()
+	for _, outage := range s.outages {
+		if outage.Room == room.Name && outage.EndsAt.After(now) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// roomByName finds a room by its name.
+func (s *MemoryAllocationService) roomByName(name string) (models.Room, error) {
+	for _, room := range s.rooms {
+		if room.Name == name {
+			return room, nil
+		}
+	}
+
+	return models.Room{}, fmt.Errorf("room %q does not exist", name)
+}
+
+// allocatePrograms mirrors SqlcAllocationService.allocatePrograms, including
+// the error messages of the allocate_classrooms and allocate_laboratories
+// database functions.
+func (s *MemoryAllocationService) allocatePrograms(state *memorySemester, request *models.AllocateRequest, strict bool) ([]models.ProgramShortfall, error) {
+	strategy := s.strategyFor(request.Semester)
+	candidates := s.freeRooms(state)
+
+	shortfall := []models.ProgramShortfall{}
+	for _, program := range request.Programs {
+		classrooms, err := s.allocateClassrooms(state, strategy, request.Faculty, program, program.Classrooms, strict)
+		if err != nil {
+			return nil, err
+		}
+
+		laboratories, err := s.allocateLaboratories(state, strategy, request.Faculty, program, program.Laboratories, strict)
+		if err != nil {
+			return nil, err
+		}
+
+		missing := models.ProgramShortfall{
+			Name:         program.Name,
+			Classrooms:   program.Classrooms - classrooms,
+			Laboratories: program.Laboratories - laboratories,
+		}
+
+		if missing.Classrooms > 0 || missing.Laboratories > 0 {
+			missing.Unmet = unmetRequirements(program, missing, candidates)
+			shortfall = append(shortfall, missing)
+		}
+	}
+
+	return shortfall, nil
+}
+
+func (s *MemoryAllocationService) allocateClassrooms(state *memorySemester, strategy AllocationStrategy, faculty string, program models.ProgramInfo, count int, strict bool) (int, error) {
+	allocated := s.give(state, strategy, faculty, program, false, false, count)
+
+	// If not enough rooms were allocated, fail
+	if strict && allocated < count {
+		return allocated, fmt.Errorf("Not enough available classroom rooms to allocate (%d out of %d)", allocated, count)
+	}
+
+	return allocated, nil
+}
+
+func (s *MemoryAllocationService) allocateLaboratories(state *memorySemester, strategy AllocationStrategy, faculty string, program models.ProgramInfo, count int, strict bool) (int, error) {
+	// 1. Try to allocate as many matching laboratory-type rooms as available
+	allocated := s.give(state, strategy, faculty, program, true, false, count)
+
+	// 2. If not enough, allocate classroom-type rooms as adapted labs (an
+	// adapted classroom cannot provide specific equipment)
+	if allocated < count && program.Equipment == "" {
+		allocated += s.give(state, strategy, faculty, program, false, true, count-allocated)
+	}
+
+	// 3. If still not enough rooms, fail
+	if strict && allocated < count {
+		return allocated, fmt.Errorf("Not enough rooms to allocate for labs (%d out of %d)", allocated, count)
+	}
+
+	return allocated, nil
+}
+
+// give hands up to count free rooms of one type to a program, in the order
+// chosen by the strategy, and returns how many it gave.
+func (s *MemoryAllocationService) give(state *memorySemester, strategy AllocationStrategy, faculty string, program models.ProgramInfo, laboratory bool, adapted bool, count int) int {
+	if count <= 0 {
+		return 0
+	}
+
+	// 1. Find the free rooms that meet the program's requirements
+	candidates := []models.Room{}
+	for _, room := range s.freeRooms(state) {
+		if room.Laboratory != laboratory || room.Capacity < program.MinCapacity {
+			continue
+		}
+
+		if laboratory && program.Equipment != "" && !slices.Contains(room.Equipment, program.Equipment) {
+			continue
+		}
+
+		candidates = append(candidates, room)
+	}
+
+	// 2. Take them in the strategy's order
+	given := 0
+	for _, room := range strategy.Rank(candidates, program) {
+		if given == count {
+			break
+		}
+
+		s.sequence++
+		state.allocations[room.ID] = &memoryAllocation{
+			id:        s.sequence,
+			room:      room,
+			faculty:   faculty,
+			program:   program.Name,
+			adapted:   adapted,
+			offeredAt: time.Now(),
+		}
+		given++
+	}
+
+	return given
+}
+
+// programAllocations lists the rooms each requested program holds.
+func (s *MemoryAllocationService) programAllocations(state *memorySemester, request *models.AllocateRequest) []models.ProgramAllocation {
+	programs := []models.ProgramAllocation{}
+	for _, info := range request.Programs {
+		program := models.ProgramAllocation{
+			Name:         info.Name,
+			Classrooms:   []string{},
+			Laboratories: []string{},
+			Adapted:      []string{},
+		}
+
+		elsewhere := 0
+		for _, allocation := range state.sorted() {
+			if allocation.faculty != request.Faculty || allocation.program != info.Name {
+				continue
+			}
+
+			if info.Building != "" && allocation.room.Building != info.Building {
+				elsewhere++
+			}
+
+			if allocation.room.Laboratory {
+				program.Laboratories = append(program.Laboratories, allocation.room.Name)
+			} else if allocation.adapted {
+				program.Adapted = append(program.Adapted, allocation.room.Name)
+			} else {
+				program.Classrooms = append(program.Classrooms, allocation.room.Name)
+			}
+		}
+
+		// Report when the preferred building could not be honoured
+		if elsewhere > 0 {
+			program.Unmet = append(program.Unmet, fmt.Sprintf("building %q (%d rooms elsewhere)", info.Building, elsewhere))
+		}
+
+		programs = append(programs, program)
+	}
+
+	return programs
+}
+
+// release deletes the allocations matching the filter and returns their rooms.
+func (s *MemoryAllocationService) release(state *memorySemester, filter func(allocation *memoryAllocation) bool) []string {
+	released := []string{}
+	for _, allocation := range state.sorted() {
+		if filter(allocation) {
+			delete(state.allocations, allocation.room.ID)
+			released = append(released, allocation.room.Name)
+		}
+	}
+
+	return released
+}
+
+// releaseBookings deletes the bookings matching the filter and returns their
+// rooms, each once.
+func (s *MemoryAllocationService) releaseBookings(state *memorySemester, filter func(booking *memoryBooking) bool) []string {
+	released := []string{}
+	for _, booking := range state.sortedBookings() {
+		if !filter(booking) {
+			continue
+		}
+
+		delete(state.bookings, booking.block())
+		if !slices.Contains(released, booking.room.Name) {
+			released = append(released, booking.room.Name)
+		}
+	}
+
+	return released
+}
+
+// releaseBookingOffers deletes the bookings still awaiting confirmation that
+// match the filter, and returns their rooms once per faculty and program.
+func (s *MemoryAllocationService) releaseBookingOffers(state *memorySemester, semester string, filter func(booking *memoryBooking) bool) []models.ReleasedRoom {
+	released := []models.ReleasedRoom{}
+	for _, booking := range state.sortedBookings() {
+		if booking.locked || !filter(booking) {
+			continue
+		}
+
+		delete(state.bookings, booking.block())
+
+		room := models.ReleasedRoom{
+			Semester: semester,
+			Faculty:  booking.faculty,
+			Program:  booking.program,
+			Room:     booking.room.Name,
+		}
+		if !slices.Contains(released, room) {
+			released = append(released, room)
+		}
+	}
+
+	return released
+}
+
+// holdState names the state of an allocation or booking as the database does.
+func holdState(locked bool) string {
+	if locked {
+		return "locked"
+	}
+
+	return "awaiting"
+}
+
+// shrink releases some of a program's confirmed rooms, like the shrink_rooms
+// database function: adapted classrooms go before real laboratories, and the
+// newest rooms go first.
+func (s *MemoryAllocationService) shrink(state *memorySemester, faculty string, program string, laboratories bool, count int) ([]string, error) {
+	candidates := []*memoryAllocation{}
+	for _, allocation := range state.sorted() {
+		if allocation.faculty != faculty || allocation.program != program || !allocation.locked {
+			continue
+		}
+
+		if laboratories == (allocation.room.Laboratory || allocation.adapted) {
+			candidates = append(candidates, allocation)
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b *memoryAllocation) int {
+		if a.adapted != b.adapted {
+			if a.adapted {
+				return -1
+			}
+			return 1
+		}
+
+		return b.id - a.id
+	})
+
+	released := []string{}
+	for _, allocation := range candidates[:min(count, len(candidates))] {
+		delete(state.allocations, allocation.room.ID)
+		released = append(released, allocation.room.Name)
+	}
+
+	if len(released) < count {
+		return nil, fmt.Errorf("Not enough confirmed rooms to release for program %s (%d out of %d)", program, len(released), count)
+	}
+
+	return released, nil
+}
+
+// processWaitlist hands the free rooms of a semester to the waitlist, oldest
+// entries first, and returns one notification per faculty that got rooms.
+func (s *MemoryAllocationService) processWaitlist(state *memorySemester, semester string) []*models.Notification {
+	strategy := s.strategyFor(semester)
+
+	notifications := []*models.Notification{}
+	byFaculty := map[string]*models.Notification{}
+	remaining := []*memoryWaitlistEntry{}
+	for _, entry := range state.waitlist {
+		// 1. Grant as much as possible without failing on shortfalls, within
+		// the program's requirements
+		program := models.ProgramInfo{
+			Name:        entry.program,
+			MinCapacity: entry.minCapacity,
+			Equipment:   entry.equipment,
+			Building:    entry.building,
+		}
+		classrooms, _ := s.allocateClassrooms(state, strategy, entry.faculty, program, entry.classrooms, false)
+		laboratories, _ := s.allocateLaboratories(state, strategy, entry.faculty, program, entry.laboratories, false)
+
+		// 2. Remove satisfied entries, shrink the rest
+		entry.classrooms -= classrooms
+		entry.laboratories -= laboratories
+		if entry.classrooms > 0 || entry.laboratories > 0 {
+			remaining = append(remaining, entry)
+		}
+
+		if classrooms == 0 && laboratories == 0 {
+			continue
+		}
+
+		notification, ok := byFaculty[entry.faculty]
+		if !ok {
+			notification = &models.Notification{
+				Type:     "waitlist-granted",
+				Semester: semester,
+				Faculty:  entry.faculty,
+			}
+			byFaculty[entry.faculty] = notification
+			notifications = append(notifications, notification)
+		}
+
+		notification.Programs = append(notification.Programs, models.ProgramInfo{
+			Name:         entry.program,
+			Classrooms:   classrooms,
+			Laboratories: laboratories,
+		})
+	}
+
+	state.waitlist = remaining
+	return notifications
+}
+
+func (s *MemoryAllocationService) notify(notifications []*models.Notification) {
+	for _, notification := range notifications {
+		s.notifier.Notify(notification)
+	}
+}
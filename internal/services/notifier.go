@@ -0,0 +1,13 @@
+package services
+
+import "github.com/foxinuni/distribuidos-central/internal/models"
+
+// Notifier pushes notifications to faculties outside of the request/reply
+// flow (e.g. rooms granted from the waitlist, offers that expired).
+type Notifier interface {
+	Notify(notification *models.Notification)
+}
+
+type nopNotifier struct{}
+
+func (nopNotifier) Notify(*models.Notification) {}
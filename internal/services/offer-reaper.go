@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// OfferReaper periodically releases offers that faculties never confirmed,
+// so a faculty that crashes between allocate and confirm does not keep its
+// rooms awaiting forever. It also relocates the rooms whose outage started
+// since the last run, and forgets the responses past the replay window.
+type OfferReaper struct {
+	interval  time.Duration
+	expirer   OfferExpirer
+	relocator RelocationService
+	stopch    chan struct{}
+	waitgroup sync.WaitGroup
+
+	// Rooms already reported with no equivalent room free, so each one is
+	// logged once while it stays unplaced
+	unplaced map[string]bool
+}
+
+func NewOfferReaper(expirer OfferExpirer, relocator RelocationService, interval time.Duration) *OfferReaper {
+	return &OfferReaper{
+		interval:  interval,
+		expirer:   expirer,
+		relocator: relocator,
+		stopch:    make(chan struct{}),
+		unplaced:  make(map[string]bool),
+	}
+}
+
+func (r *OfferReaper) Start() {
+	log.Info().Msgf("Starting offer reaper (interval: %s)", r.interval)
+
+	r.waitgroup.Add(1)
+	go func() {
+		defer r.waitgroup.Done()
+
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-r.stopch:
+				return
+			case <-ticker.C:
+				r.reap()
+			}
+		}
+	}()
+}
+
+func (r *OfferReaper) Stop() {
+	close(r.stopch)
+	r.waitgroup.Wait()
+
+	log.Info().Msg("Offer reaper stopped.")
+}
+
+func (r *OfferReaper) reap() {
+	released, err := r.expirer.ExpireOffers(context.Background())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to expire offers")
+	}
+
+	for _, room := range released {
+		log.Warn().Msgf("Offer expired: released %s (faculty: %q, program: %q, semester: %q)", room.Room, room.Faculty, room.Program, room.Semester)
+	}
+
+	forgotten, err := r.expirer.ForgetRequests(context.Background())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to forget processed requests")
+	} else if forgotten > 0 {
+		log.Debug().Msgf("Forgot %d processed requests past the replay window", forgotten)
+	}
+
+	relocations, err := r.relocator.RelocateOutages(context.Background())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to relocate rooms out of service")
+		return
+	}
+
+	unplaced := make(map[string]bool)
+	for _, response := range relocations {
+		for _, relocation := range response.Relocated {
+			log.Warn().Msgf("Room out of service: moved %s to %s (faculty: %q, program: %q, semester: %q)", relocation.Previous, relocation.Room, relocation.Faculty, relocation.Program, response.Semester)
+		}
+
+		for _, relocation := range response.Unplaced {
+			key := fmt.Sprintf("%s/%s/%s/%s/%s/%d", response.Semester, relocation.Faculty, relocation.Program, relocation.Previous, relocation.Day, relocation.Start)
+			if !r.unplaced[key] {
+				log.Error().Msgf("Room out of service: no equivalent room is free for %s (faculty: %q, program: %q, semester: %q)", relocation.Previous, relocation.Faculty, relocation.Program, response.Semester)
+			}
+			unplaced[key] = true
+		}
+	}
+	r.unplaced = unplaced
+}
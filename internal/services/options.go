@@ -0,0 +1,81 @@
+package services
+
+import (
+	"io"
+	"time"
+)
+
+type allocationSettings struct {
+	hold         time.Duration
+	replayWindow time.Duration
+	notifier     Notifier
+	strategy     AllocationStrategy
+	strategies   map[string]AllocationStrategy
+	eventLog     io.Writer
+}
+
+func defaultAllocationSettings() allocationSettings {
+	return allocationSettings{
+		hold:         10 * time.Minute,
+		replayWindow: 24 * time.Hour,
+		notifier:     nopNotifier{},
+		strategy:     FirstFitStrategy{},
+		strategies:   make(map[string]AllocationStrategy),
+	}
+}
+
+// strategyFor returns the allocation strategy used for a semester.
+func (s *allocationSettings) strategyFor(semester string) AllocationStrategy {
+	if strategy, ok := s.strategies[semester]; ok {
+		return strategy
+	}
+
+	return s.strategy
+}
+
+type AllocationOptions func(*allocationSettings)
+
+// WithHoldPeriod sets how long an offer stays awaiting before it expires.
+func WithHoldPeriod(hold time.Duration) AllocationOptions {
+	return func(s *allocationSettings) {
+		s.hold = hold
+	}
+}
+
+// WithReplayWindow sets how long the response of a client request is kept to
+// answer its retries. Past it, the identity and request id may be reused.
+func WithReplayWindow(window time.Duration) AllocationOptions {
+	return func(s *allocationSettings) {
+		s.replayWindow = window
+	}
+}
+
+// WithNotifier sets where faculties are told about waitlist grants and expired
+// offers.
+func WithNotifier(notifier Notifier) AllocationOptions {
+	return func(s *allocationSettings) {
+		s.notifier = notifier
+	}
+}
+
+// WithStrategy sets the allocation strategy used by default.
+func WithStrategy(strategy AllocationStrategy) AllocationOptions {
+	return func(s *allocationSettings) {
+		s.strategy = strategy
+	}
+}
+
+// WithSemesterStrategy overrides the allocation strategy of a single semester.
+func WithSemesterStrategy(semester string, strategy AllocationStrategy) AllocationOptions {
+	return func(s *allocationSettings) {
+		s.strategies[semester] = strategy
+	}
+}
+
+// WithEventLog sets where the memory storage appends its allocation events,
+// one JSON object per line. The databases record them in allocation_events.
+func WithEventLog(writer io.Writer) AllocationOptions {
+	return func(s *allocationSettings) {
+		s.eventLog = writer
+	}
+}
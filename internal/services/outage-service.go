@@ -0,0 +1,228 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// OutageService manages the periods rooms are out of service. Declaring an
+// outage reports the allocations it overlaps, which are relocated as soon as
+// the outage starts.
+type OutageService interface {
+	DeclareOutage(ctx context.Context, request *models.OutageRequest) (*models.OutageResponse, error)
+	ListOutages(ctx context.Context, request *models.OutageListRequest) (*models.OutageListResponse, error)
+	RemoveOutage(ctx context.Context, request *models.OutageRemoveRequest) (*models.RoomOutage, error)
+}
+
+func (s *SqlcAllocationService) DeclareOutage(ctx context.Context, request *models.OutageRequest) (*models.OutageResponse, error) {
+	if err := validateOutage(request); err != nil {
+		return nil, err
+	}
+
+	var notifications []*models.Notification
+
+	response := &models.OutageResponse{}
+	if err := s.transaction(ctx, "declare-outage", response, func(querier *repository.Queries) error {
+		// 1. Find the room, locking it so no allocation takes it meanwhile
+		room, err := querier.GetRoomByName(ctx, request.Room)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("room %q does not exist", request.Room)
+		} else if err != nil {
+			return err
+		}
+
+		// 2. Store the outage
+		id, err := querier.CreateOutage(ctx, repository.CreateOutageParams{
+			RoomID:   room.ID,
+			StartsAt: pgtype.Timestamptz{Time: request.StartsAt, Valid: true},
+			EndsAt:   pgtype.Timestamptz{Time: request.EndsAt, Valid: true},
+			Reason:   request.Reason,
+		})
+		if err != nil {
+			return err
+		}
+
+		response.Outage = models.RoomOutage{
+			ID:       int(id),
+			Room:     room.Name,
+			StartsAt: request.StartsAt,
+			EndsAt:   request.EndsAt,
+			Reason:   request.Reason,
+		}
+
+		// 3. Report the allocations it overlaps
+		rows, err := querier.GetOutageConflicts(ctx, room.ID)
+		if err != nil {
+			return err
+		}
+
+		response.Conflicts = []models.OutageConflict{}
+		for _, row := range rows {
+			response.Conflicts = append(response.Conflicts, models.OutageConflict{
+				Semester: row.Semester,
+				Faculty:  row.Faculty,
+				Program:  row.Program,
+				State:    string(row.State),
+			})
+		}
+
+		// 4. Relocate them if the outage already started, the offer reaper
+		// does it otherwise once it starts
+		response.Relocations = []models.RelocateResponse{}
+		if !outageActive(response.Outage, time.Now()) {
+			return nil
+		}
+
+		for _, semester := range conflictSemesters(response.Conflicts) {
+			relocated, relocatedNotifications, err := s.relocate(ctx, querier, semester, room.Name)
+			if err != nil {
+				return err
+			}
+
+			response.Relocations = append(response.Relocations, *relocated)
+			notifications = append(notifications, relocatedNotifications...)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *SqlcAllocationService) ListOutages(ctx context.Context, request *models.OutageListRequest) (*models.OutageListResponse, error) {
+	rows, err := repository.New(s.pool).ListOutages(ctx, request.All)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &models.OutageListResponse{Outages: []models.RoomOutage{}}
+	for _, row := range rows {
+		response.Outages = append(response.Outages, models.RoomOutage{
+			ID:       int(row.ID),
+			Room:     row.Name,
+			StartsAt: row.StartsAt.Time,
+			EndsAt:   row.EndsAt.Time,
+			Reason:   row.Reason,
+		})
+	}
+
+	return response, nil
+}
+
+// RemoveOutage deletes an outage, for instance one declared by mistake. The
+// room can be allocated again unless another outage covers it, so it is
+// offered to the waitlists right away.
+func (s *SqlcAllocationService) RemoveOutage(ctx context.Context, request *models.OutageRemoveRequest) (*models.RoomOutage, error) {
+	var notifications []*models.Notification
+
+	response := &models.RoomOutage{}
+	if err := s.transaction(ctx, "remove-outage", response, func(querier *repository.Queries) error {
+		// 1. Delete the outage
+		row, err := querier.DeleteOutage(ctx, int32(request.ID))
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("outage %d does not exist", request.ID)
+		} else if err != nil {
+			return err
+		}
+
+		*response = models.RoomOutage{
+			ID:       int(row.ID),
+			Room:     row.Name,
+			StartsAt: row.StartsAt.Time,
+			EndsAt:   row.EndsAt.Time,
+			Reason:   row.Reason,
+		}
+
+		// 2. Offer the room to every semester waiting for rooms
+		semesters, err := querier.ListWaitlistSemesters(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, semester := range semesters {
+			granted, err := s.processWaitlist(ctx, querier, semester)
+			if err != nil {
+				return err
+			}
+
+			notifications = append(notifications, granted...)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// validateOutage checks the room and period of an outage.
+func validateOutage(request *models.OutageRequest) error {
+	if strings.TrimSpace(request.Room) == "" {
+		return fmt.Errorf("room name is required")
+	}
+
+	if !request.EndsAt.After(request.StartsAt) {
+		return fmt.Errorf("outage must end after it starts")
+	}
+
+	if !request.EndsAt.After(time.Now()) {
+		return fmt.Errorf("outage already ended")
+	}
+
+	return nil
+}
+
+// conflictSemesters lists, in order, the semesters of the conflicts.
+func conflictSemesters(conflicts []models.OutageConflict) []string {
+	semesters := []string{}
+	for _, conflict := range conflicts {
+		if !slices.Contains(semesters, conflict.Semester) {
+			semesters = append(semesters, conflict.Semester)
+		}
+	}
+
+	return semesters
+}
+
+// outageActive tells whether an outage keeps its room out of service, that is,
+// whether it has started and not ended yet.
+func outageActive(outage models.RoomOutage, now time.Time) bool {
+	return !outage.StartsAt.After(now) && outage.EndsAt.After(now)
+}
+
+// listOutages sorts the outages by start, leaving out the ones that ended
+// unless all of them are wanted.
+func listOutages(outages map[int]models.RoomOutage, all bool) []models.RoomOutage {
+	now := time.Now()
+
+	listed := []models.RoomOutage{}
+	for _, outage := range outages {
+		if all || outage.EndsAt.After(now) {
+			listed = append(listed, outage)
+		}
+	}
+
+	slices.SortFunc(listed, func(a, b models.RoomOutage) int {
+		if c := a.StartsAt.Compare(b.StartsAt); c != 0 {
+			return c
+		}
+
+		return a.ID - b.ID
+	})
+
+	return listed
+}
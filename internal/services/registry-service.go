@@ -0,0 +1,241 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+type RegistryService interface {
+	RegisterFaculty(ctx context.Context, request *models.RegisterFacultyRequest) (*models.RegisterFacultyResponse, error)
+	SetQuota(ctx context.Context, request *models.FacultyQuota) (*models.FacultyQuota, error)
+}
+
+// roomCount is a number of classrooms and laboratories.
+type roomCount struct {
+	classrooms   int
+	laboratories int
+}
+
+// RegisterFaculty registers a faculty and its programs. Names already
+// registered are left alone, so the same registry can be loaded many times.
+func (s *SqlcAllocationService) RegisterFaculty(ctx context.Context, request *models.RegisterFacultyRequest) (*models.RegisterFacultyResponse, error) {
+	if err := validateRegistration(request); err != nil {
+		return nil, err
+	}
+
+	response := &models.RegisterFacultyResponse{}
+	if err := s.transaction(ctx, "register-faculty", response, func(querier *repository.Queries) error {
+		// 1. Register the faculty and its programs
+		if err := querier.RegisterFaculty(ctx, request.Faculty); err != nil {
+			return err
+		}
+
+		for _, program := range request.Programs {
+			if err := querier.RegisterProgram(ctx, repository.RegisterProgramParams{
+				Faculty: request.Faculty,
+				Name:    program,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// 2. Answer with every program of the faculty
+		programs, err := querier.GetFacultyPrograms(ctx, request.Faculty)
+		if err != nil {
+			return err
+		}
+
+		response.Faculty = request.Faculty
+		response.Programs = programs
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *SqlcAllocationService) SetQuota(ctx context.Context, request *models.FacultyQuota) (*models.FacultyQuota, error) {
+	if request.Classrooms < 0 || request.Laboratories < 0 {
+		return nil, fmt.Errorf("quotas cannot be negative")
+	}
+
+	response := &models.FacultyQuota{}
+	if err := s.transaction(ctx, "set-quota", response, func(querier *repository.Queries) error {
+		// 1. The semester and the faculty must exist
+		if _, err := querier.GetSemesterState(ctx, request.Semester); errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("semester %q does not exist", request.Semester)
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.checkRegistry(ctx, querier, request.Faculty, nil); err != nil {
+			return err
+		}
+
+		// 2. Set the quota
+		if err := querier.SetFacultyQuota(ctx, repository.SetFacultyQuotaParams{
+			Semester:     request.Semester,
+			Faculty:      request.Faculty,
+			Classrooms:   int32(request.Classrooms),
+			Laboratories: int32(request.Laboratories),
+		}); err != nil {
+			return err
+		}
+
+		*response = *request
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// checkDemand checks the programs of a request against the registry and the
+// faculty's quota for the semester.
+func (s *SqlcAllocationService) checkDemand(ctx context.Context, querier *repository.Queries, semester string, faculty string, programs []models.ProgramInfo) error {
+	if err := s.checkRegistry(ctx, querier, faculty, programNames(programs)); err != nil {
+		return err
+	}
+
+	demand, returned := programDemand(programs)
+	return s.checkQuota(ctx, querier, semester, faculty, demand, returned)
+}
+
+// checkRegistry fails unless the faculty and every given program are
+// registered.
+func (s *SqlcAllocationService) checkRegistry(ctx context.Context, querier *repository.Queries, faculty string, programs []string) error {
+	known, err := querier.FacultyExists(ctx, faculty)
+	if err != nil {
+		return err
+	}
+
+	registered, err := querier.GetFacultyPrograms(ctx, faculty)
+	if err != nil {
+		return err
+	}
+
+	return checkRegistration(faculty, known, registered, programs)
+}
+
+// checkQuota fails if the demand would take the faculty above its quota for
+// the semester, after giving back the returned rooms. The quota row is locked
+// so concurrent requests of the same faculty are checked one at a time.
+func (s *SqlcAllocationService) checkQuota(ctx context.Context, querier *repository.Queries, semester string, faculty string, demand roomCount, returned roomCount) error {
+	if demand == (roomCount{}) {
+		return nil
+	}
+
+	// 1. Faculties without a quota are not limited
+	quota, err := querier.GetFacultyQuota(ctx, repository.GetFacultyQuotaParams{
+		Semester: semester,
+		Faculty:  faculty,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	// 2. Compare with what the faculty holds or waits for
+	return s.checkUsage(ctx, querier, semester, faculty,
+		roomCount{classrooms: int(quota.Classrooms), laboratories: int(quota.Laboratories)},
+		demand,
+		returned,
+	)
+}
+
+// checkUsage compares a quota with what the faculty holds or waits for,
+// giving back the returned rooms.
+func (s *SqlcAllocationService) checkUsage(ctx context.Context, querier *repository.Queries, semester string, faculty string, quota roomCount, demand roomCount, returned roomCount) error {
+	usage, err := querier.GetFacultyUsage(ctx, repository.GetFacultyUsageParams{
+		Semester: semester,
+		Faculty:  faculty,
+	})
+	if err != nil {
+		return err
+	}
+
+	return compareQuota(semester, faculty,
+		quota,
+		roomCount{classrooms: int(usage.Classrooms) - returned.classrooms, laboratories: int(usage.Laboratories) - returned.laboratories},
+		demand,
+	)
+}
+
+// validateRegistration checks the names of a registration request.
+func validateRegistration(request *models.RegisterFacultyRequest) error {
+	if strings.TrimSpace(request.Faculty) == "" {
+		return fmt.Errorf("faculty name is required")
+	}
+
+	for _, program := range request.Programs {
+		if strings.TrimSpace(program) == "" {
+			return fmt.Errorf("program names cannot be empty")
+		}
+	}
+
+	return nil
+}
+
+// checkRegistration fails unless the faculty is known and every program is
+// among its registered programs.
+func checkRegistration(faculty string, known bool, registered []string, programs []string) error {
+	if !known {
+		return fmt.Errorf("faculty %q is not registered", faculty)
+	}
+
+	for _, program := range programs {
+		if !slices.Contains(registered, program) {
+			return fmt.Errorf("program %q is not registered for faculty %q", program, faculty)
+		}
+	}
+
+	return nil
+}
+
+// compareQuota fails if a faculty using some rooms would go above its quota by
+// getting the demand too. Only the room types being asked for are compared,
+// so a faculty above a lowered quota can still give rooms back.
+func compareQuota(semester string, faculty string, quota roomCount, used roomCount, demand roomCount) error {
+	if demand.classrooms > 0 && used.classrooms+demand.classrooms > quota.classrooms {
+		return fmt.Errorf("faculty %q would hold %d classrooms in semester %q, above its quota of %d", faculty, used.classrooms+demand.classrooms, semester, quota.classrooms)
+	}
+
+	if demand.laboratories > 0 && used.laboratories+demand.laboratories > quota.laboratories {
+		return fmt.Errorf("faculty %q would hold %d laboratories in semester %q, above its quota of %d", faculty, used.laboratories+demand.laboratories, semester, quota.laboratories)
+	}
+
+	return nil
+}
+
+// programNames returns the names of the requested programs.
+func programNames(programs []models.ProgramInfo) []string {
+	names := make([]string, len(programs))
+	for i, program := range programs {
+		names[i] = program.Name
+	}
+
+	return names
+}
+
+// programDemand adds up the rooms the programs ask for and the rooms they give
+// back (the negative counts of a modify request).
+func programDemand(programs []models.ProgramInfo) (demand roomCount, returned roomCount) {
+	for _, program := range programs {
+		demand.classrooms += max(program.Classrooms, 0)
+		demand.laboratories += max(program.Laboratories, 0)
+		returned.classrooms += max(-program.Classrooms, 0)
+		returned.laboratories += max(-program.Laboratories, 0)
+	}
+
+	return demand, returned
+}
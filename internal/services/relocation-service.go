@@ -0,0 +1,387 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+type RelocationService interface {
+	Relocate(ctx context.Context, request *models.RelocateRequest) (*models.RelocateResponse, error)
+	RelocateOutages(ctx context.Context) ([]models.RelocateResponse, error)
+}
+
+// Relocate moves the allocations held in rooms out of service to free rooms of
+// the same type, keeping their faculty, program and state. Laboratories fall
+// back to adapted classrooms. The moves happen in a single transaction, and
+// each faculty is told which rooms it got in place of which.
+func (s *SqlcAllocationService) Relocate(ctx context.Context, request *models.RelocateRequest) (*models.RelocateResponse, error) {
+	var notifications []*models.Notification
+
+	response := &models.RelocateResponse{}
+	if err := s.transaction(ctx, "admin-relocate", response, func(querier *repository.Queries) error {
+		// 0. The semester must not be archived
+		if err := s.adminSemester(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		// 1. Move the allocations and time blocks
+		relocated, relocatedNotifications, err := s.relocate(ctx, querier, request.Semester, request.Room)
+		if err != nil {
+			return err
+		}
+
+		if request.Room != "" && len(relocated.Relocated) == 0 && len(relocated.Unplaced) == 0 {
+			return fmt.Errorf("room %q is not allocated out of service in semester %q", request.Room, request.Semester)
+		}
+
+		// 2. Tell the faculties
+		*response = *relocated
+		notifications = relocatedNotifications
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// RelocateOutages relocates, in every semester not archived, the allocations
+// of rooms that went out of service since they were given, for instance when
+// an outage declared beforehand starts.
+func (s *SqlcAllocationService) RelocateOutages(ctx context.Context) ([]models.RelocateResponse, error) {
+	var notifications []*models.Notification
+
+	responses := []models.RelocateResponse{}
+	if err := s.transaction(ctx, "relocate-outages", &responses, func(querier *repository.Queries) error {
+		// 1. Rooms back in service may leave their holders unplaced again
+		// later, and then they must be told again
+		if err := querier.ClearUnplacedRooms(ctx); err != nil {
+			return err
+		}
+
+		if err := querier.ClearUnplacedBookings(ctx); err != nil {
+			return err
+		}
+
+		// 2. Relocate every semester holding rooms out of service
+		semesters, err := querier.GetOutOfServiceSemesters(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, semester := range semesters {
+			relocated, relocatedNotifications, err := s.relocate(ctx, querier, semester, "")
+			if err != nil {
+				return err
+			}
+
+			responses = append(responses, *relocated)
+			notifications = append(notifications, relocatedNotifications...)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return responses, nil
+}
+
+// relocate moves the allocations and time blocks of a semester held in rooms
+// out of service (only those of the given room, unless it is empty) to free
+// rooms with at least the same capacity and equipment. The ones with no such
+// room left stay where they are, reported as unplaced. It returns the
+// notifications for the faculties, which hear of an unplaced room only once.
+func (s *SqlcAllocationService) relocate(ctx context.Context, querier *repository.Queries, semester string, room string) (*models.RelocateResponse, []*models.Notification, error) {
+	response := &models.RelocateResponse{
+		Semester:  semester,
+		Relocated: []models.Relocation{},
+		Unplaced:  []models.Relocation{},
+	}
+
+	// 1. Find the allocations in rooms out of service
+	rows, err := querier.GetOutOfServiceAllocations(ctx, repository.GetOutOfServiceAllocationsParams{
+		Semester: semester,
+		Room:     room,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bookings, err := querier.GetOutOfServiceBookings(ctx, repository.GetOutOfServiceBookingsParams{
+		Semester: semester,
+		Room:     room,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(rows) == 0 && len(bookings) == 0 {
+		return response, nil, nil
+	}
+
+	if err := querier.SetReleaseReason(ctx, "relocated"); err != nil {
+		return nil, nil, err
+	}
+
+	// 2. Move each allocation to the best equivalent room
+	unplaced := []models.Relocation{}
+	for _, row := range rows {
+		relocation := models.Relocation{
+			Faculty:  row.Faculty,
+			Program:  row.Program,
+			State:    string(row.State),
+			Previous: row.Name,
+			Adapted:  row.Adapted,
+		}
+
+		laboratory := row.Type == repository.RoomTypeLaboratory || row.Adapted
+		target, err := querier.FindRelocationRoom(ctx, repository.FindRelocationRoomParams{
+			RoomID:     row.RoomID,
+			Laboratory: laboratory,
+			Semester:   semester,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			// The faculty is told once, later runs only try again
+			marked, err := querier.MarkRoomUnplaced(ctx, repository.MarkRoomUnplacedParams{
+				Semester: semester,
+				RoomID:   row.RoomID,
+			})
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if marked > 0 {
+				unplaced = append(unplaced, relocation)
+			}
+
+			response.Unplaced = append(response.Unplaced, relocation)
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		// 2.1 Release the previous room and give the new one in the same
+		// state, awaiting offers keeping the time they were made
+		if _, err := querier.RevokeRoom(ctx, repository.RevokeRoomParams{
+			Semester: semester,
+			RoomID:   row.RoomID,
+		}); err != nil {
+			return nil, nil, err
+		}
+
+		relocation.Room = target.Name
+		relocation.Adapted = laboratory && target.Type == repository.RoomTypeClassroom
+		offered, err := querier.RelocateRoom(ctx, repository.RelocateRoomParams{
+			Semester:  semester,
+			Faculty:   row.Faculty,
+			Program:   row.Program,
+			Adapted:   relocation.Adapted,
+			OfferedAt: row.OfferedAt,
+			RoomID:    target.ID,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if offered == 0 {
+			return nil, nil, fmt.Errorf("room %q could not be given to faculty %q in semester %q", target.Name, row.Faculty, semester)
+		}
+
+		if row.State == repository.RoomStateLocked {
+			if err := querier.LockRoom(ctx, repository.LockRoomParams{
+				Semester: semester,
+				RoomID:   target.ID,
+			}); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		response.Relocated = append(response.Relocated, relocation)
+	}
+
+	// 3. Move each time block to an equivalent room free at the same hours
+	for _, row := range bookings {
+		relocation := models.Relocation{
+			Faculty:  row.Faculty,
+			Program:  row.Program,
+			State:    string(row.State),
+			Previous: row.Name,
+			Adapted:  row.Adapted,
+			Day:      weekdays[row.Weekday],
+			Start:    int(row.StartHour),
+			End:      int(row.EndHour),
+		}
+
+		laboratory := row.Type == repository.RoomTypeLaboratory || row.Adapted
+		target, err := querier.FindRelocationBlock(ctx, repository.FindRelocationBlockParams{
+			RoomID:     row.RoomID,
+			Laboratory: laboratory,
+			Semester:   semester,
+			Weekday:    row.Weekday,
+			StartHour:  row.StartHour,
+			EndHour:    row.EndHour,
+		})
+		if errors.Is(err, pgx.ErrNoRows) {
+			marked, err := querier.MarkBookingUnplaced(ctx, row.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if marked > 0 {
+				unplaced = append(unplaced, relocation)
+			}
+
+			response.Unplaced = append(response.Unplaced, relocation)
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+
+		// 3.1 Release the previous block and book the new one in the same state
+		if err := querier.RevokeBooking(ctx, row.ID); err != nil {
+			return nil, nil, err
+		}
+
+		relocation.Room = target.Name
+		relocation.Adapted = laboratory && target.Type == repository.RoomTypeClassroom
+		id, err := querier.RelocateBooking(ctx, repository.RelocateBookingParams{
+			RoomID:    target.ID,
+			Semester:  semester,
+			Faculty:   row.Faculty,
+			Program:   row.Program,
+			Weekday:   row.Weekday,
+			StartHour: row.StartHour,
+			EndHour:   row.EndHour,
+			Adapted:   relocation.Adapted,
+			OfferedAt: row.OfferedAt,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if row.State == repository.RoomStateLocked {
+			if err := querier.LockBooking(ctx, id); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		response.Relocated = append(response.Relocated, relocation)
+	}
+
+	// 4. Tell the faculties where their rooms went, or that they stay
+	notifications := relocationNotifications(semester, response.Relocated)
+	return response, append(notifications, unplacedNotifications(semester, unplaced)...), nil
+}
+
+// relocationRoom picks, among the free rooms, the one to move an allocation
+// of the previous room to. Like the FindRelocationRoom query, it only takes
+// rooms with at least the same capacity and equipment, preferring the same
+// type, then the same building, then the lowest id.
+func relocationRoom(free []models.Room, previous models.Room, laboratory bool) (models.Room, bool) {
+	rank := func(room models.Room) [2]bool {
+		return [2]bool{
+			room.Laboratory == laboratory,
+			room.Building == previous.Building,
+		}
+	}
+
+	var best models.Room
+	var bestRank [2]bool
+	found := false
+	for _, room := range free {
+		if room.Laboratory && !laboratory {
+			continue
+		}
+
+		if room.Capacity < previous.Capacity || !equippedLike(room, previous) {
+			continue
+		}
+
+		current := rank(room)
+		if !found || rankedBefore(current, bestRank) || (current == bestRank && room.ID < best.ID) {
+			best, bestRank, found = room, current, true
+		}
+	}
+
+	return best, found
+}
+
+// equippedLike tells whether a room has every equipment of the previous one.
+func equippedLike(room models.Room, previous models.Room) bool {
+	for _, equipment := range previous.Equipment {
+		if !slices.Contains(room.Equipment, equipment) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// rankedBefore tells whether the first rank goes before the second one.
+func rankedBefore(a [2]bool, b [2]bool) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i]
+		}
+	}
+
+	return false
+}
+
+// relocationNotifications tells each faculty, in order of appearance, which
+// rooms it got in place of which.
+func relocationNotifications(semester string, relocations []models.Relocation) []*models.Notification {
+	notifications := []*models.Notification{}
+	byFaculty := make(map[string]*models.Notification)
+	for _, relocation := range relocations {
+		notification, ok := byFaculty[relocation.Faculty]
+		if !ok {
+			notification = &models.Notification{
+				Type:     "relocated",
+				Semester: semester,
+				Faculty:  relocation.Faculty,
+			}
+			byFaculty[relocation.Faculty] = notification
+			notifications = append(notifications, notification)
+		}
+
+		notification.Rooms = append(notification.Rooms, relocation.Room)
+		notification.Previous = append(notification.Previous, relocation.Previous)
+	}
+
+	return notifications
+}
+
+// unplacedNotifications tells each faculty, in order of appearance, which of
+// its rooms are out of service with no equivalent room free to move to.
+func unplacedNotifications(semester string, relocations []models.Relocation) []*models.Notification {
+	notifications := []*models.Notification{}
+	byFaculty := make(map[string]*models.Notification)
+	for _, relocation := range relocations {
+		notification, ok := byFaculty[relocation.Faculty]
+		if !ok {
+			notification = &models.Notification{
+				Type:     "unplaced",
+				Semester: semester,
+				Faculty:  relocation.Faculty,
+			}
+			byFaculty[relocation.Faculty] = notification
+			notifications = append(notifications, notification)
+		}
+
+		if !slices.Contains(notification.Rooms, relocation.Previous) {
+			notification.Rooms = append(notification.Rooms, relocation.Previous)
+		}
+	}
+
+	return notifications
+}
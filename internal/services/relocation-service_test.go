@@ -0,0 +1,38 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+)
+
+func TestRelocationRoom(t *testing.T) {
+	previous := models.Room{ID: 1, Name: "LAB-001", Laboratory: true, Capacity: 30, Building: "A", Equipment: []string{"chemistry"}}
+
+	small := models.Room{ID: 2, Name: "LAB-002", Laboratory: true, Capacity: 20, Building: "A", Equipment: []string{"chemistry"}}
+	bare := models.Room{ID: 3, Name: "LAB-003", Laboratory: true, Capacity: 40, Building: "A", Equipment: []string{}}
+	classroom := models.Room{ID: 4, Name: "AUL-001", Capacity: 40, Building: "A", Equipment: []string{"chemistry"}}
+	distant := models.Room{ID: 5, Name: "LAB-004", Laboratory: true, Capacity: 30, Building: "B", Equipment: []string{"chemistry", "biology"}}
+	near := models.Room{ID: 6, Name: "LAB-005", Laboratory: true, Capacity: 35, Building: "A", Equipment: []string{"chemistry"}}
+
+	cases := []struct {
+		name  string
+		free  []models.Room
+		want  string
+		found bool
+	}{
+		{"smaller or less equipped rooms are never taken", []models.Room{small, bare}, "", false},
+		{"an equipped classroom is adapted", []models.Room{small, bare, classroom}, "AUL-001", true},
+		{"laboratories go before classrooms", []models.Room{classroom, distant}, "LAB-004", true},
+		{"the same building goes first", []models.Room{distant, near}, "LAB-005", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			room, found := relocationRoom(c.free, previous, true)
+			if found != c.found || room.Name != c.want {
+				t.Errorf("got %q (found %v), want %q (found %v)", room.Name, found, c.want, c.found)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+type RenewalService interface {
+	Renew(ctx context.Context, request *models.RenewRequest) (*models.RenewResponse, error)
+}
+
+// facultyRoom is a room a faculty holds for one of its programs.
+type facultyRoom struct {
+	id         int
+	name       string
+	program    string
+	laboratory bool
+	adapted    bool
+}
+
+// lab tells whether the room counts as a laboratory (adapted classrooms do).
+func (r facultyRoom) lab() bool {
+	return r.laboratory || r.adapted
+}
+
+// Renew offers a faculty, in the target semester, the same rooms it confirmed
+// in the source semester. Rooms already taken are replaced through the normal
+// allocation, and the new offers must be confirmed like any other.
+func (s *SqlcAllocationService) Renew(ctx context.Context, request *models.RenewRequest) (*models.RenewResponse, error) {
+	if err := validateRenewal(request); err != nil {
+		return nil, err
+	}
+
+	response := &models.RenewResponse{}
+	if err := s.transaction(ctx, "renew", response, func(querier *repository.Queries) error {
+		// 0. The source semester must exist and the target must be open
+		if _, err := querier.GetSemesterState(ctx, request.Source); errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("semester %q does not exist", request.Source)
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.requireOpen(ctx, querier, request.Target); err != nil {
+			return err
+		}
+
+		// 1. Find the rooms the faculty confirmed in the source semester
+		source, err := s.facultyRooms(ctx, querier, request.Source, request.Faculty, true)
+		if err != nil {
+			return err
+		}
+
+		if len(source) == 0 {
+			return fmt.Errorf("faculty %q has no confirmed rooms in semester %q", request.Faculty, request.Source)
+		}
+
+		// 2. Keep the rooms nobody took in the target semester
+		before, err := s.facultyRooms(ctx, querier, request.Target, request.Faculty, false)
+		if err != nil {
+			return err
+		}
+
+		kept := heldRooms(before)
+
+		// 2.1 Only registered programs, within the faculty's quota. Rooms it
+		// already holds in the target semester count in its usage already
+		if err := s.checkDemand(ctx, querier, request.Target, request.Faculty, renewalDemand(source, kept)); err != nil {
+			return err
+		}
+
+		for _, room := range source {
+			if kept[room.id] {
+				continue
+			}
+
+			renewed, err := querier.OfferRoom(ctx, repository.OfferRoomParams{
+				Semester: request.Target,
+				Faculty:  request.Faculty,
+				Program:  room.program,
+				Adapted:  room.adapted,
+				RoomID:   int32(room.id),
+			})
+			if err != nil {
+				return err
+			}
+
+			kept[room.id] = renewed > 0
+		}
+
+		// 3. Allocate substitutes for the rest (best-effort for partial renewals)
+		shortfall, err := s.allocatePrograms(ctx, querier, &models.AllocateRequest{
+			Semester: request.Target,
+			Faculty:  request.Faculty,
+			Programs: renewalDemand(source, kept),
+		}, !request.Partial)
+		if err != nil {
+			return err
+		}
+
+		// 4. Pair the substitutes with the rooms they replace
+		after, err := s.facultyRooms(ctx, querier, request.Target, request.Faculty, false)
+		if err != nil {
+			return err
+		}
+
+		response.Source = request.Source
+		response.Target = request.Target
+		response.Faculty = request.Faculty
+		response.Deadline = time.Now().Add(s.hold)
+		response.Partial = len(shortfall) > 0
+		response.Programs = renewalPrograms(source, kept, addedRooms(before, after, kept))
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// facultyRooms lists the rooms a faculty holds in a semester, by program and
+// in the order they were allocated.
+func (s *SqlcAllocationService) facultyRooms(ctx context.Context, querier *repository.Queries, semester string, faculty string, confirmed bool) ([]facultyRoom, error) {
+	rows, err := querier.GetRoomsByFacultySemester(ctx, repository.GetRoomsByFacultySemesterParams{
+		Faculty:  faculty,
+		Semester: semester,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := []facultyRoom{}
+	for _, row := range rows {
+		if confirmed && row.State != repository.RoomStateLocked {
+			continue
+		}
+
+		rooms = append(rooms, facultyRoom{
+			id:         int(row.RoomID),
+			name:       row.Name,
+			program:    row.Program,
+			laboratory: row.Type == repository.RoomTypeLaboratory,
+			adapted:    row.Adapted,
+		})
+	}
+
+	return rooms, nil
+}
+
+// validateRenewal checks the semesters and faculty of a renew request.
+func validateRenewal(request *models.RenewRequest) error {
+	if strings.TrimSpace(request.Source) == "" || strings.TrimSpace(request.Target) == "" {
+		return fmt.Errorf("source and target semesters are required")
+	}
+
+	if request.Source == request.Target {
+		return fmt.Errorf("cannot renew semester %q into itself", request.Source)
+	}
+
+	if strings.TrimSpace(request.Faculty) == "" {
+		return fmt.Errorf("faculty name is required")
+	}
+
+	return nil
+}
+
+// heldRooms returns the set of the given rooms.
+func heldRooms(rooms []facultyRoom) map[int]bool {
+	held := make(map[int]bool, len(rooms))
+	for _, room := range rooms {
+		held[room.id] = true
+	}
+
+	return held
+}
+
+// renewalDemand counts the rooms of each program that were not kept, in the
+// order the programs appear. With no kept rooms it is the whole renewal.
+func renewalDemand(rooms []facultyRoom, kept map[int]bool) []models.ProgramInfo {
+	programs := []models.ProgramInfo{}
+	for _, room := range rooms {
+		if kept[room.id] {
+			continue
+		}
+
+		if len(programs) == 0 || programs[len(programs)-1].Name != room.program {
+			programs = append(programs, models.ProgramInfo{Name: room.program})
+		}
+
+		if room.lab() {
+			programs[len(programs)-1].Laboratories++
+		} else {
+			programs[len(programs)-1].Classrooms++
+		}
+	}
+
+	return programs
+}
+
+// addedRooms returns the rooms held after a renewal that were neither held
+// before it nor kept from the source semester.
+func addedRooms(before []facultyRoom, after []facultyRoom, kept map[int]bool) []facultyRoom {
+	held := heldRooms(before)
+
+	added := []facultyRoom{}
+	for _, room := range after {
+		if !held[room.id] && !kept[room.id] {
+			added = append(added, room)
+		}
+	}
+
+	return added
+}
+
+// renewalPrograms reports, for each program, the rooms kept and the ones
+// replaced. Each room not kept is paired with a new room of the same program
+// and kind; without one it is reported as missing.
+func renewalPrograms(source []facultyRoom, kept map[int]bool, added []facultyRoom) []models.ProgramRenewal {
+	used := make([]bool, len(added))
+
+	programs := []models.ProgramRenewal{}
+	for _, room := range source {
+		if len(programs) == 0 || programs[len(programs)-1].Name != room.program {
+			programs = append(programs, models.ProgramRenewal{
+				Name:        room.program,
+				Kept:        []string{},
+				Substituted: []models.RoomSubstitution{},
+			})
+		}
+
+		program := &programs[len(programs)-1]
+		if kept[room.id] {
+			program.Kept = append(program.Kept, room.name)
+			continue
+		}
+
+		substitute := -1
+		for i, candidate := range added {
+			if !used[i] && candidate.program == room.program && candidate.lab() == room.lab() {
+				substitute = i
+				break
+			}
+		}
+
+		if substitute < 0 {
+			program.Missing = append(program.Missing, room.name)
+			continue
+		}
+
+		used[substitute] = true
+		program.Substituted = append(program.Substituted, models.RoomSubstitution{
+			Previous: room.name,
+			Room:     added[substitute].name,
+		})
+	}
+
+	return programs
+}
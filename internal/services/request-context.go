@@ -0,0 +1,21 @@
+package services
+
+import "context"
+
+// RequestKey identifies a client request: the ROUTER identity of the sender
+// plus the ID the client gave to the request.
+type RequestKey struct {
+	Identity string
+	ID       int
+}
+
+type requestKeyContext struct{}
+
+func WithRequestKey(ctx context.Context, identity string, id int) context.Context {
+	return context.WithValue(ctx, requestKeyContext{}, RequestKey{Identity: identity, ID: id})
+}
+
+func RequestKeyFromContext(ctx context.Context) (RequestKey, bool) {
+	key, ok := ctx.Value(requestKeyContext{}).(RequestKey)
+	return key, ok
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+)
+
+// blockHours is the length of the weekly time blocks rooms are booked in.
+const blockHours = 2
+
+// Time blocks start every blockHours between these hours, as in the
+// schedule_program database function.
+const (
+	firstBlockStart = 7
+	lastBlockStart  = 19
+)
+
+var weekdays = []string{"", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+type ScheduleService interface {
+	Schedule(ctx context.Context, request *models.ScheduleRequest) (*models.ScheduleResponse, error)
+}
+
+// Schedule books weekly time blocks for every program instead of holding rooms
+// for the whole semester. Bookings are offered like allocations: they must be
+// confirmed before the hold period ends and are released on decline.
+func (s *SqlcAllocationService) Schedule(ctx context.Context, request *models.ScheduleRequest) (*models.ScheduleResponse, error) {
+	response := &models.ScheduleResponse{}
+	if err := s.transaction(ctx, "schedule", response, func(querier *repository.Queries) error {
+		// 0. Only open semesters take bookings
+		if err := s.requireOpen(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		// 0.1 Only registered programs, within the faculty's quota
+		if err := s.checkDemand(ctx, querier, request.Semester, request.Faculty, scheduleDemand(request.Programs)); err != nil {
+			return err
+		}
+
+		response.Semester = request.Semester
+		response.Faculty = request.Faculty
+		response.Deadline = time.Now().Add(s.hold)
+		response.Programs = []models.ProgramTimetable{}
+
+		// 0.2 Rooms are ranked by the semester's strategy among those in service
+		rooms, err := querier.ListRoomsInService(ctx, request.Semester)
+		if err != nil {
+			return err
+		}
+
+		candidates := make([]models.Room, len(rooms))
+		classroomCandidates := []models.Room{}
+		laboratoryCandidates := []models.Room{}
+		for i, room := range rooms {
+			candidates[i] = roomModel(room)
+			if candidates[i].Laboratory {
+				laboratoryCandidates = append(laboratoryCandidates, candidates[i])
+			} else {
+				classroomCandidates = append(classroomCandidates, candidates[i])
+			}
+		}
+
+		strategy := s.strategyFor(request.Semester)
+
+		for _, program := range request.Programs {
+			classroomRanking := roomIDs(strategy.Rank(classroomCandidates, program))
+			laboratoryRanking := append(roomIDs(strategy.Rank(laboratoryCandidates, program)), classroomRanking...)
+
+			// 1. Book the classroom and laboratory blocks
+			classrooms, err := s.scheduleBlocks(ctx, querier, request, program, false, program.ClassroomHours, classroomRanking)
+			if err != nil {
+				return err
+			}
+
+			laboratories, err := s.scheduleBlocks(ctx, querier, request, program, true, program.LaboratoryHours, laboratoryRanking)
+			if err != nil {
+				return err
+			}
+
+			// 2. Build the program's timetable
+			timetable, err := s.programTimetable(ctx, querier, request, program.Name)
+			if err != nil {
+				return err
+			}
+
+			timetable.MissingClassroomHours = max(0, program.ClassroomHours-classrooms*blockHours)
+			timetable.MissingLaboratoryHours = max(0, program.LaboratoryHours-laboratories*blockHours)
+			if timetable.MissingClassroomHours > 0 || timetable.MissingLaboratoryHours > 0 {
+				timetable.Unmet = unmetRequirements(program, models.ProgramShortfall{
+					Classrooms:   timetable.MissingClassroomHours,
+					Laboratories: timetable.MissingLaboratoryHours,
+				}, candidates)
+				response.Partial = true
+			}
+
+			response.Programs = append(response.Programs, *timetable)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// scheduleBlocks books enough blocks to cover the given weekly hours, taking
+// the rooms of each block in the ranking's order, and returns how many were
+// booked.
+func (s *SqlcAllocationService) scheduleBlocks(ctx context.Context, querier *repository.Queries, request *models.ScheduleRequest, program models.ProgramInfo, laboratories bool, hours int, ranking []int32) (int, error) {
+	if hours <= 0 {
+		return 0, nil
+	}
+
+	booked, err := querier.ScheduleProgram(ctx, repository.ScheduleProgramParams{
+		Semester:     request.Semester,
+		Faculty:      request.Faculty,
+		Program:      program.Name,
+		Laboratories: laboratories,
+		Blocks:       int32((hours + blockHours - 1) / blockHours),
+		Strict:       !request.Partial,
+		MinCapacity:  int32(program.MinCapacity),
+		Equipment:    program.Equipment,
+		Building:     program.Building,
+		Ranking:      ranking,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int(booked), nil
+}
+
+// programTimetable lists the time blocks a program holds, ordered by day and
+// hour.
+func (s *SqlcAllocationService) programTimetable(ctx context.Context, querier *repository.Queries, request *models.ScheduleRequest, program string) (*models.ProgramTimetable, error) {
+	rows, err := querier.GetBookingsByFacultyProgramSemester(ctx, repository.GetBookingsByFacultyProgramSemesterParams{
+		Faculty:  request.Faculty,
+		Program:  program,
+		Semester: request.Semester,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	timetable := &models.ProgramTimetable{
+		Name:  program,
+		Slots: []models.TimeSlot{},
+	}
+
+	for _, row := range rows {
+		timetable.Slots = append(timetable.Slots, models.TimeSlot{
+			Room:    row.Name,
+			Type:    string(row.Type),
+			Day:     weekdays[row.Weekday],
+			Start:   fmt.Sprintf("%02d:00", row.StartHour),
+			End:     fmt.Sprintf("%02d:00", row.EndHour),
+			Adapted: row.Adapted,
+		})
+	}
+
+	return timetable, nil
+}
+
+// scheduleDemand expresses the weekly hours of each program as the blocks it
+// asks for. Every block counts as one room against the quota, like the
+// bookings the faculty already holds.
+func scheduleDemand(programs []models.ProgramInfo) []models.ProgramInfo {
+	demand := make([]models.ProgramInfo, len(programs))
+	for i, program := range programs {
+		demand[i] = models.ProgramInfo{
+			Name:         program.Name,
+			Classrooms:   (max(program.ClassroomHours, 0) + blockHours - 1) / blockHours,
+			Laboratories: (max(program.LaboratoryHours, 0) + blockHours - 1) / blockHours,
+		}
+	}
+
+	return demand
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+// semesterTransitions lists the states a semester may be in before it moves
+// to each state.
+var semesterTransitions = map[string][]string{
+	models.SemesterOpen:     {models.SemesterDraft, models.SemesterClosed},
+	models.SemesterClosed:   {models.SemesterOpen},
+	models.SemesterArchived: {models.SemesterClosed},
+}
+
+type SemesterService interface {
+	CreateSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error)
+	OpenSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error)
+	CloseSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error)
+	ArchiveSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error)
+}
+
+func (s *SqlcAllocationService) CreateSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	if strings.TrimSpace(request.Semester) == "" {
+		return nil, fmt.Errorf("semester name is required")
+	}
+
+	response := &models.SemesterResponse{}
+	if err := s.transaction(ctx, "semester-create", response, func(querier *repository.Queries) error {
+		state := repository.SemesterStateDraft
+		if request.Open {
+			state = repository.SemesterStateOpen
+		}
+
+		created, err := querier.CreateSemester(ctx, repository.CreateSemesterParams{
+			Name:  request.Semester,
+			State: state,
+		})
+		if err != nil {
+			return err
+		}
+
+		if created == 0 {
+			return fmt.Errorf("semester %q already exists", request.Semester)
+		}
+
+		response.Semester = request.Semester
+		response.State = string(state)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *SqlcAllocationService) OpenSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	response := &models.SemesterResponse{}
+	if err := s.transaction(ctx, "semester-open", response, func(querier *repository.Queries) error {
+		return s.transitionSemester(ctx, querier, request.Semester, "open", models.SemesterOpen, response)
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// CloseSemester stops a semester from taking allocations. Offers still
+// awaiting confirmation are released and the waitlist is dropped, since
+// nobody could confirm what it would grant.
+func (s *SqlcAllocationService) CloseSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	var notifications []*models.Notification
+
+	response := &models.SemesterResponse{}
+	if err := s.transaction(ctx, "semester-close", response, func(querier *repository.Queries) error {
+		// 1. Close the semester
+		if err := s.transitionSemester(ctx, querier, request.Semester, "close", models.SemesterClosed, response); err != nil {
+			return err
+		}
+
+		// 2. Release the offers nobody confirmed
+		if err := querier.SetReleaseReason(ctx, "closed"); err != nil {
+			return err
+		}
+
+		rows, err := querier.CloseRooms(ctx, request.Semester)
+		if err != nil {
+			return err
+		}
+
+		// 2.1 Time block offers are released the same way
+		blocks, err := querier.CloseBookings(ctx, request.Semester)
+		if err != nil {
+			return err
+		}
+
+		for _, block := range blocks {
+			rows = append(rows, repository.CloseRoomsRow(block))
+		}
+
+		// 3. Drop the waitlist
+		if err := querier.ClearWaitlist(ctx, request.Semester); err != nil {
+			return err
+		}
+
+		// 4. Tell each faculty which offers it lost
+		released := make([]models.ReleasedRoom, 0, len(rows))
+		for _, row := range rows {
+			released = append(released, models.ReleasedRoom{
+				Semester: row.Semester,
+				Faculty:  row.Faculty,
+				Program:  row.Program,
+				Room:     row.Name,
+			})
+		}
+
+		response.Released = released
+		notifications = semesterClosedNotifications(request.Semester, released)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *SqlcAllocationService) ArchiveSemester(ctx context.Context, request *models.SemesterRequest) (*models.SemesterResponse, error) {
+	response := &models.SemesterResponse{}
+	if err := s.transaction(ctx, "semester-archive", response, func(querier *repository.Queries) error {
+		return s.transitionSemester(ctx, querier, request.Semester, "archive", models.SemesterArchived, response)
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// transitionSemester moves a semester to the given state, failing if it does
+// not exist or its current state does not allow it.
+func (s *SqlcAllocationService) transitionSemester(ctx context.Context, querier *repository.Queries, semester string, action string, to string, response *models.SemesterResponse) error {
+	updated, err := querier.UpdateSemesterState(ctx, repository.UpdateSemesterStateParams{
+		State:      repository.SemesterState(to),
+		Name:       semester,
+		FromStates: semesterTransitions[to],
+	})
+	if err != nil {
+		return err
+	}
+
+	if updated == 0 {
+		state, err := querier.GetSemesterState(ctx, semester)
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("semester %q does not exist", semester)
+		} else if err != nil {
+			return err
+		}
+
+		return fmt.Errorf("semester %q is %s, cannot %s it", semester, state, action)
+	}
+
+	response.Semester = semester
+	response.State = to
+	return nil
+}
+
+// requireOpen fails unless the semester exists and is open. The semester row
+// is share locked, so it cannot be closed while the request runs.
+func (s *SqlcAllocationService) requireOpen(ctx context.Context, querier *repository.Queries, semester string) error {
+	state, err := querier.GetSemesterState(ctx, semester)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return checkSemesterOpen(semester, "")
+	} else if err != nil {
+		return err
+	}
+
+	return checkSemesterOpen(semester, string(state))
+}
+
+// checkSemesterOpen fails unless a semester in the given state (empty when it
+// does not exist) takes allocations.
+func checkSemesterOpen(semester string, state string) error {
+	if state == "" {
+		return fmt.Errorf("semester %q does not exist", semester)
+	}
+
+	if state != models.SemesterOpen {
+		return fmt.Errorf("semester %q is %s, not open", semester, state)
+	}
+
+	return nil
+}
+
+// semesterClosedNotifications builds one notification per faculty that lost
+// offers when a semester was closed.
+func semesterClosedNotifications(semester string, released []models.ReleasedRoom) []*models.Notification {
+	notifications := []*models.Notification{}
+	byFaculty := map[string]*models.Notification{}
+	for _, room := range released {
+		notification, ok := byFaculty[room.Faculty]
+		if !ok {
+			notification = &models.Notification{
+				Type:     "semester-closed",
+				Semester: semester,
+				Faculty:  room.Faculty,
+			}
+			byFaculty[room.Faculty] = notification
+			notifications = append(notifications, notification)
+		}
+
+		notification.Rooms = append(notification.Rooms, room.Room)
+	}
+
+	return notifications
+}
@@ -0,0 +1,1148 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+)
+
+// SqliteAllocationService stores rooms and allocations in a SQLite database
+// (see migrations-sqlite). SQLite has no PL/pgSQL nor SKIP LOCKED, so every
+// request loads its semester into a MemoryAllocationService, runs there and
+// writes the changes back in the same transaction. The database must be
+// opened with _txlock=immediate so transactions take the write lock up front
+// and requests are serialized, just like the memory service's mutex.
+type SqliteAllocationService struct {
+	allocationSettings
+	db *sql.DB
+}
+
+func NewSqliteAllocationService(db *sql.DB, options ...AllocationOptions) *SqliteAllocationService {
+	service := &SqliteAllocationService{
+		allocationSettings: defaultAllocationSettings(),
+		db:                 db,
+	}
+
+	for _, applyOption := range options {
+		applyOption(&service.allocationSettings)
+	}
+
+	return service
+}
+
+// SqliteDSN returns the data source name that opens path with the settings the
+// service relies on.
+func SqliteDSN(path string) string {
+	return fmt.Sprintf("file:%s?_txlock=immediate&_pragma=busy_timeout(10000)&_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)", path)
+}
+
+// Seed inserts the given rooms if the database has none yet, and reports
+// whether it did.
+func (s *SqliteAllocationService) Seed(ctx context.Context, rooms []models.Room) (bool, error) {
+	// 1. Create a transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// 2. Leave existing rooms alone
+	var count int
+	if err := tx.QueryRowContext(ctx, "SELECT COUNT(*) FROM rooms").Scan(&count); err != nil {
+		return false, err
+	}
+
+	if count > 0 {
+		return false, nil
+	}
+
+	// 3. Insert the rooms
+	for _, room := range rooms {
+		kind := "classroom"
+		if room.Laboratory {
+			kind = "laboratory"
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO rooms (id, name, type, capacity, building, floor, equipment) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			room.ID,
+			room.Name,
+			kind,
+			room.Capacity,
+			room.Building,
+			room.Floor,
+			strings.Join(room.Equipment, ","),
+		); err != nil {
+			return false, err
+		}
+	}
+
+	return true, tx.Commit()
+}
+
+func (s *SqliteAllocationService) Allocate(ctx context.Context, request *models.AllocateRequest) (response *models.AllocateResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Allocate(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) AllocateBatch(ctx context.Context, request *models.BatchAllocateRequest) (response *models.BatchAllocateResponse, err error) {
+	if err := validateBatch(request); err != nil {
+		return nil, err
+	}
+
+	err = s.transaction(ctx, batchSemesters(request), func(engine *MemoryAllocationService) error {
+		response, err = engine.AllocateBatch(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) Quote(ctx context.Context, request *models.AllocateRequest) (response *models.QuoteResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Quote(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) Confirm(ctx context.Context, request *models.ConfirmRequest) (response *models.ConfirmResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Confirm(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) Cancel(ctx context.Context, request *models.CancelRequest) (response *models.CancelResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Cancel(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) Modify(ctx context.Context, request *models.ModifyRequest) (response *models.ModifyResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Modify(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) Availability(ctx context.Context, semester string) (availability *models.Availability, err error) {
+	err = s.transaction(ctx, []string{semester}, func(engine *MemoryAllocationService) error {
+		availability, err = engine.Availability(ctx, semester)
+		return err
+	})
+
+	return availability, err
+}
+
+func (s *SqliteAllocationService) ExpireOffers(ctx context.Context) (released []models.ReleasedRoom, err error) {
+	err = s.transaction(ctx, nil, func(engine *MemoryAllocationService) error {
+		released, err = engine.ExpireOffers(ctx)
+		return err
+	})
+
+	return released, err
+}
+
+func (s *SqliteAllocationService) ForgetRequests(ctx context.Context) (int64, error) {
+	// 1. Responses past the replay window are never replayed again
+	result, err := s.db.ExecContext(ctx,
+		"DELETE FROM processed_requests WHERE created_at <= datetime('now', ?)",
+		fmt.Sprintf("-%d seconds", int64(s.replayWindow.Seconds())),
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (s *SqliteAllocationService) WaitlistStatus(ctx context.Context, request *models.WaitlistStatusRequest) (response *models.WaitlistStatusResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.WaitlistStatus(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) CreateSemester(ctx context.Context, request *models.SemesterRequest) (response *models.SemesterResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.CreateSemester(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) OpenSemester(ctx context.Context, request *models.SemesterRequest) (response *models.SemesterResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.OpenSemester(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) CloseSemester(ctx context.Context, request *models.SemesterRequest) (response *models.SemesterResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.CloseSemester(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) ArchiveSemester(ctx context.Context, request *models.SemesterRequest) (response *models.SemesterResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.ArchiveSemester(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+// RegisterFaculty registers a faculty and its programs. Names already
+// registered are left alone.
+func (s *SqliteAllocationService) RegisterFaculty(ctx context.Context, request *models.RegisterFacultyRequest) (*models.RegisterFacultyResponse, error) {
+	if err := validateRegistration(request); err != nil {
+		return nil, err
+	}
+
+	// 1. Create a transaction
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// 2. Register the faculty and its programs
+	if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO faculties (name) VALUES (?)", request.Faculty); err != nil {
+		return nil, err
+	}
+
+	for _, program := range request.Programs {
+		if _, err := tx.ExecContext(ctx, "INSERT OR IGNORE INTO programs (faculty, name) VALUES (?, ?)", request.Faculty, program); err != nil {
+			return nil, err
+		}
+	}
+
+	// 3. Answer with every program of the faculty
+	rows, err := tx.QueryContext(ctx, "SELECT name FROM programs WHERE faculty = ? ORDER BY name", request.Faculty)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	response := &models.RegisterFacultyResponse{
+		Faculty:  request.Faculty,
+		Programs: []string{},
+	}
+
+	for rows.Next() {
+		var program string
+		if err := rows.Scan(&program); err != nil {
+			return nil, err
+		}
+
+		response.Programs = append(response.Programs, program)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *SqliteAllocationService) SetQuota(ctx context.Context, request *models.FacultyQuota) (response *models.FacultyQuota, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.SetQuota(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) Renew(ctx context.Context, request *models.RenewRequest) (response *models.RenewResponse, err error) {
+	if err := validateRenewal(request); err != nil {
+		return nil, err
+	}
+
+	err = s.transaction(ctx, []string{request.Source, request.Target}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Renew(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) ProposeSwap(ctx context.Context, request *models.SwapProposal) (response *models.Swap, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.ProposeSwap(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) RespondSwap(ctx context.Context, request *models.SwapDecision) (response *models.Swap, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.RespondSwap(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) ListSwaps(ctx context.Context, request *models.SwapListRequest) (response *models.SwapListResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.ListSwaps(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) AdminAssign(ctx context.Context, request *models.AdminAssignRequest) (response *models.AdminAllocation, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.AdminAssign(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) AdminRevoke(ctx context.Context, request *models.AdminRevokeRequest) (response *models.AdminAllocation, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.AdminRevoke(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) AdminBlockRoom(ctx context.Context, request *models.AdminBlockRequest) (response *models.AdminBlockResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.AdminBlockRoom(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) DeclareOutage(ctx context.Context, request *models.OutageRequest) (*models.OutageResponse, error) {
+	if err := validateOutage(request); err != nil {
+		return nil, err
+	}
+
+	response := &models.OutageResponse{Conflicts: []models.OutageConflict{}}
+	store := func(tx *sql.Tx) error {
+		// 1. Find the room and store the outage
+		var room int
+		if err := tx.QueryRowContext(ctx, "SELECT id, name FROM rooms WHERE name = ? ORDER BY id LIMIT 1", request.Room).Scan(&room, &response.Outage.Room); errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("room %q does not exist", request.Room)
+		} else if err != nil {
+			return err
+		}
+
+		if err := tx.QueryRowContext(ctx,
+			"INSERT INTO room_outages (room_id, starts_at, ends_at, reason) VALUES (?, ?, ?, ?) RETURNING id",
+			room,
+			request.StartsAt.UTC(),
+			request.EndsAt.UTC(),
+			request.Reason,
+		).Scan(&response.Outage.ID); err != nil {
+			return err
+		}
+
+		response.Outage.StartsAt = request.StartsAt
+		response.Outage.EndsAt = request.EndsAt
+		response.Outage.Reason = request.Reason
+
+		// 2. Report the allocations and time blocks it overlaps
+		rows, err := tx.QueryContext(ctx,
+			"SELECT a.semester, a.faculty, a.program, a.state FROM room_allocations a JOIN semesters s ON s.name = a.semester WHERE a.room_id = ? AND s.state <> 'archived' "+
+				"UNION SELECT b.semester, b.faculty, b.program, b.state FROM room_bookings b JOIN semesters s ON s.name = b.semester WHERE b.room_id = ? AND s.state <> 'archived' "+
+				"ORDER BY 1, 2, 3, 4",
+			room,
+			room,
+		)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var conflict models.OutageConflict
+			if err := rows.Scan(&conflict.Semester, &conflict.Faculty, &conflict.Program, &conflict.State); err != nil {
+				return err
+			}
+
+			response.Conflicts = append(response.Conflicts, conflict)
+		}
+
+		return rows.Err()
+	}
+
+	// 3. Relocate them, on every semester, if the outage already started
+	if err := s.transactionAfter(ctx, store, nil, func(engine *MemoryAllocationService) error {
+		engine.notify(engine.relocateConflicts(response))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (s *SqliteAllocationService) ListOutages(ctx context.Context, request *models.OutageListRequest) (*models.OutageListResponse, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	outages, err := s.outages(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.OutageListResponse{Outages: listOutages(outages, request.All)}, nil
+}
+
+// RemoveOutage runs on every semester, since any waitlist may take the room.
+func (s *SqliteAllocationService) RemoveOutage(ctx context.Context, request *models.OutageRemoveRequest) (response *models.RoomOutage, err error) {
+	err = s.transaction(ctx, nil, func(engine *MemoryAllocationService) error {
+		response, err = engine.RemoveOutage(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+// outages reads every outage, by id.
+func (s *SqliteAllocationService) outages(ctx context.Context, tx *sql.Tx) (map[int]models.RoomOutage, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT o.id, r.name, o.starts_at, o.ends_at, o.reason FROM room_outages o JOIN rooms r ON r.id = o.room_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outages := make(map[int]models.RoomOutage)
+	for rows.Next() {
+		var outage models.RoomOutage
+		if err := rows.Scan(&outage.ID, &outage.Room, &outage.StartsAt, &outage.EndsAt, &outage.Reason); err != nil {
+			return nil, err
+		}
+
+		outages[outage.ID] = outage
+	}
+
+	return outages, rows.Err()
+}
+
+func (s *SqliteAllocationService) Relocate(ctx context.Context, request *models.RelocateRequest) (response *models.RelocateResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Relocate(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+func (s *SqliteAllocationService) RelocateOutages(ctx context.Context) (responses []models.RelocateResponse, err error) {
+	err = s.transaction(ctx, nil, func(engine *MemoryAllocationService) error {
+		responses, err = engine.RelocateOutages(ctx)
+		return err
+	})
+
+	return responses, err
+}
+
+// Schedule books the time blocks in the memory engine. Transactions take the
+// write lock when they begin, so no other booking can take the same block in
+// the meantime.
+func (s *SqliteAllocationService) Schedule(ctx context.Context, request *models.ScheduleRequest) (response *models.ScheduleResponse, err error) {
+	err = s.transaction(ctx, []string{request.Semester}, func(engine *MemoryAllocationService) error {
+		response, err = engine.Schedule(ctx, request)
+		return err
+	})
+
+	return response, err
+}
+
+// notificationBuffer holds back notifications until the transaction that
+// produced them commits.
+type notificationBuffer struct {
+	notifications []*models.Notification
+}
+
+func (b *notificationBuffer) Notify(notification *models.Notification) {
+	b.notifications = append(b.notifications, notification)
+}
+
+// transaction loads the given semesters (every semester when nil) into a
+// memory engine, runs fn on it and saves what fn changed.
+func (s *SqliteAllocationService) transaction(ctx context.Context, semesters []string, fn func(engine *MemoryAllocationService) error) error {
+	return s.transactionAfter(ctx, nil, semesters, fn)
+}
+
+// transactionAfter is transaction, running prepare first (when given) on the
+// database itself, so the engine loads what it wrote.
+func (s *SqliteAllocationService) transactionAfter(ctx context.Context, prepare func(tx *sql.Tx) error, semesters []string, fn func(engine *MemoryAllocationService) error) error {
+	// 1. Create a transaction (immediate, so it holds the write lock)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if prepare != nil {
+		if err := prepare(tx); err != nil {
+			return err
+		}
+	}
+
+	// 2. Load the state the request works on
+	buffer := &notificationBuffer{}
+	engine, err := s.load(ctx, tx, semesters, buffer)
+	if err != nil {
+		return err
+	}
+
+	before := make(map[string]*memorySemester, len(engine.semesters))
+	for semester, state := range engine.semesters {
+		before[semester] = state.clone()
+	}
+
+	processed := make(map[RequestKey]bool, len(engine.processed))
+	for key := range engine.processed {
+		processed[key] = true
+	}
+
+	outages := make(map[int]bool, len(engine.outages))
+	for id := range engine.outages {
+		outages[id] = true
+	}
+
+	// 3. Process the request
+	if err := fn(engine); err != nil {
+		return err
+	}
+
+	// 4. Save the changes
+	for semester, state := range engine.semesters {
+		previous, ok := before[semester]
+		if !ok {
+			previous = newMemorySemester()
+		}
+
+		if err := s.save(ctx, tx, semester, previous, state); err != nil {
+			return err
+		}
+	}
+
+	for id := range outages {
+		if _, kept := engine.outages[id]; kept {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM room_outages WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+
+	for key, stored := range engine.processed {
+		if processed[key] {
+			continue
+		}
+
+		// Responses past the replay window were not loaded and are overwritten
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO processed_requests (identity, request_id, type, response) VALUES (?, ?, ?, ?) "+
+				"ON CONFLICT (identity, request_id) DO UPDATE SET type = excluded.type, response = excluded.response, created_at = CURRENT_TIMESTAMP",
+			key.Identity,
+			key.ID,
+			stored.kind,
+			stored.response,
+		); err != nil {
+			return err
+		}
+	}
+
+	// 5. Commit the transaction, then tell the faculties
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, notification := range buffer.notifications {
+		s.notifier.Notify(notification)
+	}
+
+	return nil
+}
+
+// load builds a memory engine holding the rooms, the given semesters and the
+// processed request found in ctx.
+func (s *SqliteAllocationService) load(ctx context.Context, tx *sql.Tx, semesters []string, notifier Notifier) (*MemoryAllocationService, error) {
+	engine := &MemoryAllocationService{
+		allocationSettings: s.allocationSettings,
+		faculties:          make(map[string][]string),
+		semesters:          make(map[string]*memorySemester),
+		processed:          make(map[RequestKey]memoryProcessedRequest),
+	}
+	engine.notifier = notifier
+
+	// The events are saved with the rest of the changes, never in a file
+	engine.eventLog = nil
+
+	// 1. Load the rooms
+	rows, err := tx.QueryContext(ctx, "SELECT id, name, type, capacity, building, floor, equipment FROM rooms ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+
+	rooms := make(map[int]models.Room)
+	for rows.Next() {
+		var room models.Room
+		var kind, equipment string
+		if err := rows.Scan(&room.ID, &room.Name, &kind, &room.Capacity, &room.Building, &room.Floor, &equipment); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		room.Laboratory = kind == "laboratory"
+		room.Equipment = []string{}
+		if equipment != "" {
+			room.Equipment = strings.Split(equipment, ",")
+		}
+
+		engine.rooms = append(engine.rooms, room)
+		rooms[room.ID] = room
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 1.1 Load the outages that keep rooms out of service
+	if engine.outages, err = s.outages(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	// 1.2 Load the registered faculties and programs
+	rows, err = tx.QueryContext(ctx, "SELECT f.name, p.name FROM faculties f LEFT JOIN programs p ON p.faculty = f.name ORDER BY f.name, p.name")
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var faculty string
+		var program sql.NullString
+		if err := rows.Scan(&faculty, &program); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		if _, ok := engine.faculties[faculty]; !ok {
+			engine.faculties[faculty] = []string{}
+		}
+
+		if program.Valid {
+			engine.faculties[faculty] = append(engine.faculties[faculty], program.String)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 2. Load the lifecycle state, quotas, allocations, bookings, waitlist, swaps and blocks of the semesters
+	for _, semester := range semesters {
+		engine.semester(semester)
+	}
+
+	filter, names, args := "", "", []any{}
+	if semesters != nil {
+		placeholders := "(?" + strings.Repeat(", ?", len(semesters)-1) + ")"
+		filter = " WHERE semester IN " + placeholders
+		names = " WHERE name IN " + placeholders
+		for _, semester := range semesters {
+			args = append(args, semester)
+		}
+	}
+
+	rows, err = tx.QueryContext(ctx, "SELECT name, state FROM semesters"+names, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var semester, state string
+		if err := rows.Scan(&semester, &state); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		engine.semester(semester).state = state
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, "SELECT semester, faculty, classrooms, laboratories FROM faculty_quotas"+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var semester, faculty string
+		var quota roomCount
+		if err := rows.Scan(&semester, &faculty, &quota.classrooms, &quota.laboratories); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		engine.semester(semester).quotas[faculty] = quota
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, "SELECT id, state, room_id, semester, faculty, program, adapted, offered_at, unplaced FROM room_allocations"+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var allocation memoryAllocation
+		var state, semester string
+		var room int
+		if err := rows.Scan(&allocation.id, &state, &room, &semester, &allocation.faculty, &allocation.program, &allocation.adapted, &allocation.offeredAt, &allocation.unplaced); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		allocation.room = rooms[room]
+		allocation.locked = state == "locked"
+		engine.semester(semester).allocations[room] = &allocation
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, "SELECT id, state, room_id, semester, faculty, program, weekday, start_hour, adapted, offered_at, unplaced FROM room_bookings"+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var booking memoryBooking
+		var state, semester string
+		var room int
+		if err := rows.Scan(&booking.id, &state, &room, &semester, &booking.faculty, &booking.program, &booking.weekday, &booking.start, &booking.adapted, &booking.offeredAt, &booking.unplaced); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		booking.room = rooms[room]
+		booking.locked = state == "locked"
+		engine.semester(semester).bookings[booking.block()] = &booking
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, "SELECT id, semester, faculty, program, classrooms, laboratories, min_capacity, equipment, building, created_at FROM waitlist"+filter+" ORDER BY id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var entry memoryWaitlistEntry
+		var semester string
+		if err := rows.Scan(&entry.id, &semester, &entry.faculty, &entry.program, &entry.classrooms, &entry.laboratories, &entry.minCapacity, &entry.equipment, &entry.building, &entry.createdAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		state := engine.semester(semester)
+		state.waitlist = append(state.waitlist, &entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 2.1 Load the swaps and the rooms each side gives
+	rows, err = tx.QueryContext(ctx, "SELECT id, semester, proposer, recipient, program, state, created_at FROM swaps"+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	swaps := make(map[int]*memorySwap)
+	for rows.Next() {
+		var swap memorySwap
+		var semester string
+		if err := rows.Scan(&swap.id, &semester, &swap.proposer, &swap.recipient, &swap.program, &swap.state, &swap.createdAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		swaps[swap.id] = &swap
+		engine.semester(semester).swaps[swap.id] = &swap
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, "SELECT sr.swap_id, sr.room_id, sr.faculty FROM swap_rooms sr JOIN swaps ON swaps.id = sr.swap_id"+filter+" ORDER BY sr.room_id", args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var id, room int
+		var faculty string
+		if err := rows.Scan(&id, &room, &faculty); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		swap := swaps[id]
+		if faculty == swap.proposer {
+			swap.offered = append(swap.offered, rooms[room])
+		} else {
+			swap.requested = append(swap.requested, rooms[room])
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 2.2 Load the rooms taken out of service
+	rows, err = tx.QueryContext(ctx, "SELECT semester, room_id, reason FROM room_blocks"+filter, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	for rows.Next() {
+		var semester, reason string
+		var room int
+		if err := rows.Scan(&semester, &room, &reason); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		engine.semester(semester).blocked[room] = reason
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// 3. New allocations, bookings, entries and swaps are numbered after the existing ones
+	if err := tx.QueryRowContext(ctx,
+		"SELECT COALESCE(MAX(id), 0) FROM (SELECT id FROM room_allocations UNION ALL SELECT id FROM room_bookings UNION ALL SELECT id FROM waitlist UNION ALL SELECT id FROM swaps)",
+	).Scan(&engine.sequence); err != nil {
+		return nil, err
+	}
+
+	// 4. Load the stored response of the request being retried, if any
+	if key, tracked := RequestKeyFromContext(ctx); tracked {
+		var stored memoryProcessedRequest
+		err := tx.QueryRowContext(ctx,
+			"SELECT type, response, created_at FROM processed_requests WHERE identity = ? AND request_id = ?",
+			key.Identity,
+			key.ID,
+		).Scan(&stored.kind, &stored.response, &stored.createdAt)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		} else if err == nil && stored.createdAt.After(time.Now().Add(-s.replayWindow)) {
+			engine.processed[key] = stored
+		}
+	}
+
+	return engine, nil
+}
+
+// save writes the differences between two versions of a semester.
+func (s *SqliteAllocationService) save(ctx context.Context, tx *sql.Tx, semester string, before *memorySemester, after *memorySemester) error {
+	// 0. Save the lifecycle state
+	if before.state != after.state {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO semesters (name, state) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET state = excluded.state, updated_at = CURRENT_TIMESTAMP",
+			semester,
+			after.state,
+		); err != nil {
+			return err
+		}
+	}
+
+	// 0.1 Save the quotas that changed
+	for faculty, quota := range after.quotas {
+		if old, ok := before.quotas[faculty]; ok && old == quota {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO faculty_quotas (semester, faculty, classrooms, laboratories) VALUES (?, ?, ?, ?) ON CONFLICT (semester, faculty) DO UPDATE SET classrooms = excluded.classrooms, laboratories = excluded.laboratories",
+			semester,
+			faculty,
+			quota.classrooms,
+			quota.laboratories,
+		); err != nil {
+			return err
+		}
+	}
+
+	// 1. Index the allocations by their own ID
+	previous := make(map[int]*memoryAllocation, len(before.allocations))
+	for _, allocation := range before.allocations {
+		previous[allocation.id] = allocation
+	}
+
+	current := make(map[int]bool, len(after.allocations))
+	for _, allocation := range after.allocations {
+		current[allocation.id] = true
+	}
+
+	// 2. Delete the released allocations
+	for id := range previous {
+		if !current[id] {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM room_allocations WHERE id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 3. Insert the new allocations and lock the confirmed ones
+	for _, allocation := range after.sorted() {
+		state := holdState(allocation.locked)
+
+		old, existed := previous[allocation.id]
+		if !existed {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO room_allocations (id, state, room_id, semester, faculty, program, adapted, offered_at, unplaced) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				allocation.id,
+				state,
+				allocation.room.ID,
+				semester,
+				allocation.faculty,
+				allocation.program,
+				allocation.adapted,
+				allocation.offeredAt,
+				allocation.unplaced,
+			); err != nil {
+				return err
+			}
+		} else if old.locked != allocation.locked || old.faculty != allocation.faculty || old.program != allocation.program || old.unplaced != allocation.unplaced {
+			// 3.1 Swapped rooms change hands as well
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE room_allocations SET state = ?, faculty = ?, program = ?, unplaced = ? WHERE id = ?",
+				state,
+				allocation.faculty,
+				allocation.program,
+				allocation.unplaced,
+				allocation.id,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 3.2 Time blocks are saved the same way, relocated ones are new bookings
+	blocks := make(map[int]*memoryBooking, len(before.bookings))
+	for _, booking := range before.bookings {
+		blocks[booking.id] = booking
+	}
+
+	booked := make(map[int]bool, len(after.bookings))
+	for _, booking := range after.bookings {
+		booked[booking.id] = true
+	}
+
+	for id := range blocks {
+		if !booked[id] {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM room_bookings WHERE id = ?", id); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, booking := range after.sortedBookings() {
+		state := holdState(booking.locked)
+
+		old, existed := blocks[booking.id]
+		if !existed {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO room_bookings (id, state, room_id, semester, faculty, program, weekday, start_hour, end_hour, adapted, offered_at, unplaced) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				booking.id,
+				state,
+				booking.room.ID,
+				semester,
+				booking.faculty,
+				booking.program,
+				booking.weekday,
+				booking.start,
+				booking.start+blockHours,
+				booking.adapted,
+				booking.offeredAt,
+				booking.unplaced,
+			); err != nil {
+				return err
+			}
+		} else if old.locked != booking.locked || old.unplaced != booking.unplaced {
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE room_bookings SET state = ?, unplaced = ? WHERE id = ?",
+				state,
+				booking.unplaced,
+				booking.id,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	// 3.3 Record what changed, as the Postgres triggers do
+	if err := s.insertEvents(ctx, tx, allocationEvents(semester, before, after, time.Now())); err != nil {
+		return err
+	}
+
+	// 4. Replace the waitlist entries that changed
+	entries := make(map[int]*memoryWaitlistEntry, len(before.waitlist))
+	for _, entry := range before.waitlist {
+		entries[entry.id] = entry
+	}
+
+	for _, entry := range after.waitlist {
+		old, existed := entries[entry.id]
+		delete(entries, entry.id)
+
+		if !existed {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO waitlist (id, semester, faculty, program, classrooms, laboratories, min_capacity, equipment, building, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+				entry.id,
+				semester,
+				entry.faculty,
+				entry.program,
+				entry.classrooms,
+				entry.laboratories,
+				entry.minCapacity,
+				entry.equipment,
+				entry.building,
+				entry.createdAt,
+			); err != nil {
+				return err
+			}
+		} else if old.classrooms != entry.classrooms || old.laboratories != entry.laboratories {
+			if _, err := tx.ExecContext(ctx,
+				"UPDATE waitlist SET classrooms = ?, laboratories = ? WHERE id = ?",
+				entry.classrooms,
+				entry.laboratories,
+				entry.id,
+			); err != nil {
+				return err
+			}
+		}
+	}
+
+	for id := range entries {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM waitlist WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+
+	// 5. Insert the new swaps and save the decisions
+	for id, swap := range after.swaps {
+		old, existed := before.swaps[id]
+		if existed {
+			if old.state != swap.state {
+				if _, err := tx.ExecContext(ctx, "UPDATE swaps SET state = ?, decided_at = CURRENT_TIMESTAMP WHERE id = ?", swap.state, id); err != nil {
+					return err
+				}
+			}
+
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO swaps (id, semester, proposer, recipient, program, state, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			id,
+			semester,
+			swap.proposer,
+			swap.recipient,
+			swap.program,
+			swap.state,
+			swap.createdAt,
+		); err != nil {
+			return err
+		}
+
+		for _, side := range []struct {
+			faculty string
+			rooms   []models.Room
+		}{{swap.proposer, swap.offered}, {swap.recipient, swap.requested}} {
+			for _, room := range side.rooms {
+				if _, err := tx.ExecContext(ctx, "INSERT INTO swap_rooms (swap_id, room_id, faculty) VALUES (?, ?, ?)", id, room.ID, side.faculty); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// 6. Block and unblock rooms
+	for room, reason := range after.blocked {
+		if old, existed := before.blocked[room]; existed && old == reason {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO room_blocks (semester, room_id, reason) VALUES (?, ?, ?) ON CONFLICT (semester, room_id) DO UPDATE SET reason = excluded.reason",
+			semester,
+			room,
+			reason,
+		); err != nil {
+			return err
+		}
+	}
+
+	for room := range before.blocked {
+		if _, kept := after.blocked[room]; kept {
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM room_blocks WHERE semester = ? AND room_id = ?", semester, room); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+)
+
+// sqliteWorkers opens the same database file once per worker, as separate
+// central processes sharing it would.
+func sqliteWorkers(t *testing.T, workers int, classrooms int) []*SqliteAllocationService {
+	t.Helper()
+
+	// 1. Every room seats the same, so any free room can take a relocation
+	rooms := GenerateRooms(classrooms, 0)
+	for i := range rooms {
+		rooms[i].Capacity = 30
+	}
+
+	path := filepath.Join(t.TempDir(), "central.db")
+	services := []*SqliteAllocationService{NewSqliteAllocationService(migrateSqlite(t, path))}
+	if _, err := services[0].Seed(context.Background(), rooms); err != nil {
+		t.Fatalf("seed sqlite: %v", err)
+	}
+
+	// 2. Open the file again for each other worker
+	for len(services) < workers {
+		db, err := sql.Open("sqlite", SqliteDSN(path))
+		if err != nil {
+			t.Fatalf("open sqlite: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		services = append(services, NewSqliteAllocationService(db))
+	}
+
+	return services
+}
+
+// sqliteHolders reads who holds each room of the semester, failing when a
+// room is held twice.
+func sqliteHolders(t *testing.T, service *SqliteAllocationService, semester string) map[string][]string {
+	t.Helper()
+
+	rows, err := service.db.Query(
+		"SELECT r.name, a.faculty, a.state FROM room_allocations a JOIN rooms r ON r.id = a.room_id WHERE a.semester = ? ORDER BY r.name",
+		semester,
+	)
+	if err != nil {
+		t.Fatalf("read allocations: %v", err)
+	}
+	defer rows.Close()
+
+	holders := map[string][]string{}
+	seen := map[string]string{}
+	for rows.Next() {
+		var room, faculty, state string
+		if err := rows.Scan(&room, &faculty, &state); err != nil {
+			t.Fatalf("read allocations: %v", err)
+		}
+
+		if holder, taken := seen[room]; taken {
+			t.Errorf("room %s held by %s and %s", room, holder, faculty)
+		}
+		seen[room] = faculty
+
+		holders[faculty] = append(holders[faculty], room+":"+state)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("read allocations: %v", err)
+	}
+
+	return holders
+}
+
+func TestSqliteConcurrentLifecycle(t *testing.T) {
+	ctx := context.Background()
+	services := sqliteWorkers(t, 3, 30)
+
+	faculties := []string{}
+	for i := 0; i < 8; i++ {
+		faculties = append(faculties, fmt.Sprintf("Facultad-%d", i))
+	}
+
+	semester := "2025-1"
+	if _, err := services[0].CreateSemester(ctx, &models.SemesterRequest{Semester: semester, Open: true}); err != nil {
+		t.Fatalf("create semester: %v", err)
+	}
+
+	for _, faculty := range faculties {
+		if _, err := services[0].RegisterFaculty(ctx, &models.RegisterFacultyRequest{Faculty: faculty, Programs: []string{"Sistemas"}}); err != nil {
+			t.Fatalf("register %s: %v", faculty, err)
+		}
+	}
+
+	// 1. Every faculty takes two rooms and gives them back, over and over,
+	// keeping the last two. Each release must return both rooms, wherever
+	// they were relocated in the meantime
+	var waitgroup sync.WaitGroup
+	for i, faculty := range faculties {
+		waitgroup.Add(1)
+		go func() {
+			defer waitgroup.Done()
+
+			service := services[i%len(services)]
+			for round := 0; round <= 8; round++ {
+				if _, err := service.Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: faculty, Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 2}}}); err != nil {
+					t.Errorf("%s: allocate: %v", faculty, err)
+					return
+				}
+
+				// 1.1 Decline every other round, confirm and cancel the rest
+				if round%2 == 1 {
+					declined, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: faculty, Accept: false})
+					if err != nil || len(declined.Released) != 2 {
+						t.Errorf("%s: decline: %v (released %v)", faculty, err, declined)
+						return
+					}
+
+					continue
+				}
+
+				if _, err := service.Confirm(ctx, &models.ConfirmRequest{Semester: semester, Faculty: faculty, Accept: true}); err != nil {
+					t.Errorf("%s: confirm: %v", faculty, err)
+					return
+				}
+
+				if round == 8 {
+					return
+				}
+
+				cancelled, err := service.Cancel(ctx, &models.CancelRequest{Semester: semester, Faculty: faculty})
+				if err != nil || len(cancelled.Released) != 2 {
+					t.Errorf("%s: cancel: %v (released %v)", faculty, err, cancelled)
+					return
+				}
+			}
+		}()
+	}
+
+	// 2. Meanwhile, rooms in use go out of service and are relocated
+	out := map[string]bool{}
+	relocated := 0
+
+	waitgroup.Add(1)
+	go func() {
+		defer waitgroup.Done()
+
+		service := services[len(services)-1]
+		for len(out) < 5 {
+			var room string
+			err := service.db.QueryRow(
+				"SELECT r.name FROM room_allocations a JOIN rooms r ON r.id = a.room_id WHERE a.semester = ? AND r.id NOT IN (SELECT room_id FROM room_outages) ORDER BY random() LIMIT 1",
+				semester,
+			).Scan(&room)
+			if err == sql.ErrNoRows {
+				time.Sleep(time.Millisecond)
+				continue
+			} else if err != nil {
+				t.Errorf("pick room: %v", err)
+				return
+			}
+
+			// 2.1 The outage has started, so the room is relocated right away,
+			// unless the faculty let it go in the meantime
+			response, err := service.DeclareOutage(ctx, &models.OutageRequest{Room: room, StartsAt: time.Now().Add(-time.Minute), EndsAt: time.Now().Add(time.Hour), Reason: "test"})
+			if err != nil {
+				t.Errorf("declare outage on %s: %v", room, err)
+				return
+			}
+			out[room] = true
+
+			for _, relocation := range response.Relocations {
+				if len(relocation.Unplaced) != 0 {
+					t.Errorf("outage on %s left %v unplaced", room, relocation.Unplaced)
+				}
+
+				relocated += len(relocation.Relocated)
+			}
+		}
+	}()
+
+	waitgroup.Wait()
+	if t.Failed() {
+		return
+	}
+
+	if relocated == 0 {
+		t.Errorf("no allocation was relocated, the test raced past every outage")
+	}
+
+	// 3. Each faculty holds exactly the two rooms it confirmed last, none of
+	// them out of service
+	holders := sqliteHolders(t, services[0], semester)
+	for _, faculty := range faculties {
+		rooms := holders[faculty]
+		if len(rooms) != 2 {
+			t.Errorf("%s holds %v, want two rooms", faculty, rooms)
+		}
+
+		for _, room := range rooms {
+			name, state, _ := strings.Cut(room, ":")
+			if state != "locked" {
+				t.Errorf("%s holds %s %s, want locked", faculty, name, state)
+			}
+
+			if out[name] {
+				t.Errorf("%s still holds %s, which is out of service", faculty, name)
+			}
+		}
+	}
+}
+
+func TestSqliteConcurrentAllocations(t *testing.T) {
+	ctx := context.Background()
+	services := sqliteWorkers(t, 4, 20)
+
+	semester := "2025-1"
+	if _, err := services[0].CreateSemester(ctx, &models.SemesterRequest{Semester: semester, Open: true}); err != nil {
+		t.Fatalf("create semester: %v", err)
+	}
+
+	faculties := []string{}
+	for i := 0; i < 12; i++ {
+		faculty := fmt.Sprintf("Facultad-%d", i)
+		if _, err := services[0].RegisterFaculty(ctx, &models.RegisterFacultyRequest{Faculty: faculty, Programs: []string{"Sistemas"}}); err != nil {
+			t.Fatalf("register %s: %v", faculty, err)
+		}
+
+		faculties = append(faculties, faculty)
+	}
+
+	// 1. Twelve faculties race for three rooms each, there are only twenty
+	var waitgroup sync.WaitGroup
+	granted := make([]int, len(faculties))
+	for i, faculty := range faculties {
+		waitgroup.Add(1)
+		go func() {
+			defer waitgroup.Done()
+
+			response, err := services[i%len(services)].Allocate(ctx, &models.AllocateRequest{Semester: semester, Faculty: faculty, Partial: true, Programs: []models.ProgramInfo{{Name: "Sistemas", Classrooms: 3}}})
+			if err != nil {
+				t.Errorf("%s: allocate: %v", faculty, err)
+				return
+			}
+
+			granted[i] = len(offeredRooms(response.Programs))
+		}()
+	}
+	waitgroup.Wait()
+
+	// 2. What each faculty was told matches what was stored, every room once
+	holders := sqliteHolders(t, services[0], semester)
+
+	total := 0
+	for i, faculty := range faculties {
+		if len(holders[faculty]) != granted[i] {
+			t.Errorf("%s was granted %d rooms but holds %v", faculty, granted[i], holders[faculty])
+		}
+
+		total += granted[i]
+	}
+
+	if total != 20 {
+		t.Errorf("%d rooms handed out, want 20", total)
+	}
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+	"github.com/jackc/pgx/v5"
+)
+
+type SwapService interface {
+	ProposeSwap(ctx context.Context, request *models.SwapProposal) (*models.Swap, error)
+	RespondSwap(ctx context.Context, request *models.SwapDecision) (*models.Swap, error)
+	ListSwaps(ctx context.Context, request *models.SwapListRequest) (*models.SwapListResponse, error)
+}
+
+// ProposeSwap records a proposal to trade confirmed rooms with another
+// faculty. Nothing changes hands until the other faculty accepts it.
+func (s *SqlcAllocationService) ProposeSwap(ctx context.Context, request *models.SwapProposal) (*models.Swap, error) {
+	if err := validateSwap(request); err != nil {
+		return nil, err
+	}
+
+	var notifications []*models.Notification
+
+	response := &models.Swap{}
+	if err := s.transaction(ctx, "swap-propose", response, func(querier *repository.Queries) error {
+		// 0. Only open semesters take swaps, between registered faculties
+		if err := s.requireOpen(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		if err := s.checkRegistry(ctx, querier, request.Faculty, []string{request.Program}); err != nil {
+			return err
+		}
+
+		if err := s.checkRegistry(ctx, querier, request.To, nil); err != nil {
+			return err
+		}
+
+		// 1. Each side must hold the rooms it gives, confirmed
+		offered, err := s.swapRooms(ctx, querier, request.Semester, request.Faculty, request.Offered)
+		if err != nil {
+			return err
+		}
+
+		requested, err := s.swapRooms(ctx, querier, request.Semester, request.To, request.Requested)
+		if err != nil {
+			return err
+		}
+
+		// 2. Record the proposal
+		created, err := querier.CreateSwap(ctx, repository.CreateSwapParams{
+			Semester:  request.Semester,
+			Proposer:  request.Faculty,
+			Recipient: request.To,
+			Program:   request.Program,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, side := range []struct {
+			faculty string
+			rooms   []facultyRoom
+		}{{request.Faculty, offered}, {request.To, requested}} {
+			for _, room := range side.rooms {
+				if err := querier.AddSwapRoom(ctx, repository.AddSwapRoomParams{
+					SwapID:  created.ID,
+					RoomID:  int32(room.id),
+					Faculty: side.faculty,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		*response = models.Swap{
+			ID:        int(created.ID),
+			Semester:  request.Semester,
+			Proposer:  request.Faculty,
+			Recipient: request.To,
+			Program:   request.Program,
+			State:     models.SwapPending,
+			CreatedAt: created.CreatedAt.Time,
+			Offered:   roomNames(offered),
+			Requested: roomNames(requested),
+		}
+
+		// 3. Tell the other faculty
+		notifications = []*models.Notification{swapNotification("swap-proposed", request.To, response)}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+// RespondSwap accepts or rejects a swap proposal. An accepted swap moves the
+// rooms of both sides at once, or fails if any of them changed hands since
+// it was proposed.
+func (s *SqlcAllocationService) RespondSwap(ctx context.Context, request *models.SwapDecision) (*models.Swap, error) {
+	var notifications []*models.Notification
+
+	response := &models.Swap{}
+	if err := s.transaction(ctx, "swap-respond", response, func(querier *repository.Queries) error {
+		// 1. Find the swap and its rooms
+		swap, err := querier.GetSwap(ctx, int32(request.Swap))
+		if errors.Is(err, pgx.ErrNoRows) || (err == nil && swap.Semester != request.Semester) {
+			return fmt.Errorf("swap %d does not exist in semester %q", request.Swap, request.Semester)
+		} else if err != nil {
+			return err
+		}
+
+		rows, err := querier.GetSwapRooms(ctx, swap.ID)
+		if err != nil {
+			return err
+		}
+
+		*response = models.Swap{
+			ID:        int(swap.ID),
+			Semester:  swap.Semester,
+			Proposer:  swap.Proposer,
+			Recipient: swap.Recipient,
+			Program:   swap.Program,
+			State:     string(swap.State),
+			CreatedAt: swap.CreatedAt.Time,
+			Offered:   []string{},
+			Requested: []string{},
+		}
+
+		for _, row := range rows {
+			if row.Faculty == swap.Proposer {
+				response.Offered = append(response.Offered, row.Name)
+			} else {
+				response.Requested = append(response.Requested, row.Name)
+			}
+		}
+
+		if err := checkSwapDecision(response, request); err != nil {
+			return err
+		}
+
+		// 2. Rejections only close the proposal
+		if !request.Accept {
+			if err := querier.DecideSwap(ctx, repository.DecideSwapParams{
+				ID:    swap.ID,
+				State: repository.SwapStateRejected,
+			}); err != nil {
+				return err
+			}
+
+			response.State = models.SwapRejected
+			notifications = []*models.Notification{swapNotification("swap-rejected", swapCounterpart(response, request.Faculty), response)}
+			return nil
+		}
+
+		// 3. Only open semesters take swaps, into registered programs
+		if err := s.requireOpen(ctx, querier, request.Semester); err != nil {
+			return err
+		}
+
+		if err := s.checkRegistry(ctx, querier, swap.Recipient, []string{request.Program}); err != nil {
+			return err
+		}
+
+		// 3.1 Each side must still hold the rooms it gives
+		offered, err := s.swapRooms(ctx, querier, swap.Semester, swap.Proposer, response.Offered)
+		if err != nil {
+			return staleSwap(request.Swap, err)
+		}
+
+		requested, err := s.swapRooms(ctx, querier, swap.Semester, swap.Recipient, response.Requested)
+		if err != nil {
+			return staleSwap(request.Swap, err)
+		}
+
+		// 3.2 Both faculties must stay within their quotas
+		if err := s.checkQuota(ctx, querier, swap.Semester, swap.Recipient, swapCount(offered), swapCount(requested)); err != nil {
+			return err
+		}
+
+		if err := s.checkQuota(ctx, querier, swap.Semester, swap.Proposer, swapCount(requested), swapCount(offered)); err != nil {
+			return err
+		}
+
+		// 4. Move the rooms of both sides
+		for _, transfer := range []repository.TransferRoomsParams{
+			{Recipient: swap.Recipient, Program: request.Program, Semester: swap.Semester, Faculty: swap.Proposer, Rooms: swapRoomIDs(offered)},
+			{Recipient: swap.Proposer, Program: swap.Program, Semester: swap.Semester, Faculty: swap.Recipient, Rooms: swapRoomIDs(requested)},
+		} {
+			moved, err := querier.TransferRooms(ctx, transfer)
+			if err != nil {
+				return err
+			}
+
+			if int(moved) != len(transfer.Rooms) {
+				return staleSwap(request.Swap, fmt.Errorf("%d of the rooms of faculty %q changed hands", len(transfer.Rooms)-int(moved), transfer.Faculty))
+			}
+		}
+
+		if err := querier.DecideSwap(ctx, repository.DecideSwapParams{
+			ID:    swap.ID,
+			State: repository.SwapStateAccepted,
+		}); err != nil {
+			return err
+		}
+
+		response.State = models.SwapAccepted
+		notifications = []*models.Notification{swapNotification("swap-accepted", swap.Proposer, response)}
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	s.notify(notifications)
+	return response, nil
+}
+
+func (s *SqlcAllocationService) ListSwaps(ctx context.Context, request *models.SwapListRequest) (*models.SwapListResponse, error) {
+	// 1. Create new querier
+	querier := repository.New(s.pool)
+
+	// 2. Get the pending swaps the faculty takes part in
+	rows, err := querier.ListPendingSwaps(ctx, repository.ListPendingSwapsParams{
+		Semester: request.Semester,
+		Faculty:  request.Faculty,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Generate response
+	response := &models.SwapListResponse{
+		Semester: request.Semester,
+		Faculty:  request.Faculty,
+		Swaps:    []models.Swap{},
+	}
+
+	for _, row := range rows {
+		swap := models.Swap{
+			ID:        int(row.ID),
+			Semester:  row.Semester,
+			Proposer:  row.Proposer,
+			Recipient: row.Recipient,
+			Program:   row.Program,
+			State:     string(row.State),
+			CreatedAt: row.CreatedAt.Time,
+			Offered:   []string{},
+			Requested: []string{},
+		}
+
+		rooms, err := querier.GetSwapRooms(ctx, row.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, room := range rooms {
+			if room.Faculty == row.Proposer {
+				swap.Offered = append(swap.Offered, room.Name)
+			} else {
+				swap.Requested = append(swap.Requested, room.Name)
+			}
+		}
+
+		response.Swaps = append(response.Swaps, swap)
+	}
+
+	return response, nil
+}
+
+// swapRooms finds the named rooms among the rooms a faculty confirmed.
+func (s *SqlcAllocationService) swapRooms(ctx context.Context, querier *repository.Queries, semester string, faculty string, names []string) ([]facultyRoom, error) {
+	held, err := s.facultyRooms(ctx, querier, semester, faculty, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return pickRooms(held, semester, faculty, names)
+}
+
+// validateSwap checks the faculties and rooms of a swap proposal. A swap
+// trades rooms both ways, so each side must give at least one.
+func validateSwap(request *models.SwapProposal) error {
+	if strings.TrimSpace(request.Faculty) == "" || strings.TrimSpace(request.To) == "" {
+		return fmt.Errorf("both faculties of a swap are required")
+	}
+
+	if request.Faculty == request.To {
+		return fmt.Errorf("faculty %q cannot swap rooms with itself", request.Faculty)
+	}
+
+	if strings.TrimSpace(request.Program) == "" {
+		return fmt.Errorf("program is required to receive the requested rooms")
+	}
+
+	if len(request.Offered) == 0 || len(request.Requested) == 0 {
+		return fmt.Errorf("a swap must offer and request at least one room")
+	}
+
+	return nil
+}
+
+// checkSwapDecision fails unless the faculty may take the decision on a
+// pending swap: only the recipient accepts, while either side may reject.
+func checkSwapDecision(swap *models.Swap, request *models.SwapDecision) error {
+	if swap.State != models.SwapPending {
+		return fmt.Errorf("swap %d is already %s", swap.ID, swap.State)
+	}
+
+	if request.Faculty != swap.Proposer && request.Faculty != swap.Recipient {
+		return fmt.Errorf("swap %d does not involve faculty %q", swap.ID, request.Faculty)
+	}
+
+	if request.Accept && request.Faculty != swap.Recipient {
+		return fmt.Errorf("only faculty %q can accept swap %d", swap.Recipient, swap.ID)
+	}
+
+	if request.Accept && strings.TrimSpace(request.Program) == "" {
+		return fmt.Errorf("program is required to accept swap %d", swap.ID)
+	}
+
+	return nil
+}
+
+// pickRooms returns the named rooms among the held ones, failing on the first
+// one the faculty does not hold.
+func pickRooms(held []facultyRoom, semester string, faculty string, names []string) ([]facultyRoom, error) {
+	byName := make(map[string]facultyRoom, len(held))
+	for _, room := range held {
+		byName[room.name] = room
+	}
+
+	rooms := []facultyRoom{}
+	for _, name := range names {
+		room, ok := byName[name]
+		if !ok {
+			return nil, fmt.Errorf("room %q is not confirmed for faculty %q in semester %q", name, faculty, semester)
+		}
+
+		delete(byName, name)
+		rooms = append(rooms, room)
+	}
+
+	return rooms, nil
+}
+
+// swapCount counts the classrooms and laboratories (adapted classrooms
+// included) among the rooms.
+func swapCount(rooms []facultyRoom) roomCount {
+	count := roomCount{}
+	for _, room := range rooms {
+		if room.lab() {
+			count.laboratories++
+		} else {
+			count.classrooms++
+		}
+	}
+
+	return count
+}
+
+func swapRoomIDs(rooms []facultyRoom) []int32 {
+	ids := make([]int32, len(rooms))
+	for i, room := range rooms {
+		ids[i] = int32(room.id)
+	}
+
+	return ids
+}
+
+func roomNames(rooms []facultyRoom) []string {
+	names := make([]string, len(rooms))
+	for i, room := range rooms {
+		names[i] = room.name
+	}
+
+	return names
+}
+
+// swapCounterpart returns the other faculty of a swap.
+func swapCounterpart(swap *models.Swap, faculty string) string {
+	if faculty == swap.Proposer {
+		return swap.Recipient
+	}
+
+	return swap.Proposer
+}
+
+func staleSwap(id int, err error) error {
+	return fmt.Errorf("swap %d can no longer be applied: %w", id, err)
+}
+
+// swapNotification tells a faculty about a swap, listing the rooms it would
+// receive.
+func swapNotification(kind string, faculty string, swap *models.Swap) *models.Notification {
+	rooms := swap.Requested
+	if faculty == swap.Recipient {
+		rooms = swap.Offered
+	}
+
+	return &models.Notification{
+		Type:     kind,
+		Semester: swap.Semester,
+		Faculty:  faculty,
+		Swap:     swap.ID,
+		Rooms:    rooms,
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+
+	"github.com/foxinuni/distribuidos-central/internal/models"
+	"github.com/foxinuni/distribuidos-central/internal/repository"
+)
+
+type WaitlistService interface {
+	WaitlistStatus(ctx context.Context, request *models.WaitlistStatusRequest) (*models.WaitlistStatusResponse, error)
+}
+
+func (s *SqlcAllocationService) WaitlistStatus(ctx context.Context, request *models.WaitlistStatusRequest) (*models.WaitlistStatusResponse, error) {
+	// 1. Create new querier
+	querier := repository.New(s.pool)
+
+	// 2. Get the faculty's pending entries
+	rows, err := querier.GetWaitlistByFacultySemester(ctx, repository.GetWaitlistByFacultySemesterParams{
+		Faculty:  request.Faculty,
+		Semester: request.Semester,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Generate response
+	response := &models.WaitlistStatusResponse{
+		Semester: request.Semester,
+		Faculty:  request.Faculty,
+		Entries:  []models.WaitlistEntry{},
+	}
+
+	for _, row := range rows {
+		response.Entries = append(response.Entries, models.WaitlistEntry{
+			Program:      row.Program,
+			Classrooms:   int(row.Classrooms),
+			Laboratories: int(row.Laboratories),
+			Position:     int(row.Position),
+			Since:        row.CreatedAt.Time,
+		})
+	}
+
+	return response, nil
+}
+
+// enqueue adds the unmet demand of a request to the end of the waitlist.
+func (s *SqlcAllocationService) enqueue(ctx context.Context, querier *repository.Queries, request *models.AllocateRequest, shortfall []models.ProgramShortfall) error {
+	for _, missing := range shortfall {
+		program := programInfo(request.Programs, missing.Name)
+		if err := querier.AddToWaitlist(ctx, repository.AddToWaitlistParams{
+			Semester:     request.Semester,
+			Faculty:      request.Faculty,
+			Program:      missing.Name,
+			Classrooms:   int32(max(missing.Classrooms, 0)),
+			Laboratories: int32(max(missing.Laboratories, 0)),
+			MinCapacity:  int32(program.MinCapacity),
+			Equipment:    program.Equipment,
+			Building:     program.Building,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// programInfo returns the requested program with the given name, whose
+// requirements the waitlist keeps.
+func programInfo(programs []models.ProgramInfo, name string) models.ProgramInfo {
+	for _, program := range programs {
+		if program.Name == name {
+			return program
+		}
+	}
+
+	return models.ProgramInfo{Name: name}
+}
+
+// processWaitlist hands the free rooms of a semester to the waitlist, oldest
+// entries first, and returns one notification per faculty that got rooms.
+func (s *SqlcAllocationService) processWaitlist(ctx context.Context, querier *repository.Queries, semester string) ([]*models.Notification, error) {
+	// 1. Rank the free rooms with the semester's strategy (the preferred
+	// building of each entry is moved to the front by process_waitlist)
+	classrooms, laboratories, err := s.freeCandidates(ctx, querier, semester)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := s.strategyFor(semester)
+	classroomRanking := roomIDs(strategy.Rank(classrooms, models.ProgramInfo{}))
+	laboratoryRanking := append(roomIDs(strategy.Rank(laboratories, models.ProgramInfo{})), classroomRanking...)
+
+	// 2. Hand the rooms out, oldest entries first
+	rows, err := querier.ProcessWaitlist(ctx, repository.ProcessWaitlistParams{
+		Semester:          semester,
+		ClassroomRanking:  classroomRanking,
+		LaboratoryRanking: laboratoryRanking,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 3. Tell each faculty what it got
+	notifications := []*models.Notification{}
+	byFaculty := map[string]*models.Notification{}
+	for _, row := range rows {
+		notification, ok := byFaculty[row.Faculty]
+		if !ok {
+			notification = &models.Notification{
+				Type:     "waitlist-granted",
+				Semester: semester,
+				Faculty:  row.Faculty,
+			}
+			byFaculty[row.Faculty] = notification
+			notifications = append(notifications, notification)
+		}
+
+		notification.Programs = append(notification.Programs, models.ProgramInfo{
+			Name:         row.Program,
+			Classrooms:   int(row.Classrooms),
+			Laboratories: int(row.Laboratories),
+		})
+	}
+
+	return notifications, nil
+}
+
+func (s *SqlcAllocationService) notify(notifications []*models.Notification) {
+	for _, notification := range notifications {
+		s.notifier.Notify(notification)
+	}
+}
@@ -0,0 +1,9 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS enforce_adapted_logic_update;
+DROP TRIGGER IF EXISTS enforce_adapted_logic_insert;
+
+-- Drop tables
+DROP TABLE IF EXISTS waitlist;
+DROP TABLE IF EXISTS processed_requests;
+DROP TABLE IF EXISTS room_allocations;
+DROP TABLE IF EXISTS rooms;
@@ -0,0 +1,64 @@
+-- SQLite equivalent of the Postgres migrations. The allocation logic that
+-- lives in PL/pgSQL functions there is done in Go by SqliteAllocationService.
+
+-- Create rooms table
+CREATE TABLE IF NOT EXISTS rooms (
+    id INTEGER PRIMARY KEY,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL CHECK (type IN ('classroom', 'laboratory')),
+    capacity INTEGER NOT NULL DEFAULT 40,
+    building TEXT NOT NULL DEFAULT '',
+    floor INTEGER NOT NULL DEFAULT 1,
+    equipment TEXT NOT NULL DEFAULT '' -- comma separated tags
+);
+
+-- Create room_allocations table
+CREATE TABLE IF NOT EXISTS room_allocations (
+    id INTEGER PRIMARY KEY,
+    state TEXT NOT NULL CHECK (state IN ('awaiting', 'locked')),
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    semester TEXT NOT NULL,
+    faculty TEXT NOT NULL,
+    program TEXT NOT NULL,
+    adapted BOOLEAN NOT NULL DEFAULT FALSE,
+    offered_at DATETIME NOT NULL,
+    UNIQUE (semester, room_id)
+);
+
+-- Laboratory rooms cannot be adapted
+CREATE TRIGGER IF NOT EXISTS enforce_adapted_logic_insert
+BEFORE INSERT ON room_allocations
+FOR EACH ROW
+WHEN NEW.adapted AND (SELECT type FROM rooms WHERE id = NEW.room_id) = 'laboratory'
+BEGIN
+    SELECT RAISE(ABORT, 'Laboratory rooms cannot be adapted.');
+END;
+
+CREATE TRIGGER IF NOT EXISTS enforce_adapted_logic_update
+BEFORE UPDATE ON room_allocations
+FOR EACH ROW
+WHEN NEW.adapted AND (SELECT type FROM rooms WHERE id = NEW.room_id) = 'laboratory'
+BEGIN
+    SELECT RAISE(ABORT, 'Laboratory rooms cannot be adapted.');
+END;
+
+-- Create processed_requests table (responses already sent, keyed by client request)
+CREATE TABLE IF NOT EXISTS processed_requests (
+    identity TEXT NOT NULL,
+    request_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    response BLOB NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (identity, request_id)
+);
+
+-- Create waitlist table (unmet program demand, served in arrival order)
+CREATE TABLE IF NOT EXISTS waitlist (
+    id INTEGER PRIMARY KEY,
+    semester TEXT NOT NULL,
+    faculty TEXT NOT NULL,
+    program TEXT NOT NULL,
+    classrooms INTEGER NOT NULL DEFAULT 0,
+    laboratories INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL
+);
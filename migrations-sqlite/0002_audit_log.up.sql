@@ -0,0 +1,15 @@
+-- Create audit_log table (every request received, who sent it and how it ended)
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY,
+    created_at DATETIME NOT NULL,
+    identity TEXT NOT NULL,
+    request_id INTEGER NOT NULL,
+    faculty TEXT NOT NULL DEFAULT '',
+    type TEXT NOT NULL,
+    payload_hash TEXT NOT NULL,
+    outcome TEXT NOT NULL,
+    error TEXT NOT NULL DEFAULT '',
+    latency_us INTEGER NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_faculty_idx ON audit_log (faculty, created_at);
@@ -0,0 +1,13 @@
+-- Create semesters table (only open semesters accept allocations)
+CREATE TABLE IF NOT EXISTS semesters (
+    name TEXT PRIMARY KEY,
+    state TEXT NOT NULL DEFAULT 'draft' CHECK (state IN ('draft', 'open', 'closed', 'archived')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Semesters already in use stay open
+INSERT OR IGNORE INTO semesters (name, state)
+SELECT semester, 'open' FROM room_allocations
+UNION
+SELECT semester, 'open' FROM waitlist;
@@ -0,0 +1,4 @@
+-- Drop tables
+DROP TABLE IF EXISTS faculty_quotas;
+DROP TABLE IF EXISTS programs;
+DROP TABLE IF EXISTS faculties;
@@ -0,0 +1,31 @@
+-- Create faculties table
+CREATE TABLE IF NOT EXISTS faculties (
+    name TEXT PRIMARY KEY
+);
+
+-- Create programs table (the programs each faculty may request rooms for)
+CREATE TABLE IF NOT EXISTS programs (
+    faculty TEXT NOT NULL REFERENCES faculties(name),
+    name TEXT NOT NULL,
+    PRIMARY KEY (faculty, name)
+);
+
+-- Create faculty_quotas table (most rooms a faculty may hold in a semester)
+CREATE TABLE IF NOT EXISTS faculty_quotas (
+    semester TEXT NOT NULL REFERENCES semesters(name),
+    faculty TEXT NOT NULL REFERENCES faculties(name),
+    classrooms INTEGER NOT NULL CHECK (classrooms >= 0),
+    laboratories INTEGER NOT NULL CHECK (laboratories >= 0),
+    PRIMARY KEY (semester, faculty)
+);
+
+-- Faculties and programs already in use stay registered
+INSERT OR IGNORE INTO faculties (name)
+SELECT faculty FROM room_allocations
+UNION
+SELECT faculty FROM waitlist;
+
+INSERT OR IGNORE INTO programs (faculty, name)
+SELECT faculty, program FROM room_allocations
+UNION
+SELECT faculty, program FROM waitlist;
@@ -0,0 +1,3 @@
+-- Drop tables
+DROP TABLE IF EXISTS swap_rooms;
+DROP TABLE IF EXISTS swaps;
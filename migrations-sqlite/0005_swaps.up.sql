@@ -0,0 +1,22 @@
+-- Create swaps table (a faculty offers some of its rooms to another faculty in
+-- exchange for some of the other faculty's rooms)
+CREATE TABLE IF NOT EXISTS swaps (
+    id INTEGER PRIMARY KEY,
+    semester TEXT NOT NULL REFERENCES semesters(name),
+    proposer TEXT NOT NULL REFERENCES faculties(name),
+    recipient TEXT NOT NULL REFERENCES faculties(name),
+    program TEXT NOT NULL,
+    state TEXT NOT NULL DEFAULT 'pending' CHECK (state IN ('pending', 'accepted', 'rejected')),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    decided_at DATETIME,
+    FOREIGN KEY (proposer, program) REFERENCES programs(faculty, name),
+    CHECK (proposer <> recipient)
+);
+
+-- Create swap_rooms table (the rooms each side of a swap gives)
+CREATE TABLE IF NOT EXISTS swap_rooms (
+    swap_id INTEGER NOT NULL REFERENCES swaps(id) ON DELETE CASCADE,
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    faculty TEXT NOT NULL REFERENCES faculties(name),
+    PRIMARY KEY (swap_id, room_id)
+);
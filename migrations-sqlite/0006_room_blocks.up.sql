@@ -0,0 +1,8 @@
+-- Create room_blocks table (rooms taken out of service for a semester)
+CREATE TABLE IF NOT EXISTS room_blocks (
+    semester TEXT NOT NULL REFERENCES semesters(name),
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (semester, room_id)
+);
@@ -0,0 +1,12 @@
+-- Create room_outages table (periods a room is under maintenance or out of service)
+CREATE TABLE IF NOT EXISTS room_outages (
+    id INTEGER PRIMARY KEY,
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    starts_at DATETIME NOT NULL,
+    ends_at DATETIME NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS room_outages_room_idx ON room_outages (room_id, ends_at);
@@ -0,0 +1,4 @@
+-- Drop the requirement columns
+ALTER TABLE waitlist DROP COLUMN building;
+ALTER TABLE waitlist DROP COLUMN equipment;
+ALTER TABLE waitlist DROP COLUMN min_capacity;
@@ -0,0 +1,4 @@
+-- Waitlist entries keep the requirements of the program
+ALTER TABLE waitlist ADD COLUMN min_capacity INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE waitlist ADD COLUMN equipment TEXT NOT NULL DEFAULT '';
+ALTER TABLE waitlist ADD COLUMN building TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Drop the detail columns
+ALTER TABLE audit_log DROP COLUMN room;
+ALTER TABLE audit_log DROP COLUMN program;
+ALTER TABLE audit_log DROP COLUMN semester;
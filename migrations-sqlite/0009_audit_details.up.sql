@@ -0,0 +1,4 @@
+-- Admin changes record what they touched
+ALTER TABLE audit_log ADD COLUMN semester TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN program TEXT NOT NULL DEFAULT '';
+ALTER TABLE audit_log ADD COLUMN room TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Drop the unplaced notices
+ALTER TABLE room_allocations DROP COLUMN unplaced;
@@ -0,0 +1,3 @@
+-- Remember which holders were told no equivalent room was free for their room
+-- out of service
+ALTER TABLE room_allocations ADD COLUMN unplaced INTEGER NOT NULL DEFAULT 0;
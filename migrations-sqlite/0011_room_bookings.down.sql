@@ -0,0 +1,3 @@
+-- Drop tables
+DROP TRIGGER IF EXISTS enforce_adapted_booking_insert;
+DROP TABLE IF EXISTS room_bookings;
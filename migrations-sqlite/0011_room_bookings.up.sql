@@ -0,0 +1,30 @@
+-- Create room_bookings table (weekly time blocks held instead of whole rooms).
+-- Blocks all last two hours and start at the same hours, so two bookings of a
+-- room overlap only when they hold the same block. Writers take the database
+-- lock when they begin, so the service checks the overlaps of a program itself
+CREATE TABLE IF NOT EXISTS room_bookings (
+    id INTEGER PRIMARY KEY,
+    state TEXT NOT NULL CHECK (state IN ('awaiting', 'locked')),
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    semester TEXT NOT NULL REFERENCES semesters(name),
+    faculty TEXT NOT NULL,
+    program TEXT NOT NULL,
+    weekday INTEGER NOT NULL CHECK (weekday BETWEEN 1 AND 6),
+    start_hour INTEGER NOT NULL,
+    end_hour INTEGER NOT NULL,
+    adapted BOOLEAN NOT NULL DEFAULT FALSE,
+    offered_at DATETIME NOT NULL,
+    unplaced INTEGER NOT NULL DEFAULT 0,
+    CHECK (end_hour > start_hour),
+    UNIQUE (semester, room_id, weekday, start_hour),
+    FOREIGN KEY (faculty, program) REFERENCES programs(faculty, name)
+);
+
+-- Laboratory rooms cannot be adapted
+CREATE TRIGGER IF NOT EXISTS enforce_adapted_booking_insert
+BEFORE INSERT ON room_bookings
+FOR EACH ROW
+WHEN NEW.adapted AND (SELECT type FROM rooms WHERE id = NEW.room_id) = 'laboratory'
+BEGIN
+    SELECT RAISE(ABORT, 'Laboratory rooms cannot be adapted.');
+END;
@@ -0,0 +1,4 @@
+-- Drop tables
+DROP TRIGGER IF EXISTS allocation_events_no_delete;
+DROP TRIGGER IF EXISTS allocation_events_no_update;
+DROP TABLE IF EXISTS allocation_events;
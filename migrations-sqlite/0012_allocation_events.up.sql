@@ -0,0 +1,32 @@
+-- Create allocation_events table (append-only history of room_allocations and
+-- room_bookings, written by the service as it saves them). Weekday and hours
+-- are NULL for rooms held the whole semester
+CREATE TABLE IF NOT EXISTS allocation_events (
+    id INTEGER PRIMARY KEY,
+    occurred_at DATETIME NOT NULL,
+    type TEXT NOT NULL,
+    semester TEXT NOT NULL,
+    faculty TEXT NOT NULL,
+    program TEXT NOT NULL,
+    room_id INTEGER NOT NULL,
+    room TEXT NOT NULL,
+    adapted BOOLEAN NOT NULL,
+    weekday INTEGER,
+    start_hour INTEGER,
+    end_hour INTEGER
+);
+
+CREATE INDEX IF NOT EXISTS allocation_events_occurred_at_idx ON allocation_events (occurred_at);
+
+-- Events are never changed nor removed
+CREATE TRIGGER IF NOT EXISTS allocation_events_no_update
+BEFORE UPDATE ON allocation_events
+BEGIN
+    SELECT RAISE(ABORT, 'allocation_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS allocation_events_no_delete
+BEFORE DELETE ON allocation_events
+BEGIN
+    SELECT RAISE(ABORT, 'allocation_events is append-only');
+END;
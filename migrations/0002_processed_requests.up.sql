@@ -0,0 +1,9 @@
+-- Create processed_requests table (responses already sent, keyed by client request)
+CREATE TABLE IF NOT EXISTS processed_requests (
+    identity TEXT NOT NULL,
+    request_id INTEGER NOT NULL,
+    type TEXT NOT NULL,
+    response JSONB NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (identity, request_id)
+);
@@ -0,0 +1,2 @@
+-- Drop stored procedures
+DROP FUNCTION IF EXISTS release_rooms(TEXT, TEXT);
@@ -0,0 +1,13 @@
+-- Function to release the rooms a faculty declined
+CREATE OR REPLACE FUNCTION release_rooms(faculty_name TEXT, semester_name TEXT) RETURNS SETOF TEXT AS $$
+BEGIN
+    RETURN QUERY
+    DELETE FROM room_allocations ra
+    USING rooms r
+    WHERE r.id = ra.room_id
+      AND ra.faculty = faculty_name
+      AND ra.semester = semester_name
+      AND ra.state = 'awaiting'
+    RETURNING r.name;
+END;
+$$ LANGUAGE plpgsql;
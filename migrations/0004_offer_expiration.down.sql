@@ -0,0 +1,15 @@
+-- Drop stored procedures
+DROP FUNCTION IF EXISTS expire_rooms(INTERVAL);
+DROP FUNCTION IF EXISTS lock_rooms(TEXT, TEXT);
+
+-- Restore the original lock_rooms
+CREATE OR REPLACE FUNCTION lock_rooms(faculty_name TEXT, semester_name TEXT) RETURNS VOID AS $$
+BEGIN
+    UPDATE room_allocations
+    SET state = 'locked'
+    WHERE faculty = faculty_name AND semester = semester_name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drop columns
+ALTER TABLE room_allocations DROP COLUMN IF EXISTS offered_at;
@@ -0,0 +1,32 @@
+-- Record when each offer was made
+ALTER TABLE room_allocations ADD COLUMN offered_at TIMESTAMPTZ NOT NULL DEFAULT now();
+
+-- Lock rooms now reports how many rows were locked (zero when the offer expired)
+DROP FUNCTION IF EXISTS lock_rooms(TEXT, TEXT);
+
+CREATE OR REPLACE FUNCTION lock_rooms(faculty_name TEXT, semester_name TEXT) RETURNS INT AS $$
+DECLARE
+    locked_count INT;
+BEGIN
+    UPDATE room_allocations
+    SET state = 'locked'
+    WHERE faculty = faculty_name AND semester = semester_name;
+
+    GET DIAGNOSTICS locked_count = ROW_COUNT;
+    RETURN locked_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to release offers that were not confirmed within the hold period
+CREATE OR REPLACE FUNCTION expire_rooms(hold INTERVAL)
+RETURNS TABLE (semester TEXT, faculty TEXT, program TEXT, name TEXT) AS $$
+BEGIN
+    RETURN QUERY
+    DELETE FROM room_allocations ra
+    USING rooms r
+    WHERE r.id = ra.room_id
+      AND ra.state = 'awaiting'
+      AND ra.offered_at < now() - hold
+    RETURNING ra.semester, ra.faculty, ra.program, r.name;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,109 @@
+-- Drop stored procedures
+DROP FUNCTION IF EXISTS allocate_laboratories(TEXT, TEXT, TEXT, INT, BOOLEAN);
+DROP FUNCTION IF EXISTS allocate_classrooms(TEXT, TEXT, TEXT, INT, BOOLEAN);
+
+-- Restore the original allocation functions
+-- Function to allocate classrooms
+CREATE OR REPLACE FUNCTION allocate_classrooms(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _count INT
+)
+RETURNS VOID AS $$
+DECLARE
+    available_room RECORD;
+    allocated_count INT := 0;
+BEGIN
+    -- Loop through available classroom-type rooms and allocate them
+    FOR available_room IN
+        SELECT r.id
+        FROM rooms r
+        WHERE r.type = 'classroom'
+          AND r.id NOT IN (
+              SELECT room_id
+              FROM room_allocations
+              WHERE semester = _semester
+          )
+        ORDER BY r.id
+        FOR UPDATE SKIP LOCKED
+        LIMIT _count
+    LOOP
+        -- Insert allocation
+        INSERT INTO room_allocations (
+            state, room_id, semester, faculty, program
+        ) VALUES (
+            'awaiting', available_room.id, _semester, _faculty, _program
+        );
+
+        allocated_count := allocated_count + 1;
+    END LOOP;
+
+    -- If not enough rooms were allocated, raise exception
+    IF allocated_count < _count THEN
+        RAISE EXCEPTION 'Not enough available classroom rooms to allocate (% out of %)', allocated_count, _count;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to allocate laboratories
+CREATE OR REPLACE FUNCTION allocate_laboratories(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _count INT
+)
+RETURNS VOID AS $$
+DECLARE
+    allocated_count INT := 0;
+    lab_room RECORD;
+    classroom_room RECORD;
+BEGIN
+    -- Step 1: Try to allocate as many laboratory-type rooms as available
+    FOR lab_room IN
+        SELECT r.id
+        FROM rooms r
+        WHERE r.type = 'laboratory'
+          AND r.id NOT IN (
+              SELECT room_id FROM room_allocations WHERE semester = _semester
+          )
+        ORDER BY r.id
+        FOR UPDATE SKIP LOCKED
+        LIMIT _count
+    LOOP
+        INSERT INTO room_allocations (
+            state, room_id, semester, faculty, program, adapted
+        ) VALUES (
+            'awaiting', lab_room.id, _semester, _faculty, _program, FALSE
+        );
+        allocated_count := allocated_count + 1;
+    END LOOP;
+
+    -- Step 2: If not enough, allocate classroom-type rooms as adapted labs
+    IF allocated_count < _count THEN
+        FOR classroom_room IN
+            SELECT r.id
+            FROM rooms r
+            WHERE r.type = 'classroom'
+              AND r.id NOT IN (
+                  SELECT room_id FROM room_allocations WHERE semester = _semester
+              )
+            ORDER BY r.id
+            FOR UPDATE SKIP LOCKED
+            LIMIT (_count - allocated_count)
+        LOOP
+            INSERT INTO room_allocations (
+                state, room_id, semester, faculty, program, adapted
+            ) VALUES (
+                'awaiting', classroom_room.id, _semester, _faculty, _program, TRUE
+            );
+            allocated_count := allocated_count + 1;
+        END LOOP;
+    END IF;
+
+    -- Step 3: If still not enough rooms, raise exception
+    IF allocated_count < _count THEN
+        RAISE EXCEPTION 'Not enough rooms to allocate for labs (% out of %)', allocated_count, _count;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,5 @@
+-- Drop stored procedures
+DROP FUNCTION IF EXISTS process_waitlist(TEXT);
+
+-- Drop tables
+DROP TABLE IF EXISTS waitlist;
@@ -0,0 +1,53 @@
+-- Create waitlist table (unmet program demand, served in arrival order)
+CREATE TABLE IF NOT EXISTS waitlist (
+    id SERIAL PRIMARY KEY,
+    semester TEXT NOT NULL,
+    faculty TEXT NOT NULL,
+    program TEXT NOT NULL,
+    classrooms INT NOT NULL DEFAULT 0,
+    laboratories INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Function to hand released rooms to the waitlist, oldest entries first
+CREATE OR REPLACE FUNCTION process_waitlist(_semester TEXT)
+RETURNS TABLE (faculty TEXT, program TEXT, classrooms INT, laboratories INT) AS $$
+#variable_conflict use_column
+DECLARE
+    entry RECORD;
+    granted_classrooms INT;
+    granted_laboratories INT;
+BEGIN
+    FOR entry IN
+        SELECT w.id, w.faculty, w.program, w.classrooms, w.laboratories
+        FROM waitlist w
+        WHERE w.semester = _semester
+        ORDER BY w.id
+        FOR UPDATE
+    LOOP
+        -- Grant as much as possible without failing on shortfalls
+        granted_classrooms := allocate_classrooms(_semester, entry.faculty, entry.program, entry.classrooms, FALSE);
+        granted_laboratories := allocate_laboratories(_semester, entry.faculty, entry.program, entry.laboratories, FALSE);
+
+        IF granted_classrooms = 0 AND granted_laboratories = 0 THEN
+            CONTINUE;
+        END IF;
+
+        -- Remove satisfied entries, shrink the rest
+        IF granted_classrooms = entry.classrooms AND granted_laboratories = entry.laboratories THEN
+            DELETE FROM waitlist WHERE id = entry.id;
+        ELSE
+            UPDATE waitlist
+            SET classrooms = classrooms - granted_classrooms,
+                laboratories = laboratories - granted_laboratories
+            WHERE id = entry.id;
+        END IF;
+
+        faculty := entry.faculty;
+        program := entry.program;
+        classrooms := granted_classrooms;
+        laboratories := granted_laboratories;
+        RETURN NEXT;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
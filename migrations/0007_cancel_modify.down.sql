@@ -0,0 +1,3 @@
+-- Drop stored procedures
+DROP FUNCTION IF EXISTS shrink_rooms(TEXT, TEXT, TEXT, BOOLEAN, INT);
+DROP FUNCTION IF EXISTS cancel_rooms(TEXT, TEXT, TEXT);
@@ -0,0 +1,57 @@
+-- Function to give back confirmed rooms (all of a faculty's, or one program's)
+CREATE OR REPLACE FUNCTION cancel_rooms(faculty_name TEXT, semester_name TEXT, program_name TEXT)
+RETURNS SETOF TEXT AS $$
+BEGIN
+    RETURN QUERY
+    DELETE FROM room_allocations ra
+    USING rooms r
+    WHERE r.id = ra.room_id
+      AND ra.faculty = faculty_name
+      AND ra.semester = semester_name
+      AND ra.state = 'locked'
+      AND (program_name = '' OR ra.program = program_name)
+    RETURNING r.name;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to release some of a program's confirmed rooms. Laboratory
+-- requests give back adapted classrooms before real laboratories.
+CREATE OR REPLACE FUNCTION shrink_rooms(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _laboratories BOOLEAN,
+    _count INT
+)
+RETURNS SETOF TEXT AS $$
+DECLARE
+    released_count INT;
+BEGIN
+    RETURN QUERY
+    DELETE FROM room_allocations ra
+    USING rooms r
+    WHERE r.id = ra.room_id
+      AND ra.id IN (
+          SELECT a.id
+          FROM room_allocations a
+          JOIN rooms ar ON ar.id = a.room_id
+          WHERE a.semester = _semester
+            AND a.faculty = _faculty
+            AND a.program = _program
+            AND a.state = 'locked'
+            AND (
+                (_laboratories AND (ar.type = 'laboratory' OR a.adapted))
+                OR (NOT _laboratories AND ar.type = 'classroom' AND NOT a.adapted)
+            )
+          ORDER BY a.adapted DESC, a.id DESC
+          LIMIT _count
+      )
+    RETURNING r.name;
+
+    -- If the program does not hold that many rooms, raise exception
+    GET DIAGNOSTICS released_count = ROW_COUNT;
+    IF released_count < _count THEN
+        RAISE EXCEPTION 'Not enough confirmed rooms to release for program % (% out of %)', _program, released_count, _count;
+    END IF;
+END;
+$$ LANGUAGE plpgsql;
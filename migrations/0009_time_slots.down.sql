@@ -0,0 +1,133 @@
+-- Drop stored procedures
+DROP FUNCTION IF EXISTS allocate_laboratories(TEXT, TEXT, TEXT, INT, BOOLEAN, INT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS allocate_classrooms(TEXT, TEXT, TEXT, INT, BOOLEAN, INT, TEXT);
+DROP FUNCTION IF EXISTS schedule_program(TEXT, TEXT, TEXT, BOOLEAN, INT, BOOLEAN, INT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS room_is_free(TEXT, INT);
+
+-- Restore the previous allocation functions
+-- Function to allocate classrooms
+CREATE OR REPLACE FUNCTION allocate_classrooms(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _count INT,
+    _strict BOOLEAN DEFAULT TRUE,
+    _min_capacity INT DEFAULT 0,
+    _building TEXT DEFAULT ''
+)
+RETURNS INT AS $$
+DECLARE
+    available_room RECORD;
+    allocated_count INT := 0;
+BEGIN
+    -- Loop through matching classroom-type rooms (preferred building first) and allocate them
+    FOR available_room IN
+        SELECT r.id
+        FROM rooms r
+        WHERE r.type = 'classroom'
+          AND r.capacity >= _min_capacity
+          AND r.id NOT IN (
+              SELECT room_id
+              FROM room_allocations
+              WHERE semester = _semester
+          )
+        ORDER BY (r.building = _building) DESC, r.id
+        FOR UPDATE SKIP LOCKED
+        LIMIT _count
+    LOOP
+        -- Insert allocation
+        INSERT INTO room_allocations (
+            state, room_id, semester, faculty, program
+        ) VALUES (
+            'awaiting', available_room.id, _semester, _faculty, _program
+        );
+
+        allocated_count := allocated_count + 1;
+    END LOOP;
+
+    -- If not enough rooms were allocated, raise exception
+    IF _strict AND allocated_count < _count THEN
+        RAISE EXCEPTION 'Not enough available classroom rooms to allocate (% out of %)', allocated_count, _count;
+    END IF;
+
+    RETURN allocated_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to allocate laboratories
+CREATE OR REPLACE FUNCTION allocate_laboratories(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _count INT,
+    _strict BOOLEAN DEFAULT TRUE,
+    _min_capacity INT DEFAULT 0,
+    _equipment TEXT DEFAULT '',
+    _building TEXT DEFAULT ''
+)
+RETURNS INT AS $$
+DECLARE
+    allocated_count INT := 0;
+    lab_room RECORD;
+    classroom_room RECORD;
+BEGIN
+    -- Step 1: Try to allocate as many matching laboratory-type rooms as available
+    FOR lab_room IN
+        SELECT r.id
+        FROM rooms r
+        WHERE r.type = 'laboratory'
+          AND r.capacity >= _min_capacity
+          AND (_equipment = '' OR _equipment = ANY(r.equipment))
+          AND r.id NOT IN (
+              SELECT room_id FROM room_allocations WHERE semester = _semester
+          )
+        ORDER BY (r.building = _building) DESC, r.id
+        FOR UPDATE SKIP LOCKED
+        LIMIT _count
+    LOOP
+        INSERT INTO room_allocations (
+            state, room_id, semester, faculty, program, adapted
+        ) VALUES (
+            'awaiting', lab_room.id, _semester, _faculty, _program, FALSE
+        );
+        allocated_count := allocated_count + 1;
+    END LOOP;
+
+    -- Step 2: If not enough, allocate classroom-type rooms as adapted labs
+    -- (an adapted classroom cannot provide specific equipment)
+    IF allocated_count < _count AND _equipment = '' THEN
+        FOR classroom_room IN
+            SELECT r.id
+            FROM rooms r
+            WHERE r.type = 'classroom'
+              AND r.capacity >= _min_capacity
+              AND r.id NOT IN (
+                  SELECT room_id FROM room_allocations WHERE semester = _semester
+              )
+            ORDER BY (r.building = _building) DESC, r.id
+            FOR UPDATE SKIP LOCKED
+            LIMIT (_count - allocated_count)
+        LOOP
+            INSERT INTO room_allocations (
+                state, room_id, semester, faculty, program, adapted
+            ) VALUES (
+                'awaiting', classroom_room.id, _semester, _faculty, _program, TRUE
+            );
+            allocated_count := allocated_count + 1;
+        END LOOP;
+    END IF;
+
+    -- Step 3: If still not enough rooms, raise exception
+    IF _strict AND allocated_count < _count THEN
+        RAISE EXCEPTION 'Not enough rooms to allocate for labs (% out of %)', allocated_count, _count;
+    END IF;
+
+    RETURN allocated_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drop triggers
+DROP TRIGGER IF EXISTS enforce_adapted_logic ON room_bookings;
+
+-- Drop tables
+DROP TABLE IF EXISTS room_bookings;
@@ -0,0 +1,220 @@
+-- Needed to mix equality and range overlap in an exclusion constraint
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- Create room_bookings table (a room held for one weekday time block)
+CREATE TABLE IF NOT EXISTS room_bookings (
+    id SERIAL PRIMARY KEY,
+    state room_state NOT NULL,
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    semester TEXT NOT NULL,
+    faculty TEXT NOT NULL,
+    program TEXT NOT NULL,
+    weekday INT NOT NULL CHECK (weekday BETWEEN 1 AND 6),
+    hours INT4RANGE NOT NULL,
+    adapted BOOLEAN NOT NULL DEFAULT FALSE,
+    offered_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    EXCLUDE USING gist (semester WITH =, room_id WITH =, weekday WITH =, hours WITH &&)
+);
+
+-- Create the trigger (same adapted rule as room_allocations)
+CREATE TRIGGER enforce_adapted_logic
+BEFORE INSERT OR UPDATE ON room_bookings
+FOR EACH ROW
+EXECUTE FUNCTION check_adapted_consistency();
+
+-- Function telling whether a room can be held for a whole semester
+CREATE OR REPLACE FUNCTION room_is_free(_semester TEXT, _room_id INT) RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM room_allocations WHERE semester = _semester AND room_id = _room_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM room_bookings WHERE semester = _semester AND room_id = _room_id
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Function to book weekly time blocks of two hours for a program
+CREATE OR REPLACE FUNCTION schedule_program(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _laboratories BOOLEAN,
+    _blocks INT,
+    _strict BOOLEAN DEFAULT TRUE,
+    _min_capacity INT DEFAULT 0,
+    _equipment TEXT DEFAULT '',
+    _building TEXT DEFAULT ''
+)
+RETURNS INT AS $$
+DECLARE
+    candidate RECORD;
+    booked_count INT := 0;
+BEGIN
+    -- Walk the week block by block, real laboratories before adapted classrooms
+    FOR candidate IN
+        SELECT r.id, r.type, d.weekday, int4range(h.start, h.start + 2) AS hours
+        FROM rooms r
+        CROSS JOIN generate_series(1, 6) AS d(weekday)
+        CROSS JOIN generate_series(7, 19, 2) AS h(start)
+        WHERE r.capacity >= _min_capacity
+          AND (
+              (NOT _laboratories AND r.type = 'classroom')
+              OR (_laboratories AND r.type = 'laboratory' AND (_equipment = '' OR _equipment = ANY(r.equipment)))
+              OR (_laboratories AND r.type = 'classroom' AND _equipment = '')
+          )
+        ORDER BY d.weekday, h.start, (r.type = 'laboratory') DESC, (r.building = _building) DESC, r.id
+    LOOP
+        EXIT WHEN booked_count >= _blocks;
+
+        -- Skip rooms held for the whole semester
+        CONTINUE WHEN EXISTS (
+            SELECT 1 FROM room_allocations WHERE semester = _semester AND room_id = candidate.id
+        );
+
+        -- Skip blocks where the room is taken or the program is already in class
+        CONTINUE WHEN EXISTS (
+            SELECT 1
+            FROM room_bookings b
+            WHERE b.semester = _semester
+              AND b.weekday = candidate.weekday
+              AND b.hours && candidate.hours
+              AND (b.room_id = candidate.id OR (b.faculty = _faculty AND b.program = _program))
+        );
+
+        -- Insert booking (a concurrent booking of the same block is skipped)
+        BEGIN
+            INSERT INTO room_bookings (
+                state, room_id, semester, faculty, program, weekday, hours, adapted
+            ) VALUES (
+                'awaiting', candidate.id, _semester, _faculty, _program, candidate.weekday, candidate.hours,
+                _laboratories AND candidate.type = 'classroom'
+            );
+            booked_count := booked_count + 1;
+        EXCEPTION WHEN exclusion_violation THEN
+            CONTINUE;
+        END;
+    END LOOP;
+
+    -- If not enough blocks were booked, raise exception
+    IF _strict AND booked_count < _blocks THEN
+        RAISE EXCEPTION 'Not enough free time blocks to schedule (% out of %)', booked_count, _blocks;
+    END IF;
+
+    RETURN booked_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Allocation functions skip rooms with bookings as well
+DROP FUNCTION IF EXISTS allocate_laboratories(TEXT, TEXT, TEXT, INT, BOOLEAN, INT, TEXT, TEXT);
+DROP FUNCTION IF EXISTS allocate_classrooms(TEXT, TEXT, TEXT, INT, BOOLEAN, INT, TEXT);
+
+-- Function to allocate classrooms
+CREATE OR REPLACE FUNCTION allocate_classrooms(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _count INT,
+    _strict BOOLEAN DEFAULT TRUE,
+    _min_capacity INT DEFAULT 0,
+    _building TEXT DEFAULT ''
+)
+RETURNS INT AS $$
+DECLARE
+    available_room RECORD;
+    allocated_count INT := 0;
+BEGIN
+    -- Loop through matching classroom-type rooms (preferred building first) and allocate them
+    FOR available_room IN
+        SELECT r.id
+        FROM rooms r
+        WHERE r.type = 'classroom'
+          AND r.capacity >= _min_capacity
+          AND room_is_free(_semester, r.id)
+        ORDER BY (r.building = _building) DESC, r.id
+        FOR UPDATE SKIP LOCKED
+        LIMIT _count
+    LOOP
+        -- Insert allocation
+        INSERT INTO room_allocations (
+            state, room_id, semester, faculty, program
+        ) VALUES (
+            'awaiting', available_room.id, _semester, _faculty, _program
+        );
+
+        allocated_count := allocated_count + 1;
+    END LOOP;
+
+    -- If not enough rooms were allocated, raise exception
+    IF _strict AND allocated_count < _count THEN
+        RAISE EXCEPTION 'Not enough available classroom rooms to allocate (% out of %)', allocated_count, _count;
+    END IF;
+
+    RETURN allocated_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to allocate laboratories
+CREATE OR REPLACE FUNCTION allocate_laboratories(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _count INT,
+    _strict BOOLEAN DEFAULT TRUE,
+    _min_capacity INT DEFAULT 0,
+    _equipment TEXT DEFAULT '',
+    _building TEXT DEFAULT ''
+)
+RETURNS INT AS $$
+DECLARE
+    allocated_count INT := 0;
+    lab_room RECORD;
+    classroom_room RECORD;
+BEGIN
+    -- Step 1: Try to allocate as many matching laboratory-type rooms as available
+    FOR lab_room IN
+        SELECT r.id
+        FROM rooms r
+        WHERE r.type = 'laboratory'
+          AND r.capacity >= _min_capacity
+          AND (_equipment = '' OR _equipment = ANY(r.equipment))
+          AND room_is_free(_semester, r.id)
+        ORDER BY (r.building = _building) DESC, r.id
+        FOR UPDATE SKIP LOCKED
+        LIMIT _count
+    LOOP
+        INSERT INTO room_allocations (
+            state, room_id, semester, faculty, program, adapted
+        ) VALUES (
+            'awaiting', lab_room.id, _semester, _faculty, _program, FALSE
+        );
+        allocated_count := allocated_count + 1;
+    END LOOP;
+
+    -- Step 2: If not enough, allocate classroom-type rooms as adapted labs
+    -- (an adapted classroom cannot provide specific equipment)
+    IF allocated_count < _count AND _equipment = '' THEN
+        FOR classroom_room IN
+            SELECT r.id
+            FROM rooms r
+            WHERE r.type = 'classroom'
+              AND r.capacity >= _min_capacity
+              AND room_is_free(_semester, r.id)
+            ORDER BY (r.building = _building) DESC, r.id
+            FOR UPDATE SKIP LOCKED
+            LIMIT (_count - allocated_count)
+        LOOP
+            INSERT INTO room_allocations (
+                state, room_id, semester, faculty, program, adapted
+            ) VALUES (
+                'awaiting', classroom_room.id, _semester, _faculty, _program, TRUE
+            );
+            allocated_count := allocated_count + 1;
+        END LOOP;
+    END IF;
+
+    -- Step 3: If still not enough rooms, raise exception
+    IF _strict AND allocated_count < _count THEN
+        RAISE EXCEPTION 'Not enough rooms to allocate for labs (% out of %)', allocated_count, _count;
+    END IF;
+
+    RETURN allocated_count;
+END;
+$$ LANGUAGE plpgsql;
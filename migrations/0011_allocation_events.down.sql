@@ -0,0 +1,10 @@
+-- Drop triggers
+DROP TRIGGER IF EXISTS record_allocation_events ON room_allocations;
+DROP TRIGGER IF EXISTS allocation_events_append_only ON allocation_events;
+
+-- Drop functions
+DROP FUNCTION IF EXISTS record_allocation_event();
+DROP FUNCTION IF EXISTS reject_event_changes();
+
+-- Drop tables
+DROP TABLE IF EXISTS allocation_events;
@@ -0,0 +1,63 @@
+-- Create allocation_events table (append-only history of room_allocations)
+CREATE TABLE IF NOT EXISTS allocation_events (
+    id BIGSERIAL PRIMARY KEY,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    type TEXT NOT NULL,
+    semester TEXT NOT NULL,
+    faculty TEXT NOT NULL,
+    program TEXT NOT NULL,
+    room_id INTEGER NOT NULL,
+    room TEXT NOT NULL,
+    adapted BOOLEAN NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS allocation_events_occurred_at_idx ON allocation_events (occurred_at);
+
+-- Events are never changed nor removed
+CREATE OR REPLACE FUNCTION reject_event_changes() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'allocation_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER allocation_events_append_only
+BEFORE UPDATE OR DELETE ON allocation_events
+FOR EACH ROW
+EXECUTE FUNCTION reject_event_changes();
+
+-- Record every change to room_allocations. Releases are labelled with the
+-- reason the transaction set in central.release_reason (declined, expired,
+-- cancelled, modified), or just "released".
+CREATE OR REPLACE FUNCTION record_allocation_event() RETURNS TRIGGER AS $$
+DECLARE
+    event_type TEXT;
+    allocation RECORD;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'offered';
+        allocation := NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        IF OLD.state = NEW.state THEN
+            RETURN NULL;
+        END IF;
+
+        event_type := CASE WHEN NEW.state = 'locked' THEN 'confirmed' ELSE 'unlocked' END;
+        allocation := NEW;
+    ELSE
+        event_type := COALESCE(NULLIF(current_setting('central.release_reason', TRUE), ''), 'released');
+        allocation := OLD;
+    END IF;
+
+    INSERT INTO allocation_events (type, semester, faculty, program, room_id, room, adapted)
+    SELECT event_type, allocation.semester, allocation.faculty, allocation.program, r.id, r.name, allocation.adapted
+    FROM rooms r
+    WHERE r.id = allocation.room_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_allocation_events
+AFTER INSERT OR UPDATE OR DELETE ON room_allocations
+FOR EACH ROW
+EXECUTE FUNCTION record_allocation_event();
@@ -0,0 +1,10 @@
+-- Drop constraints
+ALTER TABLE waitlist DROP CONSTRAINT IF EXISTS waitlist_semester_fkey;
+ALTER TABLE room_bookings DROP CONSTRAINT IF EXISTS room_bookings_semester_fkey;
+ALTER TABLE room_allocations DROP CONSTRAINT IF EXISTS room_allocations_semester_fkey;
+
+-- Drop tables
+DROP TABLE IF EXISTS semesters;
+
+-- Drop types
+DROP TYPE IF EXISTS semester_state;
@@ -0,0 +1,29 @@
+-- Create semester_state enum
+CREATE TYPE semester_state AS ENUM ('draft', 'open', 'closed', 'archived');
+
+-- Create semesters table (only open semesters accept allocations)
+CREATE TABLE IF NOT EXISTS semesters (
+    name TEXT PRIMARY KEY,
+    state semester_state NOT NULL DEFAULT 'draft',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+-- Semesters already in use stay open
+INSERT INTO semesters (name, state)
+SELECT semester, 'open' FROM room_allocations
+UNION
+SELECT semester, 'open' FROM room_bookings
+UNION
+SELECT semester, 'open' FROM waitlist
+ON CONFLICT (name) DO NOTHING;
+
+-- Allocations, bookings and waitlist entries must belong to a known semester
+ALTER TABLE room_allocations
+    ADD CONSTRAINT room_allocations_semester_fkey FOREIGN KEY (semester) REFERENCES semesters(name);
+
+ALTER TABLE room_bookings
+    ADD CONSTRAINT room_bookings_semester_fkey FOREIGN KEY (semester) REFERENCES semesters(name);
+
+ALTER TABLE waitlist
+    ADD CONSTRAINT waitlist_semester_fkey FOREIGN KEY (semester) REFERENCES semesters(name);
@@ -0,0 +1,9 @@
+-- Drop constraints
+ALTER TABLE waitlist DROP CONSTRAINT IF EXISTS waitlist_program_fkey;
+ALTER TABLE room_bookings DROP CONSTRAINT IF EXISTS room_bookings_program_fkey;
+ALTER TABLE room_allocations DROP CONSTRAINT IF EXISTS room_allocations_program_fkey;
+
+-- Drop tables
+DROP TABLE IF EXISTS faculty_quotas;
+DROP TABLE IF EXISTS programs;
+DROP TABLE IF EXISTS faculties;
@@ -0,0 +1,47 @@
+-- Create faculties table
+CREATE TABLE IF NOT EXISTS faculties (
+    name TEXT PRIMARY KEY
+);
+
+-- Create programs table (the programs each faculty may request rooms for)
+CREATE TABLE IF NOT EXISTS programs (
+    faculty TEXT NOT NULL REFERENCES faculties(name),
+    name TEXT NOT NULL,
+    PRIMARY KEY (faculty, name)
+);
+
+-- Create faculty_quotas table (most rooms a faculty may hold in a semester)
+CREATE TABLE IF NOT EXISTS faculty_quotas (
+    semester TEXT NOT NULL REFERENCES semesters(name),
+    faculty TEXT NOT NULL REFERENCES faculties(name),
+    classrooms INT NOT NULL CHECK (classrooms >= 0),
+    laboratories INT NOT NULL CHECK (laboratories >= 0),
+    PRIMARY KEY (semester, faculty)
+);
+
+-- Faculties and programs already in use stay registered
+INSERT INTO faculties (name)
+SELECT faculty FROM room_allocations
+UNION
+SELECT faculty FROM room_bookings
+UNION
+SELECT faculty FROM waitlist
+ON CONFLICT (name) DO NOTHING;
+
+INSERT INTO programs (faculty, name)
+SELECT faculty, program FROM room_allocations
+UNION
+SELECT faculty, program FROM room_bookings
+UNION
+SELECT faculty, program FROM waitlist
+ON CONFLICT (faculty, name) DO NOTHING;
+
+-- Allocations, bookings and waitlist entries must belong to a registered program
+ALTER TABLE room_allocations
+    ADD CONSTRAINT room_allocations_program_fkey FOREIGN KEY (faculty, program) REFERENCES programs(faculty, name);
+
+ALTER TABLE room_bookings
+    ADD CONSTRAINT room_bookings_program_fkey FOREIGN KEY (faculty, program) REFERENCES programs(faculty, name);
+
+ALTER TABLE waitlist
+    ADD CONSTRAINT waitlist_program_fkey FOREIGN KEY (faculty, program) REFERENCES programs(faculty, name);
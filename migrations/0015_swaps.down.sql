@@ -0,0 +1,36 @@
+-- Restore the allocation event recorder without ownership changes
+CREATE OR REPLACE FUNCTION record_allocation_event() RETURNS TRIGGER AS $$
+DECLARE
+    event_type TEXT;
+    allocation RECORD;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'offered';
+        allocation := NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        IF OLD.state = NEW.state THEN
+            RETURN NULL;
+        END IF;
+
+        event_type := CASE WHEN NEW.state = 'locked' THEN 'confirmed' ELSE 'unlocked' END;
+        allocation := NEW;
+    ELSE
+        event_type := COALESCE(NULLIF(current_setting('central.release_reason', TRUE), ''), 'released');
+        allocation := OLD;
+    END IF;
+
+    INSERT INTO allocation_events (type, semester, faculty, program, room_id, room, adapted)
+    SELECT event_type, allocation.semester, allocation.faculty, allocation.program, r.id, r.name, allocation.adapted
+    FROM rooms r
+    WHERE r.id = allocation.room_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drop tables
+DROP TABLE IF EXISTS swap_rooms;
+DROP TABLE IF EXISTS swaps;
+
+-- Drop types
+DROP TYPE IF EXISTS swap_state;
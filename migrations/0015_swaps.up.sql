@@ -0,0 +1,60 @@
+-- Create swap_state enum
+CREATE TYPE swap_state AS ENUM ('pending', 'accepted', 'rejected');
+
+-- Create swaps table (a faculty offers some of its rooms to another faculty in
+-- exchange for some of the other faculty's rooms)
+CREATE TABLE IF NOT EXISTS swaps (
+    id SERIAL PRIMARY KEY,
+    semester TEXT NOT NULL REFERENCES semesters(name),
+    proposer TEXT NOT NULL REFERENCES faculties(name),
+    recipient TEXT NOT NULL REFERENCES faculties(name),
+    program TEXT NOT NULL,
+    state swap_state NOT NULL DEFAULT 'pending',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    decided_at TIMESTAMPTZ,
+    FOREIGN KEY (proposer, program) REFERENCES programs(faculty, name),
+    CHECK (proposer <> recipient)
+);
+
+-- Create swap_rooms table (the rooms each side of a swap gives)
+CREATE TABLE IF NOT EXISTS swap_rooms (
+    swap_id INTEGER NOT NULL REFERENCES swaps(id) ON DELETE CASCADE,
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    faculty TEXT NOT NULL REFERENCES faculties(name),
+    PRIMARY KEY (swap_id, room_id)
+);
+
+CREATE INDEX IF NOT EXISTS swaps_semester_state_idx ON swaps (semester, state);
+
+-- Record ownership changes too, so swapped rooms can be replayed
+CREATE OR REPLACE FUNCTION record_allocation_event() RETURNS TRIGGER AS $$
+DECLARE
+    event_type TEXT;
+    allocation RECORD;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'offered';
+        allocation := NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        IF OLD.faculty <> NEW.faculty OR OLD.program <> NEW.program THEN
+            event_type := 'swapped';
+        ELSIF OLD.state = NEW.state THEN
+            RETURN NULL;
+        ELSE
+            event_type := CASE WHEN NEW.state = 'locked' THEN 'confirmed' ELSE 'unlocked' END;
+        END IF;
+
+        allocation := NEW;
+    ELSE
+        event_type := COALESCE(NULLIF(current_setting('central.release_reason', TRUE), ''), 'released');
+        allocation := OLD;
+    END IF;
+
+    INSERT INTO allocation_events (type, semester, faculty, program, room_id, room, adapted)
+    SELECT event_type, allocation.semester, allocation.faculty, allocation.program, r.id, r.name, allocation.adapted
+    FROM rooms r
+    WHERE r.id = allocation.room_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
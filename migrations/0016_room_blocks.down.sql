@@ -0,0 +1,84 @@
+-- Restore the functions without blocked rooms
+CREATE OR REPLACE FUNCTION room_is_free(_semester TEXT, _room_id INT) RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM room_allocations WHERE semester = _semester AND room_id = _room_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM room_bookings WHERE semester = _semester AND room_id = _room_id
+    );
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION schedule_program(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _laboratories BOOLEAN,
+    _blocks INT,
+    _strict BOOLEAN DEFAULT TRUE,
+    _min_capacity INT DEFAULT 0,
+    _equipment TEXT DEFAULT '',
+    _building TEXT DEFAULT ''
+)
+RETURNS INT AS $$
+DECLARE
+    candidate RECORD;
+    booked_count INT := 0;
+BEGIN
+    -- Walk the week block by block, real laboratories before adapted classrooms
+    FOR candidate IN
+        SELECT r.id, r.type, d.weekday, int4range(h.start, h.start + 2) AS hours
+        FROM rooms r
+        CROSS JOIN generate_series(1, 6) AS d(weekday)
+        CROSS JOIN generate_series(7, 19, 2) AS h(start)
+        WHERE r.capacity >= _min_capacity
+          AND (
+              (NOT _laboratories AND r.type = 'classroom')
+              OR (_laboratories AND r.type = 'laboratory' AND (_equipment = '' OR _equipment = ANY(r.equipment)))
+              OR (_laboratories AND r.type = 'classroom' AND _equipment = '')
+          )
+        ORDER BY d.weekday, h.start, (r.type = 'laboratory') DESC, (r.building = _building) DESC, r.id
+    LOOP
+        EXIT WHEN booked_count >= _blocks;
+
+        -- Skip rooms held for the whole semester
+        CONTINUE WHEN EXISTS (
+            SELECT 1 FROM room_allocations WHERE semester = _semester AND room_id = candidate.id
+        );
+
+        -- Skip blocks where the room is taken or the program is already in class
+        CONTINUE WHEN EXISTS (
+            SELECT 1
+            FROM room_bookings b
+            WHERE b.semester = _semester
+              AND b.weekday = candidate.weekday
+              AND b.hours && candidate.hours
+              AND (b.room_id = candidate.id OR (b.faculty = _faculty AND b.program = _program))
+        );
+
+        -- Insert booking (a concurrent booking of the same block is skipped)
+        BEGIN
+            INSERT INTO room_bookings (
+                state, room_id, semester, faculty, program, weekday, hours, adapted
+            ) VALUES (
+                'awaiting', candidate.id, _semester, _faculty, _program, candidate.weekday, candidate.hours,
+                _laboratories AND candidate.type = 'classroom'
+            );
+            booked_count := booked_count + 1;
+        EXCEPTION WHEN exclusion_violation THEN
+            CONTINUE;
+        END;
+    END LOOP;
+
+    -- If not enough blocks were booked, raise exception
+    IF _strict AND booked_count < _blocks THEN
+        RAISE EXCEPTION 'Not enough free time blocks to schedule (% out of %)', booked_count, _blocks;
+    END IF;
+
+    RETURN booked_count;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Drop functions
+DROP FUNCTION IF EXISTS room_in_service(TEXT, INT);
+
+-- Drop tables
+DROP TABLE IF EXISTS room_blocks;
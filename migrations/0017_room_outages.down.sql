@@ -0,0 +1,9 @@
+-- Restore the function without outages
+CREATE OR REPLACE FUNCTION room_in_service(_semester TEXT, _room_id INT) RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM room_blocks WHERE semester = _semester AND room_id = _room_id
+    );
+$$ LANGUAGE sql STABLE;
+
+-- Drop tables
+DROP TABLE IF EXISTS room_outages;
@@ -0,0 +1,21 @@
+-- Create room_outages table (periods a room is under maintenance or out of service)
+CREATE TABLE IF NOT EXISTS room_outages (
+    id SERIAL PRIMARY KEY,
+    room_id INTEGER NOT NULL REFERENCES rooms(id),
+    starts_at TIMESTAMPTZ NOT NULL,
+    ends_at TIMESTAMPTZ NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS room_outages_room_idx ON room_outages (room_id, ends_at);
+
+-- Rooms held for a whole semester cannot be given while an outage is current or upcoming
+CREATE OR REPLACE FUNCTION room_in_service(_semester TEXT, _room_id INT) RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM room_blocks WHERE semester = _semester AND room_id = _room_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM room_outages WHERE room_id = _room_id AND ends_at > now()
+    );
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,15 @@
+-- Restore lock_rooms without the deadline
+DROP FUNCTION IF EXISTS lock_rooms(TEXT, TEXT, INTERVAL);
+
+CREATE OR REPLACE FUNCTION lock_rooms(faculty_name TEXT, semester_name TEXT) RETURNS INT AS $$
+DECLARE
+    locked_count INT;
+BEGIN
+    UPDATE room_allocations
+    SET state = 'locked'
+    WHERE faculty = faculty_name AND semester = semester_name;
+
+    GET DIAGNOSTICS locked_count = ROW_COUNT;
+    RETURN locked_count;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,19 @@
+-- Only offers still awaiting and within the hold period can be confirmed, so
+-- the count is zero once the offer expired even if other rooms are locked
+DROP FUNCTION IF EXISTS lock_rooms(TEXT, TEXT);
+
+CREATE OR REPLACE FUNCTION lock_rooms(faculty_name TEXT, semester_name TEXT, hold INTERVAL) RETURNS INT AS $$
+DECLARE
+    locked_count INT;
+BEGIN
+    UPDATE room_allocations
+    SET state = 'locked'
+    WHERE faculty = faculty_name
+      AND semester = semester_name
+      AND state = 'awaiting'
+      AND offered_at > now() - hold;
+
+    GET DIAGNOSTICS locked_count = ROW_COUNT;
+    RETURN locked_count;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,55 @@
+-- Waitlist entries keep the requirements of the program, so the rooms they
+-- get later meet them too
+ALTER TABLE waitlist
+    ADD COLUMN IF NOT EXISTS min_capacity INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS equipment TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS building TEXT NOT NULL DEFAULT '';
+
+-- Function to hand released rooms to the waitlist, oldest entries first
+CREATE OR REPLACE FUNCTION process_waitlist(_semester TEXT)
+RETURNS TABLE (faculty TEXT, program TEXT, classrooms INT, laboratories INT) AS $$
+#variable_conflict use_column
+DECLARE
+    entry RECORD;
+    granted_classrooms INT;
+    granted_laboratories INT;
+BEGIN
+    FOR entry IN
+        SELECT w.id, w.faculty, w.program, w.classrooms, w.laboratories, w.min_capacity, w.equipment, w.building
+        FROM waitlist w
+        WHERE w.semester = _semester
+        ORDER BY w.id
+        FOR UPDATE
+    LOOP
+        -- Grant as much as possible without failing on shortfalls
+        granted_classrooms := allocate_classrooms(
+            _semester, entry.faculty, entry.program, entry.classrooms, FALSE,
+            entry.min_capacity, entry.building
+        );
+        granted_laboratories := allocate_laboratories(
+            _semester, entry.faculty, entry.program, entry.laboratories, FALSE,
+            entry.min_capacity, entry.equipment, entry.building
+        );
+
+        IF granted_classrooms = 0 AND granted_laboratories = 0 THEN
+            CONTINUE;
+        END IF;
+
+        -- Remove satisfied entries, shrink the rest
+        IF granted_classrooms = entry.classrooms AND granted_laboratories = entry.laboratories THEN
+            DELETE FROM waitlist WHERE id = entry.id;
+        ELSE
+            UPDATE waitlist
+            SET classrooms = classrooms - granted_classrooms,
+                laboratories = laboratories - granted_laboratories
+            WHERE id = entry.id;
+        END IF;
+
+        faculty := entry.faculty;
+        program := entry.program;
+        classrooms := granted_classrooms;
+        laboratories := granted_laboratories;
+        RETURN NEXT;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,152 @@
+-- The waitlist and time blocks take the room order chosen by the allocation
+-- strategy too (rooms missing from the ranking go last)
+DROP FUNCTION IF EXISTS process_waitlist(TEXT);
+DROP FUNCTION IF EXISTS schedule_program(TEXT, TEXT, TEXT, BOOLEAN, INT, BOOLEAN, INT, TEXT, TEXT);
+
+-- Function to hand released rooms to the waitlist, oldest entries first, in the
+-- order chosen by the allocation strategy (rooms in the entry's preferred
+-- building first, as the strategies rank them for a program)
+CREATE OR REPLACE FUNCTION process_waitlist(
+    _semester TEXT,
+    _classroom_ranking INT[] DEFAULT '{}',
+    _laboratory_ranking INT[] DEFAULT '{}'
+)
+RETURNS TABLE (faculty TEXT, program TEXT, classrooms INT, laboratories INT) AS $$
+#variable_conflict use_column
+DECLARE
+    entry RECORD;
+    granted_classrooms INT;
+    granted_laboratories INT;
+    classroom_ranking INT[];
+    laboratory_ranking INT[];
+BEGIN
+    FOR entry IN
+        SELECT w.id, w.faculty, w.program, w.classrooms, w.laboratories, w.min_capacity, w.equipment, w.building
+        FROM waitlist w
+        WHERE w.semester = _semester
+        ORDER BY w.id
+        FOR UPDATE
+    LOOP
+        -- Move the rooms of the preferred building to the front of the rankings
+        classroom_ranking := ARRAY(
+            SELECT u.id
+            FROM unnest(_classroom_ranking) WITH ORDINALITY AS u(id, ord)
+            JOIN rooms r ON r.id = u.id
+            ORDER BY (r.building = entry.building) DESC, u.ord
+        );
+        laboratory_ranking := ARRAY(
+            SELECT u.id
+            FROM unnest(_laboratory_ranking) WITH ORDINALITY AS u(id, ord)
+            JOIN rooms r ON r.id = u.id
+            ORDER BY (r.building = entry.building) DESC, u.ord
+        );
+
+        -- Grant as much as possible without failing on shortfalls
+        granted_classrooms := allocate_classrooms(
+            _semester, entry.faculty, entry.program, entry.classrooms, FALSE,
+            entry.min_capacity, entry.building, classroom_ranking
+        );
+        granted_laboratories := allocate_laboratories(
+            _semester, entry.faculty, entry.program, entry.laboratories, FALSE,
+            entry.min_capacity, entry.equipment, entry.building, laboratory_ranking
+        );
+
+        IF granted_classrooms = 0 AND granted_laboratories = 0 THEN
+            CONTINUE;
+        END IF;
+
+        -- Remove satisfied entries, shrink the rest
+        IF granted_classrooms = entry.classrooms AND granted_laboratories = entry.laboratories THEN
+            DELETE FROM waitlist WHERE id = entry.id;
+        ELSE
+            UPDATE waitlist
+            SET classrooms = classrooms - granted_classrooms,
+                laboratories = laboratories - granted_laboratories
+            WHERE id = entry.id;
+        END IF;
+
+        faculty := entry.faculty;
+        program := entry.program;
+        classrooms := granted_classrooms;
+        laboratories := granted_laboratories;
+        RETURN NEXT;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Function to book weekly time blocks for a program
+CREATE OR REPLACE FUNCTION schedule_program(
+    _semester TEXT,
+    _faculty TEXT,
+    _program TEXT,
+    _laboratories BOOLEAN,
+    _blocks INT,
+    _strict BOOLEAN DEFAULT TRUE,
+    _min_capacity INT DEFAULT 0,
+    _equipment TEXT DEFAULT '',
+    _building TEXT DEFAULT '',
+    _ranking INT[] DEFAULT '{}'
+)
+RETURNS INT AS $$
+DECLARE
+    candidate RECORD;
+    booked_count INT := 0;
+BEGIN
+    -- Walk the week block by block, real laboratories before adapted classrooms
+    FOR candidate IN
+        SELECT r.id, r.type, d.weekday, int4range(h.start, h.start + 2) AS hours
+        FROM rooms r
+        CROSS JOIN generate_series(1, 6) AS d(weekday)
+        CROSS JOIN generate_series(7, 19, 2) AS h(start)
+        WHERE r.capacity >= _min_capacity
+          AND room_in_service(_semester, r.id)
+          AND (
+              (NOT _laboratories AND r.type = 'classroom')
+              OR (_laboratories AND r.type = 'laboratory' AND (_equipment = '' OR _equipment = ANY(r.equipment)))
+              OR (_laboratories AND r.type = 'classroom' AND _equipment = '')
+          )
+        ORDER BY d.weekday, h.start, (r.type = 'laboratory') DESC,
+            array_position(_ranking, r.id) NULLS LAST, (r.building = _building) DESC, r.id
+    LOOP
+        EXIT WHEN booked_count >= _blocks;
+
+        -- Skip rooms held for the whole semester (checked once the room is
+        -- locked, so a concurrent allocation is either seen or waits)
+        PERFORM 1 FROM rooms WHERE id = candidate.id FOR UPDATE;
+
+        CONTINUE WHEN EXISTS (
+            SELECT 1 FROM room_allocations WHERE semester = _semester AND room_id = candidate.id
+        );
+
+        -- Skip blocks where the room is taken or the program is already in class
+        CONTINUE WHEN EXISTS (
+            SELECT 1
+            FROM room_bookings b
+            WHERE b.semester = _semester
+              AND b.weekday = candidate.weekday
+              AND b.hours && candidate.hours
+              AND (b.room_id = candidate.id OR (b.faculty = _faculty AND b.program = _program))
+        );
+
+        -- Insert booking (a concurrent booking of the same block is skipped)
+        BEGIN
+            INSERT INTO room_bookings (
+                state, room_id, semester, faculty, program, weekday, hours, adapted
+            ) VALUES (
+                'awaiting', candidate.id, _semester, _faculty, _program, candidate.weekday, candidate.hours,
+                _laboratories AND candidate.type = 'classroom'
+            );
+            booked_count := booked_count + 1;
+        EXCEPTION WHEN exclusion_violation THEN
+            CONTINUE;
+        END;
+    END LOOP;
+
+    -- If not enough blocks were booked, raise exception
+    IF _strict AND booked_count < _blocks THEN
+        RAISE EXCEPTION 'Not enough free time blocks to schedule (% out of %)', booked_count, _blocks;
+    END IF;
+
+    RETURN booked_count;
+END;
+$$ LANGUAGE plpgsql;
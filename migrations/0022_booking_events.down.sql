@@ -0,0 +1,44 @@
+-- Stop recording time blocks
+DROP TRIGGER IF EXISTS record_booking_events ON room_bookings;
+
+-- Restore the allocation event recorder without time blocks
+CREATE OR REPLACE FUNCTION record_allocation_event() RETURNS TRIGGER AS $$
+DECLARE
+    event_type TEXT;
+    allocation RECORD;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'offered';
+        allocation := NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        IF OLD.faculty <> NEW.faculty OR OLD.program <> NEW.program THEN
+            event_type := 'swapped';
+        ELSIF OLD.state = NEW.state THEN
+            RETURN NULL;
+        ELSE
+            event_type := CASE WHEN NEW.state = 'locked' THEN 'confirmed' ELSE 'unlocked' END;
+        END IF;
+
+        allocation := NEW;
+    ELSE
+        event_type := COALESCE(NULLIF(current_setting('central.release_reason', TRUE), ''), 'released');
+        allocation := OLD;
+    END IF;
+
+    INSERT INTO allocation_events (type, semester, faculty, program, room_id, room, adapted)
+    SELECT event_type, allocation.semester, allocation.faculty, allocation.program, r.id, r.name, allocation.adapted
+    FROM rooms r
+    WHERE r.id = allocation.room_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Booking events cannot be told apart once the columns are gone
+ALTER TABLE allocation_events DISABLE TRIGGER allocation_events_append_only;
+DELETE FROM allocation_events WHERE weekday IS NOT NULL;
+ALTER TABLE allocation_events ENABLE TRIGGER allocation_events_append_only;
+
+ALTER TABLE allocation_events
+    DROP COLUMN IF EXISTS hours,
+    DROP COLUMN IF EXISTS weekday;
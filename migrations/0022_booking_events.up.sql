@@ -0,0 +1,50 @@
+-- Time blocks are recorded too, with their weekday and hours (NULL for rooms
+-- held the whole semester)
+ALTER TABLE allocation_events
+    ADD COLUMN IF NOT EXISTS weekday INT,
+    ADD COLUMN IF NOT EXISTS hours INT4RANGE;
+
+-- Record changes to room_bookings with the same events as room_allocations
+CREATE OR REPLACE FUNCTION record_allocation_event() RETURNS TRIGGER AS $$
+DECLARE
+    event_type TEXT;
+    allocation RECORD;
+    _weekday INT;
+    _hours INT4RANGE;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        event_type := 'offered';
+        allocation := NEW;
+    ELSIF TG_OP = 'UPDATE' THEN
+        IF OLD.faculty <> NEW.faculty OR OLD.program <> NEW.program THEN
+            event_type := 'swapped';
+        ELSIF OLD.state = NEW.state THEN
+            RETURN NULL;
+        ELSE
+            event_type := CASE WHEN NEW.state = 'locked' THEN 'confirmed' ELSE 'unlocked' END;
+        END IF;
+
+        allocation := NEW;
+    ELSE
+        event_type := COALESCE(NULLIF(current_setting('central.release_reason', TRUE), ''), 'released');
+        allocation := OLD;
+    END IF;
+
+    IF TG_TABLE_NAME = 'room_bookings' THEN
+        _weekday := allocation.weekday;
+        _hours := allocation.hours;
+    END IF;
+
+    INSERT INTO allocation_events (type, semester, faculty, program, room_id, room, adapted, weekday, hours)
+    SELECT event_type, allocation.semester, allocation.faculty, allocation.program, r.id, r.name, allocation.adapted, _weekday, _hours
+    FROM rooms r
+    WHERE r.id = allocation.room_id;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER record_booking_events
+AFTER INSERT OR UPDATE OR DELETE ON room_bookings
+FOR EACH ROW
+EXECUTE FUNCTION record_allocation_event();
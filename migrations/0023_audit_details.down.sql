@@ -0,0 +1,5 @@
+-- Drop the detail columns
+ALTER TABLE audit_log
+    DROP COLUMN IF EXISTS room,
+    DROP COLUMN IF EXISTS program,
+    DROP COLUMN IF EXISTS semester;
@@ -0,0 +1,5 @@
+-- Admin changes record what they touched
+ALTER TABLE audit_log
+    ADD COLUMN IF NOT EXISTS semester TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS program TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS room TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,8 @@
+-- Restore the function counting upcoming outages
+CREATE OR REPLACE FUNCTION room_in_service(_semester TEXT, _room_id INT) RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM room_blocks WHERE semester = _semester AND room_id = _room_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM room_outages WHERE room_id = _room_id AND ends_at > now()
+    );
+$$ LANGUAGE sql STABLE;
@@ -0,0 +1,9 @@
+-- Outages only keep rooms out of service once they start; an outage months
+-- away must not take the room now
+CREATE OR REPLACE FUNCTION room_in_service(_semester TEXT, _room_id INT) RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1 FROM room_blocks WHERE semester = _semester AND room_id = _room_id
+    ) AND NOT EXISTS (
+        SELECT 1 FROM room_outages WHERE room_id = _room_id AND starts_at <= now() AND ends_at > now()
+    );
+$$ LANGUAGE sql STABLE;
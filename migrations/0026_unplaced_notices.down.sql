@@ -0,0 +1,6 @@
+-- Drop the unplaced notices
+ALTER TABLE room_bookings
+    DROP COLUMN IF EXISTS unplaced;
+
+ALTER TABLE room_allocations
+    DROP COLUMN IF EXISTS unplaced;
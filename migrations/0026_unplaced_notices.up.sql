@@ -0,0 +1,7 @@
+-- Remember which holders were told no equivalent room was free for their room
+-- out of service, so the reaper does not tell them again on every run
+ALTER TABLE room_allocations
+    ADD COLUMN IF NOT EXISTS unplaced BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE room_bookings
+    ADD COLUMN IF NOT EXISTS unplaced BOOLEAN NOT NULL DEFAULT FALSE;
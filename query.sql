@@ -1,20 +1,574 @@
 -- name: GenerateRooms :exec
 SELECT generate_rooms($1, $2);
 
--- name: LockRooms :exec
-SELECT lock_rooms($1, $2);
+-- name: LockRooms :one
+SELECT lock_rooms($1, $2, $3);
 
--- name: AllocateClassrooms :exec
-SELECT allocate_classrooms($1, $2, $3, $4);
+-- name: ReleaseRooms :many
+SELECT release_rooms($1, $2)::TEXT AS name;
 
--- name: AllocateLaboratories :exec
-SELECT allocate_laboratories($1, $2, $3, $4);
+-- name: CancelRooms :many
+SELECT cancel_rooms($1, $2, $3)::TEXT AS name;
+
+-- name: ShrinkRooms :many
+SELECT shrink_rooms($1, $2, $3, $4, $5)::TEXT AS name;
+
+-- name: LockProgramRooms :exec
+UPDATE room_allocations
+SET state = 'locked'
+WHERE faculty = $1
+    AND semester = $2
+    AND program = $3
+    AND state = 'awaiting'
+    AND offered_at = now();
+
+-- name: ExpireRooms :many
+SELECT semester, faculty, program, name
+FROM expire_rooms($1);
+
+-- name: AllocateClassrooms :one
+SELECT allocate_classrooms($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: AllocateLaboratories :one
+SELECT allocate_laboratories($1, $2, $3, $4, $5, $6, $7, $8, $9);
 
 -- name: GetRoomsByFacultyProgramSemester :many
-SELECT r.id, r.name, r.type, ra.adapted
+SELECT r.id, r.name, r.type, r.building, ra.adapted
 FROM rooms r
 JOIN room_allocations ra
     ON r.id = ra.room_id
 WHERE ra.faculty = $1
     AND ra.program = $2
     AND ra.semester = $3;
+
+-- name: GetProcessedRequest :one
+SELECT type, response
+FROM processed_requests
+WHERE identity = $1
+    AND request_id = $2
+    AND created_at > $3;
+
+-- name: SaveProcessedRequest :execrows
+INSERT INTO processed_requests (identity, request_id, type, response)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (identity, request_id) DO UPDATE
+SET type = EXCLUDED.type,
+    response = EXCLUDED.response,
+    created_at = now()
+WHERE processed_requests.created_at <= $5;
+
+-- name: ListFreeRooms :many
+SELECT id, name, type, capacity, building, floor, equipment
+FROM rooms
+WHERE room_is_free($1, id)
+ORDER BY id;
+
+-- name: CountAvailableRooms :one
+SELECT
+    COUNT(*) FILTER (WHERE r.type = 'classroom')::INT AS classrooms,
+    COUNT(*) FILTER (WHERE r.type = 'laboratory')::INT AS laboratories
+FROM rooms r
+WHERE room_is_free($1, r.id);
+
+-- name: AddToWaitlist :exec
+INSERT INTO waitlist (semester, faculty, program, classrooms, laboratories, min_capacity, equipment, building)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: RemoveFromWaitlist :exec
+DELETE FROM waitlist
+WHERE faculty = $1
+    AND semester = $2;
+
+-- name: ProcessWaitlist :many
+SELECT faculty, program, classrooms, laboratories
+FROM process_waitlist($1, $2, $3);
+
+-- name: GetWaitlistByFacultySemester :many
+SELECT w.program, w.classrooms, w.laboratories, w.created_at,
+    (SELECT COUNT(*) FROM waitlist o WHERE o.semester = w.semester AND o.id <= w.id)::INT AS position
+FROM waitlist w
+WHERE w.faculty = $1
+    AND w.semester = $2
+ORDER BY w.id;
+
+-- name: ScheduleProgram :one
+SELECT schedule_program($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);
+
+-- name: GetBookingsByFacultyProgramSemester :many
+SELECT r.name, r.type, b.weekday, lower(b.hours)::INT AS start_hour, upper(b.hours)::INT AS end_hour, b.adapted
+FROM room_bookings b
+JOIN rooms r
+    ON r.id = b.room_id
+WHERE b.faculty = $1
+    AND b.program = $2
+    AND b.semester = $3
+ORDER BY b.weekday, lower(b.hours), r.name;
+
+-- name: LockBookings :execrows
+UPDATE room_bookings
+SET state = 'locked'
+WHERE faculty = sqlc.arg(faculty)
+    AND semester = sqlc.arg(semester)
+    AND state = 'awaiting'
+    AND offered_at > now() - sqlc.arg(hold)::INTERVAL;
+
+-- name: ReleaseBookings :many
+WITH released AS (
+    DELETE FROM room_bookings
+    WHERE faculty = $1
+        AND semester = $2
+        AND state = 'awaiting'
+    RETURNING room_id
+)
+SELECT DISTINCT r.name
+FROM released
+JOIN rooms r
+    ON r.id = released.room_id;
+
+-- name: CancelBookings :many
+WITH released AS (
+    DELETE FROM room_bookings
+    WHERE faculty = sqlc.arg(faculty)
+        AND semester = sqlc.arg(semester)
+        AND (sqlc.arg(program)::TEXT = '' OR program = sqlc.arg(program)::TEXT)
+        AND state = 'locked'
+    RETURNING room_id
+)
+SELECT DISTINCT r.name
+FROM released
+JOIN rooms r
+    ON r.id = released.room_id;
+
+-- name: ExpireBookings :many
+WITH expired AS (
+    DELETE FROM room_bookings
+    WHERE state = 'awaiting'
+        AND offered_at < now() - sqlc.arg(hold)::INTERVAL
+    RETURNING semester, faculty, program, room_id
+)
+SELECT DISTINCT e.semester, e.faculty, e.program, r.name
+FROM expired e
+JOIN rooms r
+    ON r.id = e.room_id;
+
+-- name: SetReleaseReason :exec
+SELECT set_config('central.release_reason', $1, TRUE);
+
+-- name: GetEventsUntil :many
+SELECT id, occurred_at, type, semester, faculty, program, room_id, room, adapted, weekday, hours
+FROM allocation_events
+WHERE occurred_at <= sqlc.arg(until)
+    AND (sqlc.arg(semester)::TEXT = '' OR semester = sqlc.arg(semester)::TEXT)
+ORDER BY id;
+
+-- name: AddAuditRecord :exec
+INSERT INTO audit_log (identity, request_id, faculty, type, payload_hash, outcome, error, latency_us, semester, program, room)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: GetAuditRecords :many
+SELECT id, created_at, identity, request_id, faculty, type, payload_hash, outcome, error, latency_us, semester, program, room
+FROM audit_log
+WHERE (sqlc.arg(faculty)::TEXT = '' OR faculty = sqlc.arg(faculty)::TEXT)
+    AND (sqlc.arg(type)::TEXT = '' OR type = sqlc.arg(type)::TEXT)
+    AND (sqlc.arg(outcome)::TEXT = '' OR outcome = sqlc.arg(outcome)::TEXT)
+    AND (sqlc.narg(since)::TIMESTAMPTZ IS NULL OR created_at >= sqlc.narg(since)::TIMESTAMPTZ)
+ORDER BY id DESC
+LIMIT NULLIF(sqlc.arg(max_records)::INT, 0);
+
+-- name: CreateSemester :execrows
+INSERT INTO semesters (name, state)
+VALUES ($1, $2)
+ON CONFLICT (name) DO NOTHING;
+
+-- name: GetSemesterState :one
+SELECT state
+FROM semesters
+WHERE name = $1
+FOR SHARE;
+
+-- name: UpdateSemesterState :execrows
+UPDATE semesters
+SET state = sqlc.arg(state), updated_at = now()
+WHERE name = sqlc.arg(name)
+    AND state::TEXT = ANY(sqlc.arg(from_states)::TEXT[]);
+
+-- name: CloseRooms :many
+WITH released AS (
+    DELETE FROM room_allocations
+    WHERE semester = $1
+        AND state = 'awaiting'
+    RETURNING semester, faculty, program, room_id
+)
+SELECT DISTINCT e.semester, e.faculty, e.program, r.name
+FROM released e
+JOIN rooms r
+    ON r.id = e.room_id;
+
+-- name: CloseBookings :many
+WITH released AS (
+    DELETE FROM room_bookings
+    WHERE semester = $1
+        AND state = 'awaiting'
+    RETURNING semester, faculty, program, room_id
+)
+SELECT DISTINCT e.semester, e.faculty, e.program, r.name
+FROM released e
+JOIN rooms r
+    ON r.id = e.room_id;
+
+-- name: ClearWaitlist :exec
+DELETE FROM waitlist
+WHERE semester = $1;
+
+-- name: RegisterFaculty :exec
+INSERT INTO faculties (name)
+VALUES ($1)
+ON CONFLICT (name) DO NOTHING;
+
+-- name: RegisterProgram :exec
+INSERT INTO programs (faculty, name)
+VALUES ($1, $2)
+ON CONFLICT (faculty, name) DO NOTHING;
+
+-- name: FacultyExists :one
+SELECT EXISTS (
+    SELECT 1
+    FROM faculties
+    WHERE name = $1
+);
+
+-- name: GetFacultyPrograms :many
+SELECT name
+FROM programs
+WHERE faculty = $1
+ORDER BY name;
+
+-- name: SetFacultyQuota :exec
+INSERT INTO faculty_quotas (semester, faculty, classrooms, laboratories)
+VALUES ($1, $2, $3, $4)
+ON CONFLICT (semester, faculty) DO UPDATE
+SET classrooms = EXCLUDED.classrooms,
+    laboratories = EXCLUDED.laboratories;
+
+-- name: GetFacultyQuota :one
+SELECT classrooms, laboratories
+FROM faculty_quotas
+WHERE semester = $1
+    AND faculty = $2
+FOR UPDATE;
+
+-- name: GetFacultyUsage :one
+WITH held AS (
+    SELECT
+        COUNT(*) FILTER (WHERE r.type = 'classroom' AND NOT ra.adapted) AS classrooms,
+        COUNT(*) FILTER (WHERE r.type = 'laboratory' OR ra.adapted) AS laboratories
+    FROM room_allocations ra
+    JOIN rooms r
+        ON r.id = ra.room_id
+    WHERE ra.semester = sqlc.arg(semester)
+        AND ra.faculty = sqlc.arg(faculty)
+), booked AS (
+    SELECT
+        COUNT(*) FILTER (WHERE r.type = 'classroom' AND NOT rb.adapted) AS classrooms,
+        COUNT(*) FILTER (WHERE r.type = 'laboratory' OR rb.adapted) AS laboratories
+    FROM room_bookings rb
+    JOIN rooms r
+        ON r.id = rb.room_id
+    WHERE rb.semester = sqlc.arg(semester)
+        AND rb.faculty = sqlc.arg(faculty)
+), waiting AS (
+    SELECT
+        COALESCE(SUM(w.classrooms), 0) AS classrooms,
+        COALESCE(SUM(w.laboratories), 0) AS laboratories
+    FROM waitlist w
+    WHERE w.semester = sqlc.arg(semester)
+        AND w.faculty = sqlc.arg(faculty)
+)
+SELECT (held.classrooms + booked.classrooms + waiting.classrooms)::INT AS classrooms,
+    (held.laboratories + booked.laboratories + waiting.laboratories)::INT AS laboratories
+FROM held, booked, waiting;
+
+-- name: GetRoomsByFacultySemester :many
+SELECT ra.room_id, r.name, r.type, ra.adapted, ra.program, ra.state
+FROM room_allocations ra
+JOIN rooms r
+    ON r.id = ra.room_id
+WHERE ra.faculty = $1
+    AND ra.semester = $2
+ORDER BY ra.program, ra.id;
+
+-- name: OfferRoom :execrows
+INSERT INTO room_allocations (state, room_id, semester, faculty, program, adapted)
+SELECT 'awaiting', r.id, sqlc.arg(semester), sqlc.arg(faculty), sqlc.arg(program), sqlc.arg(adapted)
+FROM rooms r
+WHERE r.id = sqlc.arg(room_id)
+    AND room_is_free(sqlc.arg(semester), r.id)
+FOR UPDATE SKIP LOCKED
+ON CONFLICT (semester, room_id) DO NOTHING;
+
+-- name: CreateSwap :one
+INSERT INTO swaps (semester, proposer, recipient, program)
+VALUES ($1, $2, $3, $4)
+RETURNING id, created_at;
+
+-- name: AddSwapRoom :exec
+INSERT INTO swap_rooms (swap_id, room_id, faculty)
+VALUES ($1, $2, $3);
+
+-- name: GetSwap :one
+SELECT id, semester, proposer, recipient, program, state, created_at
+FROM swaps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: GetSwapRooms :many
+SELECT sr.room_id, r.name, sr.faculty
+FROM swap_rooms sr
+JOIN rooms r
+    ON r.id = sr.room_id
+WHERE sr.swap_id = $1
+ORDER BY sr.room_id;
+
+-- name: ListPendingSwaps :many
+SELECT id, semester, proposer, recipient, program, state, created_at
+FROM swaps
+WHERE semester = sqlc.arg(semester)
+    AND (proposer = sqlc.arg(faculty) OR recipient = sqlc.arg(faculty))
+    AND state = 'pending'
+ORDER BY id;
+
+-- name: DecideSwap :exec
+UPDATE swaps
+SET state = $2, decided_at = now()
+WHERE id = $1;
+
+-- name: TransferRooms :execrows
+UPDATE room_allocations
+SET faculty = sqlc.arg(recipient), program = sqlc.arg(program)
+WHERE semester = sqlc.arg(semester)
+    AND faculty = sqlc.arg(faculty)
+    AND state = 'locked'
+    AND room_id = ANY(sqlc.arg(rooms)::INT[]);
+
+-- name: GetRoomByName :one
+SELECT id, name, type
+FROM rooms
+WHERE name = $1
+ORDER BY id
+LIMIT 1
+FOR UPDATE;
+
+-- name: LockRoom :exec
+UPDATE room_allocations
+SET state = 'locked'
+WHERE semester = $1
+    AND room_id = $2;
+
+-- name: RevokeRoom :one
+DELETE FROM room_allocations
+WHERE semester = $1
+    AND room_id = $2
+RETURNING faculty, program, adapted;
+
+-- name: BlockRoom :exec
+INSERT INTO room_blocks (semester, room_id, reason)
+VALUES ($1, $2, $3)
+ON CONFLICT (semester, room_id) DO UPDATE
+SET reason = EXCLUDED.reason;
+
+-- name: UnblockRoom :execrows
+DELETE FROM room_blocks
+WHERE semester = $1
+    AND room_id = $2;
+
+-- name: CreateOutage :one
+INSERT INTO room_outages (room_id, starts_at, ends_at, reason)
+VALUES ($1, $2, $3, $4)
+RETURNING id;
+
+-- name: GetOutageConflicts :many
+SELECT a.semester, a.faculty, a.program, a.state
+FROM room_allocations a
+JOIN semesters s ON s.name = a.semester
+WHERE a.room_id = $1
+    AND s.state <> 'archived'
+UNION
+SELECT b.semester, b.faculty, b.program, b.state
+FROM room_bookings b
+JOIN semesters s ON s.name = b.semester
+WHERE b.room_id = $1
+    AND s.state <> 'archived'
+ORDER BY semester, faculty, program;
+
+-- name: ListOutages :many
+SELECT o.id, r.name, o.starts_at, o.ends_at, o.reason
+FROM room_outages o
+JOIN rooms r ON r.id = o.room_id
+WHERE sqlc.arg(all)::BOOLEAN OR o.ends_at > now()
+ORDER BY o.starts_at, o.id;
+
+-- name: DeleteOutage :one
+DELETE FROM room_outages o
+USING rooms r
+WHERE o.id = $1
+    AND r.id = o.room_id
+RETURNING o.id, r.name, o.starts_at, o.ends_at, o.reason;
+
+-- name: GetOutOfServiceAllocations :many
+SELECT a.room_id, r.name, r.type, a.faculty, a.program, a.state, a.adapted, a.offered_at
+FROM room_allocations a
+JOIN rooms r ON r.id = a.room_id
+WHERE a.semester = sqlc.arg(semester)
+    AND NOT room_in_service(a.semester, a.room_id)
+    AND (sqlc.arg(room)::TEXT = '' OR r.name = sqlc.arg(room))
+ORDER BY a.faculty, a.program, a.room_id
+FOR UPDATE OF a;
+
+-- name: FindRelocationRoom :one
+SELECT r.id, r.name, r.type
+FROM rooms r
+JOIN rooms old ON old.id = sqlc.arg(room_id)
+WHERE (r.type = 'classroom' OR sqlc.arg(laboratory)::BOOLEAN)
+    AND r.capacity >= old.capacity
+    AND r.equipment @> old.equipment
+    AND room_is_free(sqlc.arg(semester), r.id)
+ORDER BY
+    ((r.type = 'laboratory') = sqlc.arg(laboratory)::BOOLEAN) DESC,
+    (r.building = old.building) DESC,
+    r.id
+LIMIT 1
+FOR UPDATE OF r SKIP LOCKED;
+
+-- name: PeekSemesterState :one
+SELECT state
+FROM semesters
+WHERE name = $1;
+
+-- name: PeekFacultyQuota :one
+SELECT classrooms, laboratories
+FROM faculty_quotas
+WHERE semester = $1
+    AND faculty = $2;
+
+-- name: CountLockedProgramRooms :one
+SELECT COUNT(*)::INT
+FROM room_allocations
+WHERE faculty = $1
+    AND semester = $2
+    AND program = $3
+    AND state = 'locked';
+
+-- name: ListRoomsInService :many
+SELECT id, name, type, capacity, building, floor, equipment
+FROM rooms
+WHERE room_in_service($1, id)
+ORDER BY id;
+
+-- name: ListWaitlistSemesters :many
+SELECT DISTINCT semester
+FROM waitlist
+ORDER BY semester;
+
+-- name: RelocateRoom :execrows
+INSERT INTO room_allocations (state, room_id, semester, faculty, program, adapted, offered_at)
+SELECT 'awaiting', r.id, sqlc.arg(semester), sqlc.arg(faculty), sqlc.arg(program), sqlc.arg(adapted), sqlc.arg(offered_at)
+FROM rooms r
+WHERE r.id = sqlc.arg(room_id)
+    AND room_is_free(sqlc.arg(semester), r.id)
+FOR UPDATE SKIP LOCKED
+ON CONFLICT (semester, room_id) DO NOTHING;
+
+-- name: GetOutOfServiceBookings :many
+SELECT b.id, b.room_id, r.name, r.type, b.faculty, b.program, b.state, b.adapted,
+    b.weekday, lower(b.hours)::INT AS start_hour, upper(b.hours)::INT AS end_hour, b.offered_at
+FROM room_bookings b
+JOIN rooms r ON r.id = b.room_id
+WHERE b.semester = sqlc.arg(semester)
+    AND NOT room_in_service(b.semester, b.room_id)
+    AND (sqlc.arg(room)::TEXT = '' OR r.name = sqlc.arg(room))
+ORDER BY b.faculty, b.program, b.weekday, lower(b.hours), b.room_id
+FOR UPDATE OF b;
+
+-- name: FindRelocationBlock :one
+SELECT r.id, r.name, r.type
+FROM rooms r
+JOIN rooms old ON old.id = sqlc.arg(room_id)
+WHERE (r.type = 'classroom' OR sqlc.arg(laboratory)::BOOLEAN)
+    AND r.capacity >= old.capacity
+    AND r.equipment @> old.equipment
+    AND room_offerable(sqlc.arg(semester), r.id)
+    AND NOT EXISTS (
+        SELECT 1 FROM room_allocations a WHERE a.semester = sqlc.arg(semester) AND a.room_id = r.id
+    )
+    AND NOT EXISTS (
+        SELECT 1
+        FROM room_bookings b
+        WHERE b.semester = sqlc.arg(semester)
+            AND b.room_id = r.id
+            AND b.weekday = sqlc.arg(weekday)
+            AND b.hours && int4range(sqlc.arg(start_hour), sqlc.arg(end_hour))
+    )
+ORDER BY
+    ((r.type = 'laboratory') = sqlc.arg(laboratory)::BOOLEAN) DESC,
+    (r.building = old.building) DESC,
+    r.id
+LIMIT 1
+FOR UPDATE OF r SKIP LOCKED;
+
+-- name: RevokeBooking :exec
+DELETE FROM room_bookings
+WHERE id = $1;
+
+-- name: RelocateBooking :one
+INSERT INTO room_bookings (state, room_id, semester, faculty, program, weekday, hours, adapted, offered_at)
+VALUES ('awaiting', sqlc.arg(room_id), sqlc.arg(semester), sqlc.arg(faculty), sqlc.arg(program), sqlc.arg(weekday),
+    int4range(sqlc.arg(start_hour), sqlc.arg(end_hour)), sqlc.arg(adapted), sqlc.arg(offered_at))
+RETURNING id;
+
+-- name: LockBooking :exec
+UPDATE room_bookings
+SET state = 'locked'
+WHERE id = $1;
+
+-- name: GetOutOfServiceSemesters :many
+SELECT a.semester
+FROM room_allocations a
+JOIN semesters s ON s.name = a.semester
+WHERE s.state <> 'archived'
+    AND NOT room_in_service(a.semester, a.room_id)
+UNION
+SELECT b.semester
+FROM room_bookings b
+JOIN semesters s ON s.name = b.semester
+WHERE s.state <> 'archived'
+    AND NOT room_in_service(b.semester, b.room_id)
+ORDER BY semester;
+
+-- name: MarkRoomUnplaced :execrows
+UPDATE room_allocations
+SET unplaced = TRUE
+WHERE semester = $1
+    AND room_id = $2
+    AND NOT unplaced;
+
+-- name: MarkBookingUnplaced :execrows
+UPDATE room_bookings
+SET unplaced = TRUE
+WHERE id = $1
+    AND NOT unplaced;
+
+-- name: ClearUnplacedRooms :exec
+UPDATE room_allocations
+SET unplaced = FALSE
+WHERE unplaced
+    AND room_in_service(semester, room_id);
+
+-- name: ClearUnplacedBookings :exec
+UPDATE room_bookings
+SET unplaced = FALSE
+WHERE unplaced
+    AND room_in_service(semester, room_id);
+
+-- name: DeleteProcessedRequests :execrows
+DELETE FROM processed_requests
+WHERE created_at <= $1;